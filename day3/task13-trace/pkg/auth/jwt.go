@@ -0,0 +1,296 @@
+// Package auth verifies and mints the JWT bearer tokens used by the
+// services. HS256 with a shared secret and RS256 with keys from a JWKS file
+// are supported; every other algorithm, including "none", is rejected.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// leeway absorbs clock skew between the token issuer and the service.
+const leeway = time.Minute
+
+var (
+	ErrInvalidToken = errors.New("auth: invalid token")
+	ErrTokenExpired = errors.New("auth: token expired")
+)
+
+type Claims struct {
+	Subject string
+	// Tenant is the account whose data the token gives access to, from the
+	// private "tenant" claim.
+	Tenant string
+	Scopes []string
+	Roles  []string
+	// SharedGroups are the IDs of groups the subject may read without a
+	// role, from the private "shared_groups" claim.
+	SharedGroups []string
+	Issuer       string
+	Audience     []string
+	ExpiresAt    time.Time
+	NotBefore    time.Time
+	IssuedAt     time.Time
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// payload is the wire form of Claims. Scopes travel as the space-separated
+// OAuth 2.0 "scope" claim; the "scp" array some issuers use is read too.
+type payload struct {
+	Sub    string          `json:"sub"`
+	Tenant string          `json:"tenant,omitempty"`
+	Roles  []string        `json:"roles,omitempty"`
+	Shared []string        `json:"shared_groups,omitempty"`
+	Scope  string          `json:"scope,omitempty"`
+	Scp    []string        `json:"scp,omitempty"`
+	Iss    string          `json:"iss,omitempty"`
+	Aud    json.RawMessage `json:"aud,omitempty"`
+	Exp    json.Number     `json:"exp,omitempty"`
+	Nbf    json.Number     `json:"nbf,omitempty"`
+	Iat    json.Number     `json:"iat,omitempty"`
+}
+
+func (p *payload) claims() (*Claims, error) {
+	c := &Claims{Subject: p.Sub, Tenant: p.Tenant, Roles: p.Roles, SharedGroups: p.Shared, Issuer: p.Iss, Scopes: strings.Fields(p.Scope)}
+	c.Scopes = append(c.Scopes, p.Scp...)
+
+	if len(p.Aud) > 0 {
+		var one string
+		if err := json.Unmarshal(p.Aud, &one); err == nil {
+			c.Audience = []string{one}
+		} else if err := json.Unmarshal(p.Aud, &c.Audience); err != nil {
+			return nil, fmt.Errorf("%w: aud: %v", ErrInvalidToken, err)
+		}
+	}
+
+	for _, t := range []struct {
+		name  string
+		value json.Number
+		dst   *time.Time
+	}{
+		{"exp", p.Exp, &c.ExpiresAt},
+		{"nbf", p.Nbf, &c.NotBefore},
+		{"iat", p.Iat, &c.IssuedAt},
+	} {
+		if t.value == "" {
+			continue
+		}
+		secs, err := t.value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidToken, t.name, err)
+		}
+		*t.dst = time.Unix(int64(secs), 0)
+	}
+	return c, nil
+}
+
+func newPayload(c *Claims) *payload {
+	p := &payload{Sub: c.Subject, Tenant: c.Tenant, Roles: c.Roles, Shared: c.SharedGroups, Scope: strings.Join(c.Scopes, " "), Iss: c.Issuer}
+	switch len(c.Audience) {
+	case 0:
+	case 1:
+		p.Aud, _ = json.Marshal(c.Audience[0])
+	default:
+		p.Aud, _ = json.Marshal(c.Audience)
+	}
+	for _, t := range []struct {
+		value time.Time
+		dst   *json.Number
+	}{
+		{c.ExpiresAt, &p.Exp},
+		{c.NotBefore, &p.Nbf},
+		{c.IssuedAt, &p.Iat},
+	} {
+		if !t.value.IsZero() {
+			*t.dst = json.Number(fmt.Sprint(t.value.Unix()))
+		}
+	}
+	return p
+}
+
+// Verifier checks token signatures and the time, issuer and audience
+// claims. Tokens must carry a subject and an expiry.
+type Verifier struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewVerifier accepts HS256 tokens when secret is set and RS256 tokens when
+// keys is non-empty. Empty issuer or audience skip the respective check.
+func NewVerifier(secret []byte, keys map[string]*rsa.PublicKey, issuer, audience string) (*Verifier, error) {
+	if len(secret) == 0 && len(keys) == 0 {
+		return nil, errors.New("auth: neither an HS256 secret nor RS256 keys are configured")
+	}
+	return &Verifier{secret: secret, keys: keys, issuer: issuer, audience: audience, now: time.Now}, nil
+}
+
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch {
+	case h.Alg == HS256 && len(v.secret) > 0:
+		if !hmac.Equal(sig, hmacSHA256(v.secret, signed)) {
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case h.Alg == RS256 && len(v.keys) > 0:
+		key, err := v.key(h.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	default:
+		return nil, fmt.Errorf("%w: algorithm %q is not accepted", ErrInvalidToken, h.Alg)
+	}
+
+	var p payload
+	if err := decodeSegment(parts[1], &p); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	claims, err := p.claims()
+	if err != nil {
+		return nil, err
+	}
+	if err := v.check(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) key(kid string) (*rsa.PublicKey, error) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+func (v *Verifier) check(c *Claims) error {
+	now := v.now()
+	switch {
+	case c.Subject == "":
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	case c.ExpiresAt.IsZero():
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	case now.After(c.ExpiresAt.Add(leeway)):
+		return ErrTokenExpired
+	case !c.NotBefore.IsZero() && now.Add(leeway).Before(c.NotBefore):
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	case v.issuer != "" && c.Issuer != v.issuer:
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, c.Issuer)
+	}
+	if v.audience != "" {
+		for _, aud := range c.Audience {
+			if aud == v.audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: audience does not include %q", ErrInvalidToken, v.audience)
+	}
+	return nil
+}
+
+func SignHS256(c *Claims, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("auth: empty HS256 secret")
+	}
+	signed, err := encode(&header{Alg: HS256, Typ: "JWT"}, newPayload(c))
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(hmacSHA256(secret, []byte(signed))), nil
+}
+
+// SignRS256 signs with key; kid names the matching entry of the JWKS the
+// verifier loads and may be empty when that JWKS holds a single key.
+func SignRS256(c *Claims, key *rsa.PrivateKey, kid string) (string, error) {
+	signed, err := encode(&header{Alg: RS256, Typ: "JWT", Kid: kid}, newPayload(c))
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func encode(h *header, p *payload) (string, error) {
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	pb, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(pb), nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func hmacSHA256(secret, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
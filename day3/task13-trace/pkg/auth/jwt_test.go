@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rsaOnce sync.Once
+	rsaKeys [2]*rsa.PrivateKey
+)
+
+func testRSAKeys(t *testing.T) [2]*rsa.PrivateKey {
+	rsaOnce.Do(func() {
+		for i := range rsaKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rsaKeys[i] = key
+		}
+	})
+	return rsaKeys
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		Subject:   "user-1",
+		Tenant:    "tenant-1",
+		Scopes:    []string{"contacts:read"},
+		Issuer:    "https://issuer.example",
+		Audience:  []string{"contacts"},
+		ExpiresAt: testNow.Add(time.Hour),
+		IssuedAt:  testNow,
+	}
+}
+
+// unsigned encodes a token with the given header and a signature made by
+// sign over the signing input.
+func unsigned(t *testing.T, h *header, c *Claims, sign func(signed []byte) []byte) string {
+	signed, err := encode(h, newPayload(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func newTestVerifier(t *testing.T, secret []byte, keys map[string]*rsa.PublicKey) *Verifier {
+	v, err := NewVerifier(secret, keys, "https://issuer.example", "contacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	keys := testRSAKeys(t)
+	jwks := map[string]*rsa.PublicKey{"k1": &keys[0].PublicKey, "k2": &keys[1].PublicKey}
+	hsOnly := newTestVerifier(t, testSecret, nil)
+	rsOnly := newTestVerifier(t, nil, jwks)
+	both := newTestVerifier(t, testSecret, jwks)
+	single := newTestVerifier(t, nil, map[string]*rsa.PublicKey{"k1": &keys[0].PublicKey})
+
+	hs := func(c *Claims) string {
+		token, err := SignHS256(c, testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	rs := func(c *Claims, key *rsa.PrivateKey, kid string) string {
+		token, err := SignRS256(c, key, kid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	with := func(change func(c *Claims)) *Claims {
+		c := testClaims()
+		change(c)
+		return c
+	}
+	// The classic confusion attack signs HS256 with the RSA public key.
+	publicKeyMAC := func(signed []byte) []byte { return hmacSHA256(keys[0].PublicKey.N.Bytes(), signed) }
+	noSignature := func(signed []byte) []byte { return nil }
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		want     error
+	}{
+		{"HS256", hsOnly, hs(testClaims()), nil},
+		{"RS256 by kid", rsOnly, rs(testClaims(), keys[1], "k2"), nil},
+		{"RS256 without kid from a single key", single, rs(testClaims(), keys[0], ""), nil},
+		{"HS256 and RS256 configured", both, hs(testClaims()), nil},
+
+		{"alg none", hsOnly, unsigned(t, &header{Alg: "none"}, testClaims(), noSignature), ErrInvalidToken},
+		{"alg None", both, unsigned(t, &header{Alg: "None"}, testClaims(), noSignature), ErrInvalidToken},
+		{"alg empty", both, unsigned(t, &header{}, testClaims(), noSignature), ErrInvalidToken},
+		{"HS512", hsOnly, unsigned(t, &header{Alg: "HS512"}, testClaims(), func(signed []byte) []byte { return hmacSHA256(testSecret, signed) }), ErrInvalidToken},
+		{"HS256 with the public key to RS256 only", rsOnly, unsigned(t, &header{Alg: HS256, Kid: "k1"}, testClaims(), publicKeyMAC), ErrInvalidToken},
+		{"HS256 with the public key to both", both, unsigned(t, &header{Alg: HS256, Kid: "k1"}, testClaims(), publicKeyMAC), ErrInvalidToken},
+		{"RS256 to HS256 only", hsOnly, rs(testClaims(), keys[0], "k1"), ErrInvalidToken},
+		{"RS256 signed by another key", rsOnly, rs(testClaims(), keys[0], "k2"), ErrInvalidToken},
+		{"unknown kid", rsOnly, rs(testClaims(), keys[0], "k3"), ErrInvalidToken},
+		{"no kid among several keys", rsOnly, rs(testClaims(), keys[0], ""), ErrInvalidToken},
+		{"wrong secret", hsOnly, unsigned(t, &header{Alg: HS256}, testClaims(), func(signed []byte) []byte { return hmacSHA256([]byte("other"), signed) }), ErrInvalidToken},
+
+		{"expired within leeway", hsOnly, hs(with(func(c *Claims) { c.ExpiresAt = testNow.Add(-leeway + time.Second) })), nil},
+		{"expired", hsOnly, hs(with(func(c *Claims) { c.ExpiresAt = testNow.Add(-leeway - time.Second) })), ErrTokenExpired},
+		{"not yet valid within leeway", hsOnly, hs(with(func(c *Claims) { c.NotBefore = testNow.Add(leeway - time.Second) })), nil},
+		{"not yet valid", hsOnly, hs(with(func(c *Claims) { c.NotBefore = testNow.Add(leeway + time.Second) })), ErrInvalidToken},
+		{"missing sub", hsOnly, hs(with(func(c *Claims) { c.Subject = "" })), ErrInvalidToken},
+		{"missing exp", hsOnly, hs(with(func(c *Claims) { c.ExpiresAt = time.Time{} })), ErrInvalidToken},
+		{"wrong issuer", hsOnly, hs(with(func(c *Claims) { c.Issuer = "https://evil.example" })), ErrInvalidToken},
+		{"wrong audience", hsOnly, hs(with(func(c *Claims) { c.Audience = []string{"billing"} })), ErrInvalidToken},
+		{"one of several audiences", hsOnly, hs(with(func(c *Claims) { c.Audience = []string{"billing", "contacts"} })), nil},
+
+		{"malformed", hsOnly, "not.a-token", ErrInvalidToken},
+		{"bad signature encoding", hsOnly, hs(testClaims()) + "!", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Verify(tt.token)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.Subject != "user-1" || claims.Tenant != "tenant-1" || !claims.HasScope("contacts:read") {
+					t.Errorf("claims = %+v", claims)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTamperedClaims(t *testing.T) {
+	v := newTestVerifier(t, testSecret, nil)
+	token, err := SignHS256(testClaims(), testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := testClaims()
+	admin.Roles = []string{"admin"}
+	forged, err := encode(&header{Alg: HS256, Typ: "JWT"}, newPayload(admin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	if _, err := v.Verify(forged + "." + parts[2]); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify with swapped claims = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyReadsScopeAndScp(t *testing.T) {
+	v := newTestVerifier(t, testSecret, nil)
+	p := newPayload(testClaims())
+	p.Scope, p.Scp = "contacts:read", []string{"groups:read"}
+	signed, err := encode(&header{Alg: HS256}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := v.Verify(signed + "." + base64.RawURLEncoding.EncodeToString(hmacSHA256(testSecret, []byte(signed))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.HasScope("contacts:read") || !claims.HasScope("groups:read") {
+		t.Errorf("Scopes = %v, want both scope and scp", claims.Scopes)
+	}
+}
+
+func TestNewVerifierNeedsKeys(t *testing.T) {
+	if _, err := NewVerifier(nil, nil, "", ""); err == nil {
+		t.Error("NewVerifier without a secret or keys succeeded")
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys := testRSAKeys(t)
+	data, err := MarshalJWKS("k1", &keys[0].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed["k1"]; got == nil || !got.Equal(&keys[0].PublicKey) {
+		t.Errorf("ParseJWKS(MarshalJWKS) = %v", parsed)
+	}
+
+	n := base64.RawURLEncoding.EncodeToString(keys[0].PublicKey.N.Bytes())
+	tests := []struct {
+		name    string
+		jwks    string
+		kids    []string
+		wantErr bool
+	}{
+		{"skips other keys", `{"keys": [
+			{"kty": "EC", "kid": "ec", "n": "` + n + `", "e": "AQAB"},
+			{"kty": "RSA", "use": "enc", "kid": "enc", "n": "` + n + `", "e": "AQAB"},
+			{"kty": "RSA", "alg": "RS512", "kid": "rs512", "n": "` + n + `", "e": "AQAB"},
+			{"kty": "RSA", "kid": "sig", "n": "` + n + `", "e": "AQAB"}]}`, []string{"sig"}, false},
+		{"no signing keys", `{"keys": [{"kty": "RSA", "use": "enc", "kid": "enc", "n": "` + n + `", "e": "AQAB"}]}`, nil, true},
+		{"duplicate kid", `{"keys": [{"kty": "RSA", "kid": "a", "n": "` + n + `", "e": "AQAB"}, {"kty": "RSA", "kid": "a", "n": "` + n + `", "e": "AQAB"}]}`, nil, true},
+		{"exponent too small", `{"keys": [{"kty": "RSA", "kid": "a", "n": "` + n + `", "e": "AQ"}]}`, nil, true},
+		{"bad modulus", `{"keys": [{"kty": "RSA", "kid": "a", "n": "!", "e": "AQAB"}]}`, nil, true},
+		{"not JSON", `keys`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseJWKS([]byte(tt.jwks))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJWKS error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(parsed) != len(tt.kids) {
+				t.Fatalf("ParseJWKS = %d keys, want %v", len(parsed), tt.kids)
+			}
+			for _, kid := range tt.kids {
+				if parsed[kid] == nil {
+					t.Errorf("key %q missing", kid)
+				}
+			}
+		})
+	}
+}
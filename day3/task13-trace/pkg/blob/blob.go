@@ -0,0 +1,47 @@
+// Package blob stores opaque objects by key. Store is the interface the
+// services use; FSStore keeps the objects in a local directory, and other
+// backends such as object storage implement the same interface.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob: not found")
+	ErrInvalidKey = errors.New("blob: invalid key")
+)
+
+// Store keeps objects under slash-separated keys such as "a/b/c". Put
+// replaces an object whole: readers see either the old or the new one.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns ErrNotFound if there is no object under key. The caller
+	// closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds if there is no object under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key is made of non-empty segments of letters,
+// digits, '-', '_' and '.' that do not start with '.', so that every
+// backend can store it as is.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg[0] == '.' {
+			return false
+		}
+		for _, r := range seg {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+				return false
+			}
+		}
+	}
+	return true
+}
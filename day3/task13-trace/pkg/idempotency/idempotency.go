@@ -0,0 +1,77 @@
+// Package idempotency lets clients retry non-idempotent requests safely:
+// the first response for an Idempotency-Key is stored with a fingerprint
+// of the request and replayed for retries with the same key.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// PendingTimeout is how long a key stays claimed by a request that has not
+// completed. After it, the request is presumed lost and a retry may claim
+// the key again.
+const PendingTimeout = time.Minute
+
+// MaxKeyLength bounds the Idempotency-Key header.
+const MaxKeyLength = 255
+
+type Record struct {
+	TenantID    string
+	Key         string
+	Fingerprint string
+	// Status is zero while the first request is still running.
+	Status    int
+	Header    http.Header
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Claim is one request's hold on a key. A request presumed lost after
+// PendingTimeout may still finish once another has claimed the key over;
+// its Complete and Release then find their claim gone and change nothing.
+type Claim struct {
+	TenantID    string
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+// Store keeps records per tenant and key.
+type Store interface {
+	// Begin claims key for a request with fingerprint until now+ttl. It
+	// returns the claim when the caller now owns the key, and otherwise
+	// the record that holds it. Expired records and ones pending for
+	// longer than PendingTimeout are claimed over.
+	Begin(ctx context.Context, tenantID, key, fingerprint string, now time.Time, ttl time.Duration) (*Claim, *Record, error)
+	// Complete stores the response of the request holding claim.
+	Complete(ctx context.Context, claim *Claim, status int, header http.Header, body []byte) error
+	// Release gives up a claim without a response to replay.
+	Release(ctx context.Context, claim *Claim) error
+	// Purge deletes the records expired at now.
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Fingerprint identifies a request by method, path and body. JSON bodies
+// are compacted first, so a retry need not reproduce the whitespace.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		h.Write(compact.Bytes())
+	} else {
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
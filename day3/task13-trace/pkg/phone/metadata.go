@@ -0,0 +1,128 @@
+package phone
+
+// region describes the numbering plan of one country. Only the parts needed
+// for parsing, E.164 normalization and coarse type detection are kept.
+type region struct {
+	code           string
+	countryCode    string
+	trunkPrefix    string
+	intlPrefixes   []string
+	nationalLength []int
+	// leading digits that identify the region when several regions share a
+	// country code, e.g. Kazakhstan inside +7.
+	leadingDigits  []string
+	mobilePrefixes []string
+	tollFree       []string
+}
+
+var regions = []*region{
+	{
+		code:           "KZ",
+		countryCode:    "7",
+		trunkPrefix:    "8",
+		intlPrefixes:   []string{"810", "00"},
+		nationalLength: []int{10},
+		leadingDigits:  []string{"6", "7"},
+		mobilePrefixes: []string{"700", "701", "702", "705", "706", "707", "708", "747", "771", "775", "776", "777", "778"},
+		tollFree:       []string{"800"},
+	},
+	{
+		code:           "RU",
+		countryCode:    "7",
+		trunkPrefix:    "8",
+		intlPrefixes:   []string{"810", "00"},
+		nationalLength: []int{10},
+		mobilePrefixes: []string{"9"},
+		tollFree:       []string{"800"},
+	},
+	{
+		code:           "US",
+		countryCode:    "1",
+		trunkPrefix:    "1",
+		intlPrefixes:   []string{"011"},
+		nationalLength: []int{10},
+		tollFree:       []string{"800", "833", "844", "855", "866", "877", "888"},
+	},
+	{
+		code:           "GB",
+		countryCode:    "44",
+		trunkPrefix:    "0",
+		intlPrefixes:   []string{"00"},
+		nationalLength: []int{9, 10},
+		mobilePrefixes: []string{"7"},
+		tollFree:       []string{"800", "808"},
+	},
+	{
+		code:           "DE",
+		countryCode:    "49",
+		trunkPrefix:    "0",
+		intlPrefixes:   []string{"00"},
+		nationalLength: []int{6, 7, 8, 9, 10, 11},
+		mobilePrefixes: []string{"15", "16", "17"},
+		tollFree:       []string{"800"},
+	},
+	{
+		code:           "UA",
+		countryCode:    "380",
+		trunkPrefix:    "0",
+		intlPrefixes:   []string{"00"},
+		nationalLength: []int{9},
+		mobilePrefixes: []string{"39", "50", "63", "66", "67", "68", "73", "91", "92", "93", "94", "95", "96", "97", "98", "99"},
+		tollFree:       []string{"800"},
+	},
+	{
+		code:           "UZ",
+		countryCode:    "998",
+		trunkPrefix:    "",
+		intlPrefixes:   []string{"00"},
+		nationalLength: []int{9},
+		mobilePrefixes: []string{"33", "50", "77", "88", "90", "91", "93", "94", "95", "97", "98", "99"},
+	},
+	{
+		code:           "KG",
+		countryCode:    "996",
+		trunkPrefix:    "0",
+		intlPrefixes:   []string{"00"},
+		nationalLength: []int{9},
+		mobilePrefixes: []string{"22", "5", "7", "88", "99"},
+	},
+}
+
+func regionByCode(code string) *region {
+	for _, r := range regions {
+		if r.code == code {
+			return r
+		}
+	}
+	return nil
+}
+
+// regionForNumber picks the region for a national number within a country
+// code; regions with leadingDigits are checked before the catch-all one.
+func regionForNumber(countryCode, national string) *region {
+	var fallback *region
+	for _, r := range regions {
+		if r.countryCode != countryCode {
+			continue
+		}
+		if len(r.leadingDigits) == 0 {
+			if fallback == nil {
+				fallback = r
+			}
+			continue
+		}
+		if hasAnyPrefix(national, r.leadingDigits) {
+			return r
+		}
+	}
+	return fallback
+}
+
+func (r *region) validLength(national string) bool {
+	for _, n := range r.nationalLength {
+		if len(national) == n {
+			return true
+		}
+	}
+	return false
+}
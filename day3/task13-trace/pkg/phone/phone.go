@@ -0,0 +1,190 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Type int
+
+const (
+	Unknown Type = iota
+	Mobile
+	Landline
+	TollFree
+)
+
+func (t Type) String() string {
+	switch t {
+	case Mobile:
+		return "mobile"
+	case Landline:
+		return "landline"
+	case TollFree:
+		return "toll_free"
+	default:
+		return "unknown"
+	}
+}
+
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+var (
+	ErrInvalidNumber = errors.New("phone: invalid number")
+	ErrUnknownRegion = errors.New("phone: unknown region")
+)
+
+const (
+	minInternationalLength = 8
+	maxInternationalLength = 15
+)
+
+type Number struct {
+	CountryCode string
+	National    string
+	Region      string
+	Type        Type
+}
+
+func (n *Number) E164() string {
+	return "+" + n.CountryCode + n.National
+}
+
+func (n *Number) String() string {
+	return n.E164()
+}
+
+// Parser turns free-form numbers into E.164. Numbers written without a
+// leading "+" or an international prefix are read in the default region.
+type Parser struct {
+	region *region
+}
+
+func NewParser(defaultRegion string) (*Parser, error) {
+	r := regionByCode(strings.ToUpper(defaultRegion))
+	if r == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRegion, defaultRegion)
+	}
+	return &Parser{region: r}, nil
+}
+
+func (p *Parser) DefaultRegion() string {
+	return p.region.code
+}
+
+func (p *Parser) Normalize(s string) (string, error) {
+	n, err := p.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return n.E164(), nil
+}
+
+func (p *Parser) Parse(s string) (*Number, error) {
+	s = strings.TrimSpace(s)
+	international := strings.HasPrefix(s, "+")
+
+	var b strings.Builder
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == '+' && i == 0:
+		case strings.ContainsRune(" ()-./", c):
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidNumber, c)
+		}
+	}
+	digits := b.String()
+	if digits == "" {
+		return nil, ErrInvalidNumber
+	}
+
+	if international {
+		return parseInternational(digits)
+	}
+	return p.parseNational(digits)
+}
+
+func (p *Parser) parseNational(digits string) (*Number, error) {
+	r := p.region
+	// The trunk prefix is tried first: in regions with national numbers of
+	// several lengths, such as DE, "030 1234567" is valid with it too.
+	switch {
+	case r.trunkPrefix != "" && strings.HasPrefix(digits, r.trunkPrefix) &&
+		r.validLength(digits[len(r.trunkPrefix):]):
+		return newNumber(r.countryCode, digits[len(r.trunkPrefix):]), nil
+	case r.validLength(digits):
+		return newNumber(r.countryCode, digits), nil
+	case strings.HasPrefix(digits, r.countryCode) && r.validLength(digits[len(r.countryCode):]):
+		// Written internationally but without the "+", e.g. 77011234567.
+		return newNumber(r.countryCode, digits[len(r.countryCode):]), nil
+	}
+	for _, prefix := range r.intlPrefixes {
+		if strings.HasPrefix(digits, prefix) {
+			return parseInternational(digits[len(prefix):])
+		}
+	}
+	return nil, ErrInvalidNumber
+}
+
+func parseInternational(digits string) (*Number, error) {
+	for size := 1; size <= 3 && size < len(digits); size++ {
+		cc, national := digits[:size], digits[size:]
+		r := regionForNumber(cc, national)
+		if r == nil {
+			continue
+		}
+		if r.trunkPrefix != "" && strings.HasPrefix(national, r.trunkPrefix) &&
+			r.validLength(national[len(r.trunkPrefix):]) {
+			// "+44 (0)20 ..." style numbers keep the trunk prefix.
+			national = national[len(r.trunkPrefix):]
+			r = regionForNumber(cc, national)
+		}
+		if r == nil || !r.validLength(national) {
+			return nil, ErrInvalidNumber
+		}
+		return newNumber(cc, national), nil
+	}
+
+	// Country codes without metadata are accepted as long as the length is
+	// plausible for E.164; the split between code and number stays unknown.
+	if len(digits) < minInternationalLength || len(digits) > maxInternationalLength {
+		return nil, ErrInvalidNumber
+	}
+	return &Number{National: digits}, nil
+}
+
+func newNumber(countryCode, national string) *Number {
+	n := &Number{CountryCode: countryCode, National: national}
+	if r := regionForNumber(countryCode, national); r != nil {
+		n.Region = r.code
+		n.Type = r.classify(national)
+	}
+	return n
+}
+
+func (r *region) classify(national string) Type {
+	switch {
+	case hasAnyPrefix(national, r.tollFree):
+		return TollFree
+	case len(r.mobilePrefixes) == 0:
+		return Unknown
+	case hasAnyPrefix(national, r.mobilePrefixes):
+		return Mobile
+	default:
+		return Landline
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
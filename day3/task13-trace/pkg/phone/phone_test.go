@@ -0,0 +1,108 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		region string
+		input  string
+		e164   string
+		code   string
+		typ    Type
+	}{
+		{"RU", "+7 (912) 345-67-89", "+79123456789", "RU", Mobile},
+		{"RU", "8 912 345 67 89", "+79123456789", "RU", Mobile},
+		{"RU", "912.345.67.89", "+79123456789", "RU", Mobile},
+		{"RU", "79123456789", "+79123456789", "RU", Mobile},
+		{"RU", "8 (812) 123-45-67", "+78121234567", "RU", Landline},
+		{"RU", "8121234567", "+78121234567", "RU", Landline},
+		{"RU", "8 800 555-35-35", "+78005553535", "RU", TollFree},
+		{"RU", "+7 701 123 45 67", "+77011234567", "KZ", Mobile},
+		{"RU", "810 44 20 7946 0958", "+442079460958", "GB", Landline},
+		{"RU", "00 49 30 1234567", "+49301234567", "DE", Landline},
+		{"KZ", "8 701 123 45 67", "+77011234567", "KZ", Mobile},
+		{"KZ", "8 912 345 67 89", "+79123456789", "RU", Mobile},
+		{"US", "(212) 555-0100", "+12125550100", "US", Unknown},
+		{"US", "1 800 555 0100", "+18005550100", "US", TollFree},
+		{"US", "011 44 7911 123456", "+447911123456", "GB", Mobile},
+		{"GB", "020 7946 0958", "+442079460958", "GB", Landline},
+		{"GB", "+44 (0)20 7946 0958", "+442079460958", "GB", Landline},
+		{"GB", "07911 123456", "+447911123456", "GB", Mobile},
+		{"DE", "030/1234567", "+49301234567", "DE", Landline},
+		{"DE", "030 12345678", "+493012345678", "DE", Landline},
+		{"DE", "+49 (0)151 23456789", "+4915123456789", "DE", Mobile},
+		{"UA", "050 123 4567", "+380501234567", "UA", Mobile},
+		{"UZ", "90 123 45 67", "+998901234567", "UZ", Mobile},
+		{"KG", "0555 123 456", "+996555123456", "KG", Mobile},
+		{"RU", "+86 138 0013 8000", "+8613800138000", "", Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region+" "+tt.input, func(t *testing.T) {
+			p, err := NewParser(tt.region)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := p.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if n.E164() != tt.e164 || n.Region != tt.code || n.Type != tt.typ {
+				t.Errorf("Parse = %s %q %v, want %s %q %v", n.E164(), n.Region, n.Type, tt.e164, tt.code, tt.typ)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	p, err := NewParser("RU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{
+		"",
+		"   ",
+		"abc",
+		"+7 912 345",
+		"12345",
+		"++79123456789",
+		"8 912 345 67 89 ext. 1",
+		"+44 20 7946",
+		"+999 1234",
+		"+1234567890123456",
+	} {
+		if n, err := p.Parse(input); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("Parse(%q) = %v, %v, want ErrInvalidNumber", input, n, err)
+		}
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	p, err := NewParser("gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DefaultRegion() != "GB" {
+		t.Errorf("DefaultRegion = %q, want GB", p.DefaultRegion())
+	}
+	if _, err := NewParser("XX"); !errors.Is(err, ErrUnknownRegion) {
+		t.Errorf("NewParser(XX) = %v, want ErrUnknownRegion", err)
+	}
+}
+
+func TestNormalizeIsIdempotent(t *testing.T) {
+	for _, region := range []string{"RU", "KZ", "US", "GB", "DE", "UA", "UZ", "KG"} {
+		p, err := NewParser(region)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range []string{"+79123456789", "+442079460958", "+49301234567", "+12125550100", "+998901234567"} {
+			got, err := p.Normalize(input)
+			if err != nil || got != input {
+				t.Errorf("%s: Normalize(%q) = %q, %v", region, input, got, err)
+			}
+		}
+	}
+}
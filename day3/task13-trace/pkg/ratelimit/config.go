@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Route limits the requests matching Pattern, "[METHOD ]PATH" as in
+// http.ServeMux. A PATH ending in "/" matches every path below it.
+type Route struct {
+	Pattern string
+	Limit   Limit
+}
+
+// Config is the JSON file read by LoadConfig, e.g.
+//
+//	{
+//	  "Default": {"Requests": 600, "Per": "1m", "Burst": 100},
+//	  "Routes": [
+//	    {"Pattern": "POST /contacts/import", "Limit": {"Requests": 10, "Per": "1h"}},
+//	    {"Pattern": "GET /contacts/", "Limit": {"Requests": 300, "Per": "1m"}}
+//	  ],
+//	  "TrustForwardedFor": true
+//	}
+//
+// The first matching route applies, and every route has buckets of its
+// own; other requests share the Default buckets, or are not limited when
+// Default is null. A request takes from the bucket of its client IP and,
+// once authenticated, from that of its API key or token subject too.
+// TrustForwardedFor takes the client IP from the last X-Forwarded-For
+// entry, which only a proxy in front of the service may set.
+type Config struct {
+	Default           *Limit
+	Routes            []Route
+	TrustForwardedFor bool
+}
+
+func DefaultConfig() *Config {
+	return &Config{Default: &Limit{Requests: 600, Per: time.Minute, Burst: 100}}
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("rate limit config %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("rate limit config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c *Config) Validate() error {
+	if c.Default != nil {
+		if err := c.Default.Validate(); err != nil {
+			return fmt.Errorf("Default: %w", err)
+		}
+	}
+	for _, route := range c.Routes {
+		if _, path := splitPattern(route.Pattern); !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("route %q: path must start with /", route.Pattern)
+		}
+		if err := route.Limit.Validate(); err != nil {
+			return fmt.Errorf("route %q: %w", route.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match returns the limit for a request and the name of the bucket set it
+// uses; ok is false when the request is not limited.
+func (c *Config) Match(method, path string) (name string, limit Limit, ok bool) {
+	for _, route := range c.Routes {
+		m, p := splitPattern(route.Pattern)
+		if m != "" && m != method {
+			continue
+		}
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return route.Pattern, route.Limit, true
+		}
+	}
+	if c.Default != nil {
+		return "default", *c.Default, true
+	}
+	return "", Limit{}, false
+}
+
+func splitPattern(pattern string) (method, path string) {
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		return method, strings.TrimSpace(path)
+	}
+	return "", pattern
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled,
+// which are indistinguishable from new ones.
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	Bucket
+	fullAt time.Time
+}
+
+// MemoryStore keeps buckets in the process. Every instance of a service
+// limits on its own, so the effective limit grows with the instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	nextSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		for k, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{Bucket: limit.NewBucket(now)}
+		s.buckets[key] = b
+	}
+	r := limit.Take(&b.Bucket, now)
+	b.fullAt = limit.FullAt(b.Bucket)
+	return r, nil
+}
+
@@ -0,0 +1,141 @@
+// Package ratelimit implements token bucket rate limiting. Buckets live in
+// a Store so that several service instances can share them; MemoryStore
+// keeps them in the process.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Per on average and bursts of up to Burst
+// requests, which defaults to Requests. In JSON, Per is a duration string
+// such as "1m".
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l Limit) Validate() error {
+	switch {
+	case l.Requests <= 0:
+		return errors.New("ratelimit: Requests must be positive")
+	case l.Per <= 0:
+		return errors.New("ratelimit: Per must be positive")
+	case l.Burst < 0:
+		return errors.New("ratelimit: Burst must not be negative")
+	}
+	return nil
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate is the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// String formats the limit as a RateLimit-Policy item, e.g. "100;w=60".
+func (l Limit) String() string {
+	s := fmt.Sprintf("%d;w=%d", l.Requests, int64(math.Ceil(l.Per.Seconds())))
+	if l.Burst > 0 && l.Burst != l.Requests {
+		s += fmt.Sprintf(";burst=%d", l.Burst)
+	}
+	return s
+}
+
+func (l *Limit) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Requests int
+		Per      string
+		Burst    int
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	per, err := time.ParseDuration(raw.Per)
+	if err != nil {
+		return fmt.Errorf("ratelimit: Per: %w", err)
+	}
+	*l = Limit{Requests: raw.Requests, Per: per, Burst: raw.Burst}
+	return nil
+}
+
+func (l Limit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Requests int
+		Per      string
+		Burst    int `json:",omitempty"`
+	}{l.Requests, l.Per.String(), l.Burst})
+}
+
+// Bucket is the state a Store keeps per key.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket capacity and Remaining the whole tokens left.
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again and RetryAfter,
+	// for denied requests, the time until a token is available.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// NewBucket returns a full bucket for l.
+func (l Limit) NewBucket(now time.Time) Bucket {
+	return Bucket{Tokens: l.capacity(), Updated: now}
+}
+
+// Take refills b for the time passed since its last update and removes a
+// token if there is one. Stores call it while holding the bucket.
+func (l Limit) Take(b *Bucket, now time.Time) Result {
+	capacity, rate := l.capacity(), l.rate()
+	if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*rate)
+		b.Updated = now
+	}
+
+	r := Result{Limit: int(capacity)}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+	r.Remaining = int(b.Tokens)
+	r.Reset = seconds((capacity - b.Tokens) / rate)
+	return r
+}
+
+// FullAt is when b will have refilled completely; a store may forget the
+// bucket from then on.
+func (l Limit) FullAt(b Bucket) time.Time {
+	return b.Updated.Add(seconds((l.capacity() - b.Tokens) / l.rate()))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store holds buckets. Take must take a token from the bucket at key
+// atomically, starting with a full bucket for unknown keys; a shared
+// backend would do this in a single round trip, e.g. with a script.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxJSONBodySize     = 1 << 20
+	maxRecordedResponse = 1 << 20
+)
+
+// Middleware rejects requests whose query parameters or JSON bodies do not
+// match the documented operation with 400 before they reach next. Requests
+// without a documented operation are passed through for the mux to answer.
+//
+// With checkResponses set, every response is also compared with the
+// document and mismatches are logged. It is meant for development and CI
+// runs, where it shows handlers drifting away from the spec.
+func (s *Spec) Middleware(next http.Handler, logger *log.Logger, checkResponses bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt := s.find(r.Method, r.URL.Path)
+		if rt == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		errs, err := s.validateRequest(w, r, rt.op)
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if len(errs) > 0 {
+			http.Error(w, "invalid request: "+strings.Join(errs, "; "), http.StatusBadRequest)
+			return
+		}
+
+		if !checkResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if errs := s.validateResponse(rt.op, rec); len(errs) > 0 {
+			logger.Printf("%s %s: response does not match %s %s in the OpenAPI spec: %s\n",
+				r.Method, r.URL.Path, rt.method, rt.template, strings.Join(errs, "; "))
+		}
+	})
+}
+
+func (s *Spec) validateRequest(w http.ResponseWriter, r *http.Request, op *operation) ([]string, error) {
+	var errs []string
+
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		value := query.Get(p.Name)
+		if value == "" {
+			if p.Required {
+				errs = append(errs, fmt.Sprintf("query parameter %s: is required", p.Name))
+			}
+			continue
+		}
+		if p.Schema != nil {
+			s.validate(queryValue(value, p.Schema), p.Schema, "query parameter "+p.Name, &errs)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return errs, nil
+	}
+	mt := op.RequestBody.Content["application/json"]
+	if mt == nil || mt.Schema == nil {
+		return errs, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJSONBodySize))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			errs = append(errs, "body: is required")
+		}
+		return errs, nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+	s.validate(v, mt.Schema, "body", &errs)
+	return errs, nil
+}
+
+// queryValue converts a query string value to the JSON type its schema
+// expects, so that the schema check reports type errors. Values that do not
+// convert stay strings and fail that check.
+func queryValue(value string, sch *schema) interface{} {
+	switch sch.Type {
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	}
+	return value
+}
+
+func (s *Spec) validateResponse(op *operation, rec *recorder) []string {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return []string{fmt.Sprintf("status %d is not documented", status)}
+	}
+
+	if len(resp.Content) == 0 {
+		if rec.size > 0 {
+			return []string{fmt.Sprintf("status %d is documented without a body", status)}
+		}
+		return nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	mt := resp.Content[contentType]
+	if mt == nil {
+		return []string{fmt.Sprintf("content type %q is not documented for status %d", contentType, status)}
+	}
+	if contentType != "application/json" || mt.Schema == nil || rec.truncated {
+		return nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(rec.body.Bytes()))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return []string{"invalid JSON body: " + err.Error()}
+	}
+	var errs []string
+	s.validate(v, mt.Schema, "body", &errs)
+	return errs
+}
+
+// recorder passes a response through while keeping its status and the
+// first maxRecordedResponse bytes of its body.
+type recorder struct {
+	http.ResponseWriter
+	status    int
+	size      int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.size += len(p)
+	if rec.body.Len()+len(p) <= maxRecordedResponse {
+		rec.body.Write(p)
+	} else {
+		rec.truncated = true
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *recorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
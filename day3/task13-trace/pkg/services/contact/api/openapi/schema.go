@@ -0,0 +1,214 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// schema is the subset of the OpenAPI 3.0 schema object that the document
+// uses. Keywords outside of it are ignored.
+type schema struct {
+	Ref                  string `json:"$ref"`
+	Type                 string
+	Nullable             bool
+	Enum                 []interface{}
+	Properties           map[string]*schema
+	Required             []string
+	AdditionalProperties *additionalProperties
+	Items                *schema
+	OneOf                []*schema
+	MinLength            *int
+	MaxLength            *int
+	MinItems             *int
+	MaxItems             *int
+	Minimum              *float64
+	Maximum              *float64
+}
+
+// additionalProperties is either a boolean or a schema.
+type additionalProperties struct {
+	allowed bool
+	schema  *schema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+func (s *Spec) resolve(sch *schema) (*schema, error) {
+	if sch.Ref == "" {
+		return sch, nil
+	}
+	resolved := s.schemas[strings.TrimPrefix(sch.Ref, "#/components/schemas/")]
+	if resolved == nil {
+		return nil, fmt.Errorf("unknown schema %s", sch.Ref)
+	}
+	return resolved, nil
+}
+
+func (s *Spec) checkRefs(sch *schema, seen map[*schema]bool) error {
+	if sch == nil || seen[sch] {
+		return nil
+	}
+	seen[sch] = true
+	sch, err := s.resolve(sch)
+	if err != nil {
+		return err
+	}
+	children := append([]*schema{sch.Items}, sch.OneOf...)
+	for _, prop := range sch.Properties {
+		children = append(children, prop)
+	}
+	if sch.AdditionalProperties != nil {
+		children = append(children, sch.AdditionalProperties.schema)
+	}
+	for _, child := range children {
+		if err := s.checkRefs(child, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks a value decoded with json.Decoder.UseNumber and appends
+// one message per violation, prefixed with the location of the value.
+func (s *Spec) validate(v interface{}, sch *schema, path string, errs *[]string) {
+	sch, err := s.resolve(sch)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s: %v", path, err))
+		return
+	}
+
+	if v == nil {
+		if !sch.Nullable && sch.Type != "" {
+			*errs = append(*errs, fmt.Sprintf("%s: must not be null", path))
+		}
+		return
+	}
+
+	if len(sch.OneOf) > 0 {
+		matched := 0
+		for _, alt := range sch.OneOf {
+			var altErrs []string
+			s.validate(v, alt, path, &altErrs)
+			if len(altErrs) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			*errs = append(*errs, fmt.Sprintf("%s: must match exactly one of %d schemas, matched %d", path, len(sch.OneOf), matched))
+		}
+	}
+
+	if len(sch.Enum) > 0 {
+		found := false
+		for _, e := range sch.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: must be one of %v", path, sch.Enum))
+		}
+	}
+
+	switch sch.Type {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: must be an object", path))
+			return
+		}
+		s.validateObject(obj, sch, path, errs)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: must be an array", path))
+			return
+		}
+		if sch.MinItems != nil && len(arr) < *sch.MinItems {
+			*errs = append(*errs, fmt.Sprintf("%s: must have at least %d items", path, *sch.MinItems))
+		}
+		if sch.MaxItems != nil && len(arr) > *sch.MaxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: must have at most %d items", path, *sch.MaxItems))
+		}
+		if sch.Items != nil {
+			for i, item := range arr {
+				s.validate(item, sch.Items, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: must be a string", path))
+			return
+		}
+		n := utf8.RuneCountInString(str)
+		if sch.MinLength != nil && n < *sch.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at least %d characters", path, *sch.MinLength))
+		}
+		if sch.MaxLength != nil && n > *sch.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at most %d characters", path, *sch.MaxLength))
+		}
+	case "integer", "number":
+		num, ok := v.(json.Number)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: must be a %s", path, sch.Type))
+			return
+		}
+		if _, err := num.Int64(); sch.Type == "integer" && err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: must be an integer", path))
+			return
+		}
+		f, _ := num.Float64()
+		if sch.Minimum != nil && f < *sch.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at least %v", path, *sch.Minimum))
+		}
+		if sch.Maximum != nil && f > *sch.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at most %v", path, *sch.Maximum))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: must be a boolean", path))
+		}
+	default:
+		*errs = append(*errs, fmt.Sprintf("%s: unsupported schema type %q", path, sch.Type))
+	}
+}
+
+func (s *Spec) validateObject(obj map[string]interface{}, sch *schema, path string, errs *[]string) {
+	for _, name := range sch.Required {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s.%s: is required", path, name))
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := sch.Properties[name]; ok {
+			s.validate(obj[name], prop, path+"."+name, errs)
+			continue
+		}
+		switch extra := sch.AdditionalProperties; {
+		case extra == nil:
+		case !extra.allowed:
+			*errs = append(*errs, fmt.Sprintf("%s.%s: is not a known property", path, name))
+		case extra.schema != nil:
+			s.validate(obj[name], extra.schema, path+"."+name, errs)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package openapi serves the OpenAPI 3 document of the contact service and
+// validates HTTP traffic against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+type Spec struct {
+	raw     []byte
+	schemas map[string]*schema
+	routes  []*route
+}
+
+type route struct {
+	method   string
+	template string
+	pattern  *regexp.Regexp
+	literal  bool
+	op       *operation
+}
+
+type operation struct {
+	OperationID string
+	Parameters  []*parameter
+	RequestBody *requestBody
+	Responses   map[string]*response
+}
+
+type parameter struct {
+	Ref      string `json:"$ref"`
+	Name     string
+	In       string
+	Required bool
+	Schema   *schema
+}
+
+type requestBody struct {
+	Required bool
+	Content  map[string]*mediaType
+}
+
+type response struct {
+	Ref     string `json:"$ref"`
+	Content map[string]*mediaType
+}
+
+type mediaType struct {
+	Schema *schema
+}
+
+type pathItem struct {
+	Get    *operation
+	Put    *operation
+	Post   *operation
+	Delete *operation
+	Patch  *operation
+}
+
+func (p *pathItem) operations() map[string]*operation {
+	ops := map[string]*operation{}
+	for method, op := range map[string]*operation{
+		http.MethodGet:    p.Get,
+		http.MethodPut:    p.Put,
+		http.MethodPost:   p.Post,
+		http.MethodDelete: p.Delete,
+		http.MethodPatch:  p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// Load parses the embedded document and resolves its component references.
+func Load() (*Spec, error) {
+	return Parse(document)
+}
+
+// Parse is Load for a document other than the embedded one.
+func Parse(data []byte) (*Spec, error) {
+	var doc struct {
+		Paths      map[string]*pathItem
+		Components struct {
+			Schemas    map[string]*schema
+			Parameters map[string]*parameter
+			Responses  map[string]*response
+		}
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	s := &Spec{raw: data, schemas: doc.Components.Schemas}
+	var errs []error
+	for template, item := range doc.Paths {
+		pattern, literal := compileTemplate(template)
+		for method, op := range item.operations() {
+			for i, p := range op.Parameters {
+				if p.Ref == "" {
+					continue
+				}
+				resolved := doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+				if resolved == nil {
+					errs = append(errs, fmt.Errorf("%s %s: unknown parameter %s", method, template, p.Ref))
+					continue
+				}
+				op.Parameters[i] = resolved
+			}
+			for code, resp := range op.Responses {
+				if resp.Ref == "" {
+					continue
+				}
+				resolved := doc.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")]
+				if resolved == nil {
+					errs = append(errs, fmt.Errorf("%s %s: unknown response %s", method, template, resp.Ref))
+					continue
+				}
+				op.Responses[code] = resolved
+			}
+			s.routes = append(s.routes, &route{method: method, template: template, pattern: pattern, literal: literal, op: op})
+		}
+	}
+	for _, r := range s.routes {
+		for _, sch := range r.op.schemas() {
+			if err := s.checkRefs(sch, map[*schema]bool{}); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", r.method, r.template, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("openapi: %w", errors.Join(errs...))
+	}
+
+	// Literal paths win over templated ones, as with http.ServeMux.
+	sort.SliceStable(s.routes, func(i, j int) bool {
+		if s.routes[i].literal != s.routes[j].literal {
+			return s.routes[i].literal
+		}
+		if s.routes[i].template != s.routes[j].template {
+			return s.routes[i].template < s.routes[j].template
+		}
+		return s.routes[i].method < s.routes[j].method
+	})
+	return s, nil
+}
+
+func (op *operation) schemas() []*schema {
+	var schemas []*schema
+	for _, p := range op.Parameters {
+		schemas = append(schemas, p.Schema)
+	}
+	if op.RequestBody != nil {
+		for _, mt := range op.RequestBody.Content {
+			schemas = append(schemas, mt.Schema)
+		}
+	}
+	for _, resp := range op.Responses {
+		for _, mt := range resp.Content {
+			schemas = append(schemas, mt.Schema)
+		}
+	}
+	return schemas
+}
+
+var templateParam = regexp.MustCompile(`\{[^/{}]+\}`)
+
+func compileTemplate(template string) (*regexp.Regexp, bool) {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range templateParam.FindAllStringIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), last == 0
+}
+
+func (s *Spec) find(method, path string) *route {
+	for _, r := range s.routes {
+		if r.method == method && r.pattern.MatchString(path) {
+			return r
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the document itself, for GET /openapi.json.
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.raw)
+}
+
+// CheckRoutes compares ServeMux patterns such as "GET /contacts/{file}" or
+// "/groups" with the documented operations and reports every route that is
+// served but not documented, and every operation that nothing serves.
+func (s *Spec) CheckRoutes(patterns []string) error {
+	type served struct {
+		method string
+		path   string
+	}
+	var routes []served
+	var errs []error
+	for _, pattern := range patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			method, path = "", pattern
+		}
+		path = routeKey(strings.TrimSpace(path))
+		routes = append(routes, served{method: method, path: path})
+
+		documented := false
+		for _, r := range s.routes {
+			if routeKey(r.template) == path && (method == "" || method == r.method) {
+				documented = true
+				break
+			}
+		}
+		if !documented {
+			errs = append(errs, fmt.Errorf("route %q is not documented", pattern))
+		}
+	}
+
+	for _, r := range s.routes {
+		handled := false
+		for _, sr := range routes {
+			if sr.path == routeKey(r.template) && (sr.method == "" || sr.method == r.method) {
+				handled = true
+				break
+			}
+		}
+		if !handled {
+			errs = append(errs, fmt.Errorf("%s %s is documented but not served", r.method, r.template))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// routeKey reduces a path to a form where ServeMux wildcards and OpenAPI
+// templates compare equal: every segment holding a parameter becomes "{}".
+func routeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.Contains(seg, "{") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -1,45 +1,308 @@
 package main
 
 import (
+    "context"
+    "crypto/rsa"
+    "expvar"
     "fmt"
     "log"
+    "net"
     "net/http"
     "os"
     "os/signal"
+    "strconv"
+    "sync"
     "syscall"
+    "time"
+    _ "time/tzdata"
 
+    "go/pkg/auth"
+    "go/pkg/phone"
+    "go/pkg/services/contact/api/openapi"
     "go/pkg/services/contact/internal"
-    "go/pkg/services/contact/internal/delivery"
-    "go/pkg/services/contact/internal/repository"
+    "go/pkg/services/contact/internal/events"
     "go/pkg/services/contact/internal/usecase"
+    "go/pkg/store/postgresql"
 
     _ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
-    contactRepo := internal.NewContactRepository()
-    groupRepo := internal.NewGroupRepository()
+    db, err := postgresql.Connect(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"),
+        os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+    if err != nil {
+        log.Fatal("Could not connect to PostgreSQL: ", err)
+    }
+    defer db.Close()
 
-    contactUseCase := internal.NewContactUseCase(contactRepo)
-    groupUseCase := internal.NewGroupUseCase(groupRepo)
+    if err := postgresql.Migrate(db); err != nil {
+        log.Fatal("Could not migrate database: ", err)
+    }
 
-    contactHandler := internal.NewContactHandler(contactUseCase)
-    groupHandler := internal.NewGroupHandler(groupUseCase)
+    logger := log.New(os.Stdout, "", log.LstdFlags)
 
-    http.HandleFunc("/contacts", contactHandler.HandleHTTP)
-    http.HandleFunc("/groups", groupHandler.HandleHTTP)
+    // Numbers are normalized on write; cmd/normalize-phones rewrites the
+    // ones stored before that, and must run with the same region.
+    phoneRegion := os.Getenv("PHONE_DEFAULT_REGION")
+    if phoneRegion == "" {
+        phoneRegion = "KZ"
+    }
+    phones, err := phone.NewParser(phoneRegion)
+    if err != nil {
+        log.Fatal("Invalid PHONE_DEFAULT_REGION: ", err)
+    }
+
+    var jwks map[string]*rsa.PublicKey
+    if path := os.Getenv("AUTH_JWKS_FILE"); path != "" {
+        jwks, err = auth.LoadJWKS(path)
+        if err != nil {
+            log.Fatal("Could not load AUTH_JWKS_FILE: ", err)
+        }
+    }
+    verifier, err := auth.NewVerifier([]byte(os.Getenv("AUTH_HS256_SECRET")), jwks,
+        os.Getenv("AUTH_ISSUER"), os.Getenv("AUTH_AUDIENCE"))
+    if err != nil {
+        log.Fatal("Authentication is not configured: ", err)
+    }
+
+    contactRepo := internal.NewContactRepository(db)
+    cacheSize, cacheTTL := 10000, time.Minute
+    if size := os.Getenv("CONTACT_CACHE_SIZE"); size != "" {
+        cacheSize, err = strconv.Atoi(size)
+        if err != nil || cacheSize < 0 {
+            log.Fatal("Invalid CONTACT_CACHE_SIZE: ", size)
+        }
+    }
+    if ttl := os.Getenv("CONTACT_CACHE_TTL"); ttl != "" {
+        cacheTTL, err = time.ParseDuration(ttl)
+        if err != nil || cacheTTL <= 0 {
+            log.Fatal("Invalid CONTACT_CACHE_TTL: ", ttl)
+        }
+    }
+    if cacheSize > 0 {
+        cachedContactRepo, err := internal.NewCachedContactRepository(contactRepo, cacheSize, cacheTTL)
+        if err != nil {
+            log.Fatal("Could not create the contact cache: ", err)
+        }
+        // Served with the other expvars at GET /debug/vars.
+        expvar.Publish("contact_cache", expvar.Func(func() interface{} { return cachedContactRepo.Stats() }))
+        contactRepo = cachedContactRepo
+    }
+    fieldRepo := internal.NewCustomFieldRepository(db)
+    avatarRepo := internal.NewAvatarRepository(db)
+    avatarDir := os.Getenv("AVATAR_STORAGE_DIR")
+    if avatarDir == "" {
+        avatarDir = "data/avatars"
+    }
+    avatarBlobs, err := internal.NewBlobStore(avatarDir)
+    if err != nil {
+        log.Fatal("Could not open AVATAR_STORAGE_DIR: ", err)
+    }
+    noteRepo := internal.NewNoteRepository(db)
+    groupRepo := internal.NewGroupRepository(db)
+    apiKeyRepo := internal.NewAPIKeyRepository(db)
+    webhookRepo := internal.NewWebhookRepository(db)
+    outboxRepo := internal.NewOutboxRepository(db)
+    changeRepo := internal.NewChangeRepository(db)
+    reminderRepo := internal.NewReminderRepository(db)
+    transactor := internal.NewTransactor(db)
+
+    authz, err := internal.NewAuthorizer(os.Getenv("RBAC_CONFIG_FILE"))
+    if err != nil {
+        log.Fatal("Could not load RBAC_CONFIG_FILE: ", err)
+    }
+
+    contactUseCase := internal.NewContactUseCase(contactRepo, fieldRepo, phones, authz)
+    customFieldUseCase := internal.NewCustomFieldUseCase(fieldRepo, contactRepo, transactor, authz)
+    avatarUseCase := internal.NewAvatarUseCase(avatarRepo, contactRepo, avatarBlobs, authz)
+    noteUseCase := internal.NewNoteUseCase(noteRepo, contactRepo, authz)
+    groupUseCase := internal.NewGroupUseCase(groupRepo, contactRepo, authz)
+    apiKeyUseCase := internal.NewAPIKeyUseCase(apiKeyRepo, authz)
+    webhookUseCase := internal.NewWebhookUseCase(webhookRepo, authz)
+    eventBus := internal.NewEventBus()
+    changeUseCase := internal.NewChangeUseCase(changeRepo, eventBus, authz)
+    syncUseCase := internal.NewSyncUseCase(contactRepo, changeRepo, phones, authz)
+    maxBatchSize := usecase.DefaultMaxBatchSize
+    if size := os.Getenv("CONTACT_BATCH_MAX_SIZE"); size != "" {
+        maxBatchSize, err = strconv.Atoi(size)
+        if err != nil || maxBatchSize <= 0 {
+            log.Fatal("Invalid CONTACT_BATCH_MAX_SIZE: ", size)
+        }
+    }
+    batchUseCase := internal.NewBatchUseCase(contactUseCase, transactor, maxBatchSize)
+    authenticator := internal.NewAuthenticator(verifier, apiKeyUseCase)
+
+    contactHandler := internal.NewContactHandler(contactUseCase, logger)
+    customFieldHandler := internal.NewCustomFieldHandler(customFieldUseCase, logger)
+    avatarHandler := internal.NewAvatarHandler(avatarUseCase, logger)
+    noteHandler := internal.NewNoteHandler(noteUseCase, logger)
+    groupHandler := internal.NewGroupHandler(groupUseCase, logger)
+    apiKeyHandler := internal.NewAPIKeyHandler(apiKeyUseCase, logger)
+    webhookHandler := internal.NewWebhookHandler(webhookUseCase, logger)
+    changeHandler := internal.NewChangeHandler(changeUseCase, logger)
+    syncHandler := internal.NewSyncHandler(syncUseCase, logger)
+    batchHandler := internal.NewBatchHandler(batchUseCase, logger)
+
+    spec, err := openapi.Load()
+    if err != nil {
+        log.Fatal("Could not load OpenAPI spec: ", err)
+    }
+
+    routes := []struct {
+        pattern string
+        handler http.HandlerFunc
+    }{
+        {"/contacts", contactHandler.HandleHTTP},
+        {"GET /contacts/export.vcf", contactHandler.HandleExportVCard},
+        {"POST /contacts/import", contactHandler.HandleImportVCard},
+        {"GET /contacts/export.csv", contactHandler.HandleExportCSV},
+        {"POST /contacts/import.csv", contactHandler.HandleImportCSV},
+        {"GET /contacts/duplicates", contactHandler.HandleDuplicates},
+        {"GET /contacts/lookup", contactHandler.HandleLookup},
+        {"GET /contacts/upcoming", contactHandler.HandleUpcoming},
+        {"POST /contacts/merge", contactHandler.HandleMerge},
+        {"POST /contacts/sync", syncHandler.HandleHTTP},
+        {"POST /contacts:batch", batchHandler.HandleHTTP},
+        {"GET /contacts/fields", customFieldHandler.HandleHTTP},
+        {"POST /contacts/fields", customFieldHandler.HandleHTTP},
+        {"DELETE /contacts/fields", customFieldHandler.HandleHTTP},
+        {"GET /contacts/{file}", contactHandler.HandleContactVCard},
+        {"POST /contacts/{id}/avatar", avatarHandler.HandleUpload},
+        {"GET /contacts/{id}/avatar", avatarHandler.HandleGet},
+        {"DELETE /contacts/{id}/avatar", avatarHandler.HandleDelete},
+        {"GET /contacts/{id}/notes", noteHandler.HandleHTTP},
+        {"POST /contacts/{id}/notes", noteHandler.HandleHTTP},
+        {"GET /contacts/{id}/history", noteHandler.HandleHistory},
+        {"/groups", groupHandler.HandleHTTP},
+        {"GET /groups/contacts", groupHandler.HandleGroupContacts},
+        {"GET /groups/subtree", groupHandler.HandleGroupSubtree},
+        {"GET /groups/members", groupHandler.HandleEffectiveMembers},
+        {"/apikeys", apiKeyHandler.HandleHTTP},
+        {"/webhooks", webhookHandler.HandleHTTP},
+        {"GET /webhooks/deliveries", webhookHandler.HandleDeliveries},
+        {"GET /changes", changeHandler.HandleHTTP},
+        {"GET /changes/stream", changeHandler.HandleStream},
+        {"GET /openapi.json", spec.ServeHTTP},
+    }
+    patterns := make([]string, 0, len(routes))
+    for _, route := range routes {
+        http.HandleFunc(route.pattern, route.handler)
+        patterns = append(patterns, route.pattern)
+    }
+    if err := spec.CheckRoutes(patterns); err != nil {
+        log.Fatal("Routes do not match the OpenAPI spec: ", err)
+    }
+
+    rateLimits, err := internal.NewRateLimitConfig(os.Getenv("RATE_LIMIT_CONFIG_FILE"))
+    if err != nil {
+        log.Fatal("Could not load RATE_LIMIT_CONFIG_FILE: ", err)
+    }
+
+    idempotencyTTL := 24 * time.Hour
+    if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+        idempotencyTTL, err = time.ParseDuration(ttl)
+        if err != nil || idempotencyTTL <= 0 {
+            log.Fatal("Invalid IDEMPOTENCY_TTL: ", ttl)
+        }
+    }
+    idempotencyStore := internal.NewIdempotencyStore(db)
+    go func() {
+        for range time.Tick(time.Hour) {
+            if _, err := idempotencyStore.Purge(context.Background(), time.Now()); err != nil {
+                logger.Println("Could not purge expired idempotency keys: ", err)
+            }
+        }
+    }()
+
+    checkResponses, _ := strconv.ParseBool(os.Getenv("OPENAPI_VALIDATE_RESPONSES"))
+    handler := internal.NewIdempotencyMiddleware(http.DefaultServeMux, idempotencyStore, idempotencyTTL, logger,
+        "POST /contacts", "POST /groups", "POST /contacts:batch")
+    handler = spec.Middleware(handler, logger, checkResponses)
+    rateLimitStore := internal.NewRateLimitStore()
+    handler = internal.NewRateLimitMiddleware(handler, rateLimitStore, rateLimits, logger)
+    handler = internal.NewAuthMiddleware(handler, authenticator, logger, "/openapi.json")
+    handler = internal.NewAddressRateLimitMiddleware(handler, rateLimitStore, rateLimits, logger)
 
     go func() {
-        if err := http.ListenAndServe(":8080", nil); err != nil {
+        if err := http.ListenAndServe(":8080", handler); err != nil {
             log.Fatal("HTTP server error: ", err)
         }
     }()
 
     fmt.Println("Server started on port 8080")
 
+    grpcPort := os.Getenv("GRPC_PORT")
+    if grpcPort == "" {
+        grpcPort = "9090"
+    }
+    grpcServer, grpcHealth := internal.NewGRPCServer(contactUseCase, groupUseCase, authenticator, logger)
+    listener, err := net.Listen("tcp", ":"+grpcPort)
+    if err != nil {
+        log.Fatal("gRPC listen error: ", err)
+    }
+
+    go func() {
+        if err := grpcServer.Serve(listener); err != nil {
+            log.Fatal("gRPC server error: ", err)
+        }
+    }()
+
+    fmt.Println("gRPC server started on port " + grpcPort)
+
+    publishers := events.Publishers{eventBus}
+    eventSink, err := internal.NewEventSink(os.Getenv("EVENT_SINK"))
+    if err != nil {
+        log.Fatal("Could not open EVENT_SINK: ", err)
+    }
+    if eventSink != nil {
+        defer eventSink.Close()
+        publishers = append(publishers, eventSink)
+    }
+
+    reminderLocation := time.UTC
+    if tz := os.Getenv("REMINDER_TIMEZONE"); tz != "" {
+        reminderLocation, err = time.LoadLocation(tz)
+        if err != nil {
+            log.Fatal("Invalid REMINDER_TIMEZONE: ", tz)
+        }
+    }
+    reminderLeadDays := 0
+    if days := os.Getenv("REMINDER_LEAD_DAYS"); days != "" {
+        reminderLeadDays, err = strconv.Atoi(days)
+        if err != nil || reminderLeadDays < 0 || reminderLeadDays > usecase.MaxUpcomingDays {
+            log.Fatal("Invalid REMINDER_LEAD_DAYS: ", days)
+        }
+    }
+
+    workerCtx, stopWorkers := context.WithCancel(context.Background())
+    var workers sync.WaitGroup
+    workers.Add(4)
+    go func() {
+        defer workers.Done()
+        internal.NewWebhookDispatcher(webhookRepo, logger).Run(workerCtx)
+    }()
+    go func() {
+        defer workers.Done()
+        internal.NewEventRelay(outboxRepo, publishers, logger).Run(workerCtx)
+    }()
+    go func() {
+        defer workers.Done()
+        internal.NewReminderScheduler(reminderRepo, reminderLeadDays, reminderLocation, logger).Run(workerCtx)
+    }()
+    go func() {
+        defer workers.Done()
+        internal.NewAvatarSweeper(avatarRepo, avatarBlobs, logger).Run(workerCtx)
+    }()
+
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
     <-quit
 
     fmt.Println("Server shutting down...")
+
+    grpcHealth.Shutdown()
+    grpcServer.GracefulStop()
+
+    stopWorkers()
+    workers.Wait()
 }
@@ -0,0 +1,95 @@
+// Command normalize-phones rewrites the phone numbers stored before the
+// service normalized them on write to E.164, tenant by tenant. Until it has
+// run, lookup by phone misses those contacts. It is safe to run while the
+// service is up and to run again; numbers that do not parse are listed and
+// left for people to fix.
+//
+//	go run ./cmd/normalize-phones -dry-run   # count what would change
+//	go run ./cmd/normalize-phones
+//
+// It reads the DB_* and PHONE_DEFAULT_REGION settings of the service, and
+// must use the same default region. Contacts the service has cached show
+// their old number until CONTACT_CACHE_TTL has passed.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal"
+	"go/pkg/services/contact/internal/usecase"
+	"go/pkg/store/postgresql"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	log.SetFlags(0)
+
+	phoneRegion := os.Getenv("PHONE_DEFAULT_REGION")
+	if phoneRegion == "" {
+		phoneRegion = "KZ"
+	}
+	phones, err := phone.NewParser(phoneRegion)
+	if err != nil {
+		log.Fatal("Invalid PHONE_DEFAULT_REGION: ", err)
+	}
+
+	db, err := postgresql.Connect(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	if err != nil {
+		log.Fatal("Could not connect to PostgreSQL: ", err)
+	}
+	defer db.Close()
+
+	tenants, err := tenantsWithPhoneNumbers(db)
+	if err != nil {
+		log.Fatal("Could not list tenants: ", err)
+	}
+
+	contactRepo := internal.NewContactRepository(db)
+	normalized, unparseable := 0, 0
+	for _, tenantID := range tenants {
+		ctx := context.WithValue(context.Background(), "tenantID", tenantID)
+		backfill, err := usecase.NormalizePhoneNumbers(ctx, contactRepo, phones, *dryRun)
+		if err != nil {
+			log.Fatalf("Tenant %s: %v", tenantID, err)
+		}
+		for _, contactID := range backfill.Unparseable {
+			log.Printf("Tenant %s: contact %s: phone number does not parse", tenantID, contactID)
+		}
+		normalized += backfill.Normalized
+		unparseable += len(backfill.Unparseable)
+	}
+
+	verb := "Normalized"
+	if *dryRun {
+		verb = "Would normalize"
+	}
+	log.Printf("%s %d phone numbers in %d tenants; %d do not parse", verb, normalized, len(tenants), unparseable)
+}
+
+func tenantsWithPhoneNumbers(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT owner_id FROM contacts WHERE phone_number <> '' ORDER BY owner_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []string
+	for rows.Next() {
+		var tenantID string
+		if err := rows.Scan(&tenantID); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenantID)
+	}
+	return tenants, rows.Err()
+}
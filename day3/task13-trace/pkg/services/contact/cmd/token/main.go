@@ -0,0 +1,125 @@
+// Command token mints JWT bearer tokens for local development.
+//
+//	go run ./cmd/token -sub alice                         # HS256 with AUTH_HS256_SECRET
+//	go run ./cmd/token -sub alice -tenant acme            # token for the acme address book
+//	go run ./cmd/token -sub bob -roles "" -scope groups:read -share <group>  # one group, read-only
+//	go run ./cmd/token -genkey dev.pem                    # new RSA key for RS256
+//	go run ./cmd/token -key dev.pem -kid dev -jwks > jwks.json
+//	go run ./cmd/token -key dev.pem -kid dev -sub alice   # RS256, verified via AUTH_JWKS_FILE
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go/pkg/auth"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const allScopes = "contacts:read contacts:write groups:read groups:write"
+
+func main() {
+	sub := flag.String("sub", "", "token subject")
+	tenant := flag.String("tenant", "", "tenant claim; without it the subject gets a personal address book")
+	scope := flag.String("scope", allScopes, "space-separated scopes")
+	roles := flag.String("roles", "admin", "space-separated roles: viewer, editor, admin")
+	shared := flag.String("share", "", "space-separated IDs of groups shared read-only with the subject")
+	ttl := flag.Duration("ttl", time.Hour, "token lifetime")
+	iss := flag.String("iss", os.Getenv("AUTH_ISSUER"), "issuer claim")
+	aud := flag.String("aud", os.Getenv("AUTH_AUDIENCE"), "audience claim")
+	secret := flag.String("secret", os.Getenv("AUTH_HS256_SECRET"), "HS256 secret")
+	keyPath := flag.String("key", "", "PEM RSA private key; signs with RS256 instead of HS256")
+	kid := flag.String("kid", "", "key ID for RS256 tokens and -jwks")
+	printJWKS := flag.Bool("jwks", false, "print the JWKS for -key instead of a token")
+	genKey := flag.String("genkey", "", "write a new 2048-bit RSA private key to this file and exit")
+	flag.Parse()
+
+	log.SetFlags(0)
+
+	if *genKey != "" {
+		if err := writeKey(*genKey); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var key *rsa.PrivateKey
+	if *keyPath != "" {
+		var err error
+		key, err = auth.LoadRSAPrivateKey(*keyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *printJWKS {
+		if key == nil {
+			log.Fatal("-jwks needs -key")
+		}
+		data, err := auth.MarshalJWKS(*kid, &key.PublicKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if *sub == "" {
+		log.Fatal("-sub is required")
+	}
+	now := time.Now()
+	claims := &auth.Claims{
+		Subject:      *sub,
+		Tenant:       *tenant,
+		Scopes:       strings.Fields(*scope),
+		Roles:        strings.Fields(*roles),
+		SharedGroups: strings.Fields(*shared),
+		Issuer:       *iss,
+		IssuedAt:     now,
+		ExpiresAt:    now.Add(*ttl),
+	}
+	if *aud != "" {
+		claims.Audience = []string{*aud}
+	}
+
+	var token string
+	var err error
+	if key != nil {
+		token, err = auth.SignRS256(claims, key, *kid)
+	} else {
+		token, err = auth.SignHS256(claims, []byte(*secret))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
+
+func writeKey(path string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+// Package avatar checks uploaded contact photos and makes their
+// thumbnails. Photos are JPEG or PNG, told apart by their content rather
+// than by what the client claims.
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+const (
+	// MaxUploadSize bounds an uploaded photo.
+	MaxUploadSize = 5 << 20
+	// MaxPixels bounds the decoded photo, so that a small file cannot
+	// decode into a huge image.
+	MaxPixels = 4096 * 4096
+
+	jpegQuality = 85
+)
+
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+)
+
+// Sizes a photo is served in. Thumbnails are square.
+const (
+	SizeOriginal = "original"
+	SizeSmall    = "small"
+	SizeMedium   = "medium"
+)
+
+// Thumbnails maps the thumbnail sizes to their edge in pixels.
+var Thumbnails = map[string]int{
+	SizeSmall:  64,
+	SizeMedium: 256,
+}
+
+var (
+	ErrUnsupported = errors.New("avatar: not a JPEG or PNG image")
+	ErrTooLarge    = errors.New("avatar: image has too many pixels")
+)
+
+// ValidSize reports whether size names the original or a thumbnail.
+func ValidSize(size string) bool {
+	_, ok := Thumbnails[size]
+	return ok || size == SizeOriginal
+}
+
+// Sizes lists the original and the thumbnail sizes.
+func Sizes() []string {
+	sizes := []string{SizeOriginal}
+	for size := range Thumbnails {
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+// BlobKey names the blob of the photo of a in size. Contact IDs are unique
+// across tenants, so the tenant need not be part of it.
+func BlobKey(a *domain.Avatar, size string) string {
+	return "avatars/" + a.ContactID + "/" + a.ETag + "/" + size
+}
+
+// Image is a decoded photo.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	img         image.Image
+}
+
+func Decode(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != JPEG && contentType != PNG {
+		return nil, ErrUnsupported
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if config.Width > MaxPixels/config.Height {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	return &Image{ContentType: contentType, Width: config.Width, Height: config.Height, img: img}, nil
+}
+
+// Thumbnail crops the photo to a centered square, scales it down to size
+// pixels a side and encodes it like the photo. A photo smaller than that is
+// cropped but not scaled up.
+func (i *Image) Thumbnail(size int) ([]byte, error) {
+	b := i.img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	square := image.Rect(0, 0, side, side).Add(image.Pt(
+		b.Min.X+(b.Dx()-side)/2,
+		b.Min.Y+(b.Dy()-side)/2,
+	))
+	thumb := scale(i.img, square, min(size, side))
+
+	var buf bytes.Buffer
+	var err error
+	if i.ContentType == PNG {
+		err = png.Encode(&buf, thumb)
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scale shrinks the square r of src to size pixels a side. Each pixel is
+// the average of the source pixels it covers, which keeps fine detail from
+// turning into noise the way sampling single pixels would.
+func scale(src image.Image, r image.Rectangle, size int) *image.RGBA64 {
+	dst := image.NewRGBA64(image.Rect(0, 0, size, size))
+	side := r.Dx()
+	for y := 0; y < size; y++ {
+		y0, y1 := r.Min.Y+y*side/size, r.Min.Y+(y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := r.Min.X+x*side/size, r.Min.X+(x+1)*side/size
+			var sr, sg, sb, sa, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					sr, sg, sb, sa = sr+uint64(cr), sg+uint64(cg), sb+uint64(cb), sa+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(sr / n),
+				G: uint16(sg / n),
+				B: uint16(sb / n),
+				A: uint16(sa / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,74 @@
+package avatar
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go/pkg/blob"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// Sweeper deletes the photos of deleted contacts from blob storage. The
+// database queues them whichever way a contact is deleted, and only once
+// the deletion commits. The fields may be changed before Run.
+type Sweeper struct {
+	Interval  time.Duration
+	BatchSize int
+
+	repo   repository.AvatarRepository
+	blobs  blob.Store
+	logger *log.Logger
+}
+
+func NewSweeper(repo repository.AvatarRepository, blobs blob.Store, logger *log.Logger) *Sweeper {
+	return &Sweeper{
+		Interval:  time.Minute,
+		BatchSize: 100,
+		repo:      repo,
+		blobs:     blobs,
+		logger:    logger,
+	}
+}
+
+// Run sweeps until ctx is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Printf("Avatar sweep error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce deletes the photos queued now and returns how many it deleted.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.repo.PurgeDeletedAvatars(ctx, s.BatchSize, s.deleteBlobs)
+		total += n
+		if err != nil || n < s.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// deleteBlobs fails on the first blob it cannot delete, which leaves the
+// whole batch queued for the next sweep.
+func (s *Sweeper) deleteBlobs(ctx context.Context, avatars []*domain.Avatar) error {
+	for _, a := range avatars {
+		for _, size := range Sizes() {
+			if err := s.blobs.Delete(ctx, BlobKey(a, size)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
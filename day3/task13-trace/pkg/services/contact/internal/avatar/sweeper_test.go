@@ -0,0 +1,106 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"go/pkg/blob"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// queueRepository purges its queue the way the Postgres repository does:
+// a batch stays queued unless purge succeeds.
+type queueRepository struct {
+	repository.AvatarRepository
+	queue []*domain.Avatar
+}
+
+func (r *queueRepository) PurgeDeletedAvatars(ctx context.Context, limit int, purge func(ctx context.Context, avatars []*domain.Avatar) error) (int, error) {
+	batch := r.queue[:min(limit, len(r.queue))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := purge(ctx, batch); err != nil {
+		return 0, err
+	}
+	r.queue = r.queue[len(batch):]
+	return len(batch), nil
+}
+
+type failingStore struct {
+	blob.Store
+}
+
+func (failingStore) Delete(ctx context.Context, key string) error {
+	return errors.New("storage is down")
+}
+
+func putPhotos(t *testing.T, store blob.Store, a *domain.Avatar) {
+	for _, size := range Sizes() {
+		if err := store.Put(context.Background(), BlobKey(a, size), strings.NewReader(size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func photosLeft(t *testing.T, store blob.Store, a *domain.Avatar) int {
+	left := 0
+	for _, size := range Sizes() {
+		r, err := store.Get(context.Background(), BlobKey(a, size))
+		if errors.Is(err, blob.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		left++
+	}
+	return left
+}
+
+func TestSweeper(t *testing.T) {
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := []*domain.Avatar{
+		{ContactID: "9b2f6a1e-0c4d-4e8a-9f3b-1a2b3c4d5e6f", ETag: "aaaa"},
+		{ContactID: "0c1d2e3f-4a5b-4c6d-8e7f-8a9b0c1d2e3f", ETag: "bbbb"},
+		{ContactID: "5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9", ETag: "cccc"},
+	}
+	live := &domain.Avatar{ContactID: "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", ETag: "dddd"}
+	for _, a := range append(deleted, live) {
+		putPhotos(t, store, a)
+	}
+	repo := &queueRepository{queue: deleted}
+	logger := log.New(io.Discard, "", 0)
+
+	failing := NewSweeper(repo, failingStore{store}, logger)
+	if n, err := failing.SweepOnce(context.Background()); err == nil || n != 0 || len(repo.queue) != len(deleted) {
+		t.Fatalf("SweepOnce with failing storage = %d, %v with %d queued, want the queue kept", n, err, len(repo.queue))
+	}
+
+	sweeper := NewSweeper(repo, store, logger)
+	sweeper.BatchSize = 2
+	n, err := sweeper.SweepOnce(context.Background())
+	if err != nil || n != len(deleted) {
+		t.Fatalf("SweepOnce = %d, %v, want %d", n, err, len(deleted))
+	}
+	for _, a := range deleted {
+		if left := photosLeft(t, store, a); left != 0 {
+			t.Errorf("%d photos of deleted contact %s left", left, a.ContactID)
+		}
+	}
+	if left := photosLeft(t, store, live); left != len(Sizes()) {
+		t.Errorf("%d of %d photos of a live contact left", left, len(Sizes()))
+	}
+	if len(repo.queue) != 0 {
+		t.Errorf("%d avatars still queued", len(repo.queue))
+	}
+}
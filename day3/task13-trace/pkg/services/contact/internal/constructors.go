@@ -1,31 +1,251 @@
 package internal
 
 import (
+    "database/sql"
+    "log"
+    "net/http"
+    "time"
+
+    "go/pkg/auth"
+    "go/pkg/blob"
+    "go/pkg/idempotency"
+    "go/pkg/phone"
+    "go/pkg/ratelimit"
+    "go/pkg/services/contact/internal/avatar"
     "go/pkg/services/contact/internal/delivery"
+    "go/pkg/services/contact/internal/delivery/grpcserver"
+    "go/pkg/services/contact/internal/events"
+    "go/pkg/services/contact/internal/reminder"
     "go/pkg/services/contact/internal/repository"
     "go/pkg/services/contact/internal/usecase"
+    "go/pkg/services/contact/internal/webhook"
+    "go/pkg/store/postgresql"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/health"
 )
 
-func NewContactRepository() repository.ContactRepository {
-    return repository.NewContactRepository()
+func NewContactRepository(db *sql.DB) repository.ContactRepository {
+    return repository.NewContactRepository(db)
+}
+
+func NewCachedContactRepository(contactRepo repository.ContactRepository, size int, ttl time.Duration) (*repository.CachedContactRepository, error) {
+    return repository.NewCachedContactRepository(contactRepo, size, ttl)
+}
+
+func NewCustomFieldRepository(db *sql.DB) repository.CustomFieldRepository {
+    return repository.NewCustomFieldRepository(db)
+}
+
+func NewAvatarRepository(db *sql.DB) repository.AvatarRepository {
+    return repository.NewAvatarRepository(db)
+}
+
+// NewBlobStore keeps blobs in the local directory dir.
+func NewBlobStore(dir string) (blob.Store, error) {
+    return blob.NewFSStore(dir)
+}
+
+func NewNoteRepository(db *sql.DB) repository.NoteRepository {
+    return repository.NewNoteRepository(db)
+}
+
+func NewGroupRepository(db *sql.DB) repository.GroupRepository {
+    return repository.NewGroupRepository(db)
+}
+
+func NewWebhookRepository(db *sql.DB) repository.WebhookRepository {
+    return repository.NewWebhookRepository(db)
+}
+
+func NewOutboxRepository(db *sql.DB) repository.OutboxRepository {
+    return repository.NewOutboxRepository(db)
+}
+
+func NewChangeRepository(db *sql.DB) repository.ChangeRepository {
+    return repository.NewChangeRepository(db)
+}
+
+func NewReminderRepository(db *sql.DB) repository.ReminderRepository {
+    return repository.NewReminderRepository(db)
+}
+
+func NewTransactor(db *sql.DB) repository.Transactor {
+    return repository.NewTransactor(db)
+}
+
+func NewAPIKeyRepository(db *sql.DB) repository.APIKeyRepository {
+    return repository.NewAPIKeyRepository(db)
+}
+
+// NewAuthorizer reads the access config at configPath; an empty path
+// leaves the default policy and roles from tokens only.
+func NewAuthorizer(configPath string) (*usecase.Authorizer, error) {
+    var config *usecase.AccessConfig
+    if configPath != "" {
+        var err error
+        config, err = usecase.LoadAccessConfig(configPath)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return usecase.NewAuthorizer(config)
+}
+
+func NewContactUseCase(contactRepo repository.ContactRepository, fieldRepo repository.CustomFieldRepository, phones *phone.Parser, authz *usecase.Authorizer) usecase.ContactUseCase {
+    return usecase.NewAuthorizedContactUseCase(usecase.NewContactUseCase(contactRepo, fieldRepo, phones), authz)
+}
+
+func NewCustomFieldUseCase(fieldRepo repository.CustomFieldRepository, contactRepo repository.ContactRepository, transactor repository.Transactor, authz *usecase.Authorizer) usecase.CustomFieldUseCase {
+    return usecase.NewAuthorizedCustomFieldUseCase(usecase.NewCustomFieldUseCase(fieldRepo, contactRepo, transactor), authz)
+}
+
+func NewAvatarUseCase(avatarRepo repository.AvatarRepository, contactRepo repository.ContactRepository, blobs blob.Store, authz *usecase.Authorizer) usecase.AvatarUseCase {
+    return usecase.NewAuthorizedAvatarUseCase(usecase.NewAvatarUseCase(avatarRepo, contactRepo, blobs), authz)
+}
+
+func NewNoteUseCase(noteRepo repository.NoteRepository, contactRepo repository.ContactRepository, authz *usecase.Authorizer) usecase.NoteUseCase {
+    return usecase.NewAuthorizedNoteUseCase(usecase.NewNoteUseCase(noteRepo, contactRepo), authz)
+}
+
+func NewGroupUseCase(groupRepo repository.GroupRepository, contactRepo repository.ContactRepository, authz *usecase.Authorizer) usecase.GroupUseCase {
+    return usecase.NewAuthorizedGroupUseCase(usecase.NewGroupUseCase(groupRepo, contactRepo), authz)
+}
+
+func NewAPIKeyUseCase(apiKeyRepo repository.APIKeyRepository, authz *usecase.Authorizer) usecase.APIKeyUseCase {
+    return usecase.NewAuthorizedAPIKeyUseCase(usecase.NewAPIKeyUseCase(apiKeyRepo), authz)
+}
+
+func NewWebhookUseCase(webhookRepo repository.WebhookRepository, authz *usecase.Authorizer) usecase.WebhookUseCase {
+    return usecase.NewAuthorizedWebhookUseCase(usecase.NewWebhookUseCase(webhookRepo), authz)
+}
+
+func NewContactHandler(contactUseCase usecase.ContactUseCase, logger *log.Logger) *delivery.ContactHandler {
+    return delivery.NewContactHandler(contactUseCase, logger)
+}
+
+func NewCustomFieldHandler(customFieldUseCase usecase.CustomFieldUseCase, logger *log.Logger) *delivery.CustomFieldHandler {
+    return delivery.NewCustomFieldHandler(customFieldUseCase, logger)
+}
+
+func NewAvatarHandler(avatarUseCase usecase.AvatarUseCase, logger *log.Logger) *delivery.AvatarHandler {
+    return delivery.NewAvatarHandler(avatarUseCase, logger)
+}
+
+func NewNoteHandler(noteUseCase usecase.NoteUseCase, logger *log.Logger) *delivery.NoteHandler {
+    return delivery.NewNoteHandler(noteUseCase, logger)
+}
+
+func NewGroupHandler(groupUseCase usecase.GroupUseCase, logger *log.Logger) *delivery.GroupHandler {
+    return delivery.NewGroupHandler(groupUseCase, logger)
+}
+
+func NewAPIKeyHandler(apiKeyUseCase usecase.APIKeyUseCase, logger *log.Logger) *delivery.APIKeyHandler {
+    return delivery.NewAPIKeyHandler(apiKeyUseCase, logger)
+}
+
+func NewChangeUseCase(changeRepo repository.ChangeRepository, notifier usecase.ChangeNotifier, authz *usecase.Authorizer) usecase.ChangeUseCase {
+    return usecase.NewAuthorizedChangeUseCase(usecase.NewChangeUseCase(changeRepo, notifier), authz)
+}
+
+// NewBatchUseCase runs batches through contactUseCase, which is expected to
+// be the authorized one.
+func NewBatchUseCase(contactUseCase usecase.ContactUseCase, transactor repository.Transactor, maxSize int) usecase.BatchUseCase {
+    return usecase.NewBatchUseCase(contactUseCase, transactor, maxSize)
+}
+
+func NewBatchHandler(batchUseCase usecase.BatchUseCase, logger *log.Logger) *delivery.BatchHandler {
+    return delivery.NewBatchHandler(batchUseCase, logger)
+}
+
+func NewSyncUseCase(contactRepo repository.ContactRepository, changeRepo repository.ChangeRepository, phones *phone.Parser, authz *usecase.Authorizer) usecase.SyncUseCase {
+    return usecase.NewAuthorizedSyncUseCase(usecase.NewSyncUseCase(contactRepo, changeRepo, phones), authz)
+}
+
+func NewSyncHandler(syncUseCase usecase.SyncUseCase, logger *log.Logger) *delivery.SyncHandler {
+    return delivery.NewSyncHandler(syncUseCase, logger)
+}
+
+func NewChangeHandler(changeUseCase usecase.ChangeUseCase, logger *log.Logger) *delivery.ChangeHandler {
+    return delivery.NewChangeHandler(changeUseCase, logger)
+}
+
+func NewWebhookHandler(webhookUseCase usecase.WebhookUseCase, logger *log.Logger) *delivery.WebhookHandler {
+    return delivery.NewWebhookHandler(webhookUseCase, logger)
+}
+
+func NewWebhookDispatcher(webhookRepo repository.WebhookRepository, logger *log.Logger) *webhook.Dispatcher {
+    return webhook.NewDispatcher(webhookRepo, nil, logger)
+}
+
+func NewEventBus() *events.ChannelPublisher {
+    return events.NewChannelPublisher()
+}
+
+// NewEventSink opens the event sink named by spec, "stdout" or
+// "file:<path>"; an empty spec gives no sink.
+func NewEventSink(spec string) (*events.WriterPublisher, error) {
+    if spec == "" {
+        return nil, nil
+    }
+    return events.OpenSink(spec)
+}
+
+func NewEventRelay(outboxRepo repository.OutboxRepository, publisher events.Publisher, logger *log.Logger) *events.Relay {
+    return events.NewRelay(outboxRepo, publisher, logger)
+}
+
+// NewAvatarSweeper deletes the photos of deleted contacts from blobs.
+func NewAvatarSweeper(avatarRepo repository.AvatarRepository, blobs blob.Store, logger *log.Logger) *avatar.Sweeper {
+    return avatar.NewSweeper(avatarRepo, blobs, logger)
+}
+
+// NewReminderScheduler sends reminders leadDays ahead of the dates, with
+// days starting in loc, and writes them to logger.
+func NewReminderScheduler(reminderRepo repository.ReminderRepository, leadDays int, loc *time.Location, logger *log.Logger) *reminder.Scheduler {
+    scheduler := reminder.NewScheduler(reminderRepo, reminder.NewLogNotifier(logger), logger)
+    scheduler.LeadDays = leadDays
+    scheduler.Location = loc
+    return scheduler
+}
+
+func NewAuthenticator(verifier *auth.Verifier, apiKeyUseCase usecase.APIKeyUseCase) *delivery.Authenticator {
+    return delivery.NewAuthenticator(verifier, apiKeyUseCase)
+}
+
+func NewAuthMiddleware(next http.Handler, authenticator *delivery.Authenticator, logger *log.Logger, publicPaths ...string) http.Handler {
+    return delivery.AuthMiddleware(next, authenticator, logger, publicPaths...)
+}
+
+// NewRateLimitConfig reads the rate limit config at configPath; an empty
+// path gives ratelimit.DefaultConfig.
+func NewRateLimitConfig(configPath string) (*ratelimit.Config, error) {
+    if configPath == "" {
+        return ratelimit.DefaultConfig(), nil
+    }
+    return ratelimit.LoadConfig(configPath)
+}
+
+func NewRateLimitStore() ratelimit.Store {
+    return ratelimit.NewMemoryStore()
 }
 
-func NewGroupRepository() repository.GroupRepository {
-    return repository.NewGroupRepository()
+func NewRateLimitMiddleware(next http.Handler, store ratelimit.Store, config *ratelimit.Config, logger *log.Logger) http.Handler {
+    return delivery.RateLimitMiddleware(next, store, config, logger)
 }
 
-func NewContactUseCase(contactRepo repository.ContactRepository) usecase.ContactUseCase {
-    return usecase.NewContactUseCase(contactRepo)
+func NewAddressRateLimitMiddleware(next http.Handler, store ratelimit.Store, config *ratelimit.Config, logger *log.Logger) http.Handler {
+    return delivery.AddressRateLimitMiddleware(next, store, config, logger)
 }
 
-func NewGroupUseCase(groupRepo repository.GroupRepository) usecase.GroupUseCase {
-    return usecase.NewGroupUseCase(groupRepo)
+func NewIdempotencyStore(db *sql.DB) idempotency.Store {
+    return postgresql.NewIdempotencyStore(db)
 }
 
-func NewContactHandler(contactUseCase usecase.ContactUseCase) delivery.ContactHandler {
-    return delivery.NewContactHandler(contactUseCase)
+func NewIdempotencyMiddleware(next http.Handler, store idempotency.Store, ttl time.Duration, logger *log.Logger, routes ...string) http.Handler {
+    return delivery.IdempotencyMiddleware(next, store, ttl, logger, routes...)
 }
 
-func NewGroupHandler(groupUseCase usecase.GroupUseCase) delivery.GroupHandler {
-    return delivery.NewGroupHandler(groupUseCase)
+func NewGRPCServer(contactUseCase usecase.ContactUseCase, groupUseCase usecase.GroupUseCase, authenticator *delivery.Authenticator, logger *log.Logger) (*grpc.Server, *health.Server) {
+    return grpcserver.NewServer(contactUseCase, groupUseCase, authenticator, logger)
 }
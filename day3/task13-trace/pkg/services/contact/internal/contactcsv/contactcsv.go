@@ -0,0 +1,337 @@
+package contactcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+const MediaType = "text/csv"
+
+var Fields = []string{
+	"ID",
+	"FullName",
+	"FirstName",
+	"Patronymic",
+	"PhoneNumber",
+	"Email",
+	"Street",
+	"Locality",
+	"Region",
+	"PostalCode",
+	"Country",
+	"Tags",
+	"Birthday",
+	"Anniversaries",
+}
+
+// CustomFieldPrefix starts the names of the columns that hold custom fields,
+// such as "CustomFields.score".
+const CustomFieldPrefix = "CustomFields."
+
+// tagSeparator joins the tags in a cell, and the anniversaries in theirs.
+// Either it or a comma separates them on import.
+const tagSeparator = ";"
+
+var ErrMapping = errors.New("csv: invalid column mapping")
+
+// Mapping maps CSV header names onto Fields or custom field columns. Columns
+// that are not mentioned are matched to a field by name, ignoring case,
+// spaces, '_' and '-'.
+type Mapping map[string]string
+
+type RowError struct {
+	Row  int
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d (line %d): %v", e.Row, e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+func lookupField(name string) (string, bool) {
+	if len(name) > len(CustomFieldPrefix) && strings.EqualFold(name[:len(CustomFieldPrefix)], CustomFieldPrefix) {
+		return CustomFieldPrefix + strings.TrimSpace(name[len(CustomFieldPrefix):]), true
+	}
+	n := normalize(name)
+	for _, f := range Fields {
+		if normalize(f) == n {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func (m Mapping) resolve(header []string) ([]string, error) {
+	explicit := make(map[string]string, len(m))
+	for column, field := range m {
+		f, ok := lookupField(field)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrMapping, field)
+		}
+		explicit[normalize(column)] = f
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		n := normalize(name)
+		field, ok := explicit[n]
+		if ok {
+			delete(explicit, n)
+		} else {
+			field, _ = lookupField(name)
+		}
+		if field == "" {
+			continue
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("%w: field %s is mapped by more than one column", ErrMapping, field)
+		}
+		seen[field] = true
+		columns[i] = field
+	}
+
+	for column := range explicit {
+		return nil, fmt.Errorf("%w: column %q not found in header", ErrMapping, column)
+	}
+	if !seen["FullName"] && !seen["FirstName"] {
+		return nil, fmt.Errorf("%w: neither FullName nor FirstName is mapped", ErrMapping)
+	}
+	return columns, nil
+}
+
+// Reader streams contacts from CSV, one row at a time.
+type Reader struct {
+	r       *csv.Reader
+	columns []string
+	row     int
+}
+
+func NewReader(r io.Reader, mapping Mapping, comma rune) (*Reader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if comma != 0 {
+		cr.Comma = comma
+	}
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing header row", ErrMapping)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := mapping.resolve(header)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{r: cr, columns: columns}, nil
+}
+
+// Read returns the next contact and its 1-based data row number. Malformed or
+// invalid rows are returned as *RowError; reading may continue after them.
+func (r *Reader) Read() (*domain.Contact, int, error) {
+	record, err := r.r.Read()
+	if err == io.EOF {
+		return nil, r.row, io.EOF
+	}
+	r.row++
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, r.row, &RowError{Row: r.row, Line: parseErr.Line, Err: parseErr.Err}
+		}
+		return nil, r.row, err
+	}
+	line, _ := r.r.FieldPos(0)
+	if len(record) > len(r.columns) {
+		return nil, r.row, &RowError{Row: r.row, Line: line, Err: fmt.Errorf("expected at most %d fields, got %d", len(r.columns), len(record))}
+	}
+
+	contact := &domain.Contact{}
+	for i, value := range record {
+		if err := setField(contact, r.columns[i], strings.TrimSpace(value)); err != nil {
+			return contact, r.row, &RowError{Row: r.row, Line: line, Err: err}
+		}
+	}
+	if contact.FullName == "" {
+		contact.FullName = strings.TrimSpace(contact.FirstName + " " + contact.Patronymic)
+	}
+
+	if err := contact.Validate(); err != nil {
+		return contact, r.row, &RowError{Row: r.row, Line: line, Err: err}
+	}
+	return contact, r.row, nil
+}
+
+func setField(c *domain.Contact, field, value string) error {
+	if name, ok := strings.CutPrefix(field, CustomFieldPrefix); ok {
+		// The value is checked against the field's type on import.
+		if value != "" {
+			if c.CustomFields == nil {
+				c.CustomFields = map[string]interface{}{}
+			}
+			c.CustomFields[name] = value
+		}
+		return nil
+	}
+	// ID is exported but ignored on import: imported rows always become new contacts.
+	switch field {
+	case "FullName":
+		c.FullName = value
+	case "FirstName":
+		c.FirstName = value
+	case "Patronymic":
+		c.Patronymic = value
+	case "PhoneNumber":
+		c.PhoneNumber = value
+	case "Email":
+		c.Email = value
+	case "Street":
+		c.Address.Street = value
+	case "Locality":
+		c.Address.Locality = value
+	case "Region":
+		c.Address.Region = value
+	case "PostalCode":
+		c.Address.PostalCode = value
+	case "Country":
+		c.Address.Country = value
+	case "Tags":
+		c.Tags = strings.FieldsFunc(value, isSeparator)
+	case "Birthday":
+		if value != "" {
+			birthday, err := domain.ParseDate(value)
+			if err != nil {
+				return &domain.ValidationError{Field: "Birthday", Reason: err.Error()}
+			}
+			c.Birthday = &birthday
+		}
+	case "Anniversaries":
+		anniversaries, err := parseAnniversaries(value)
+		if err != nil {
+			return &domain.ValidationError{Field: "Anniversaries", Reason: err.Error()}
+		}
+		c.Anniversaries = anniversaries
+	}
+	return nil
+}
+
+func isSeparator(r rune) bool {
+	return r == ';' || r == ','
+}
+
+// parseAnniversaries reads anniversaries written label=date, as in
+// "Wedding=2010-06-12; First met=--03-04".
+func parseAnniversaries(value string) ([]domain.Anniversary, error) {
+	var anniversaries []domain.Anniversary
+	for _, item := range strings.FieldsFunc(value, isSeparator) {
+		label, date, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("%q is not written label=date", strings.TrimSpace(item))
+		}
+		parsed, err := domain.ParseDate(strings.TrimSpace(date))
+		if err != nil {
+			return nil, err
+		}
+		anniversaries = append(anniversaries, domain.Anniversary{Label: strings.TrimSpace(label), Date: parsed})
+	}
+	return anniversaries, nil
+}
+
+func formatAnniversaries(anniversaries []domain.Anniversary) string {
+	items := make([]string, len(anniversaries))
+	for i, a := range anniversaries {
+		items[i] = a.Label + "=" + a.Date.String()
+	}
+	return strings.Join(items, tagSeparator)
+}
+
+func record(c *domain.Contact, customFields []string) []string {
+	var birthday string
+	if c.Birthday != nil {
+		birthday = c.Birthday.String()
+	}
+	rec := []string{
+		c.ID,
+		c.FullName,
+		c.FirstName,
+		c.Patronymic,
+		c.PhoneNumber,
+		c.Email,
+		c.Address.Street,
+		c.Address.Locality,
+		c.Address.Region,
+		c.Address.PostalCode,
+		c.Address.Country,
+		strings.Join(c.Tags, tagSeparator),
+		birthday,
+		formatAnniversaries(c.Anniversaries),
+	}
+	for _, name := range customFields {
+		var value string
+		if v, ok := c.CustomFields[name]; ok {
+			value = domain.FormatCustomValue(v)
+		}
+		rec = append(rec, value)
+	}
+	return rec
+}
+
+type Writer struct {
+	w            *csv.Writer
+	customFields []string
+}
+
+func NewWriter(w io.Writer, comma rune) *Writer {
+	cw := csv.NewWriter(w)
+	if comma != 0 {
+		cw.Comma = comma
+	}
+	return &Writer{w: cw}
+}
+
+// WriteHeader writes Fields followed by a column for each of customFields;
+// Write fills in those columns.
+func (w *Writer) WriteHeader(customFields []string) error {
+	w.customFields = customFields
+	header := append([]string(nil), Fields...)
+	for _, name := range customFields {
+		header = append(header, CustomFieldPrefix+name)
+	}
+	return w.w.Write(header)
+}
+
+func (w *Writer) Write(contact *domain.Contact) error {
+	return w.w.Write(record(contact, w.customFields))
+}
+
+func (w *Writer) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
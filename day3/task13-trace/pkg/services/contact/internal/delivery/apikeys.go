@@ -0,0 +1,113 @@
+package delivery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+type APIKeyHandler struct {
+	useCase usecase.APIKeyUseCase
+	logger  *log.Logger
+}
+
+func NewAPIKeyHandler(useCase usecase.APIKeyUseCase, logger *log.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+type newAPIKey struct {
+	Name      string
+	Access    string
+	ExpiresAt *time.Time
+}
+
+// issuedAPIKey is the only response that carries the plaintext Key.
+type issuedAPIKey struct {
+	*auth.APIKey
+	Key string
+}
+
+// HandleHTTP serves /apikeys: GET lists the tenant's keys, POST issues one
+// and DELETE ?id= revokes one.
+func (h *APIKeyHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getAPIKeys(w, r)
+	case http.MethodPost:
+		h.issueAPIKey(w, r)
+	case http.MethodDelete:
+		h.revokeAPIKey(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *APIKeyHandler) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	keys, err := h.useCase.GetAPIKeys(r.Context())
+	if err != nil {
+		h.logger.Printf("[%s] Error listing API keys: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (h *APIKeyHandler) issueAPIKey(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	var req newAPIKey
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key := &auth.APIKey{Name: req.Name, Access: req.Access, ExpiresAt: req.ExpiresAt}
+	plaintext, err := h.useCase.IssueAPIKey(r.Context(), key)
+	if err != nil {
+		h.logger.Printf("[%s] Error issuing API key: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	h.logger.Printf("[%s] Issued API key %s (%s)\n", traceID, key.ID, key.Prefix)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issuedAPIKey{APIKey: key, Key: plaintext})
+}
+
+func (h *APIKeyHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	keyID := r.URL.Query().Get("id")
+	if keyID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.RevokeAPIKey(r.Context(), keyID); err != nil {
+		h.logger.Printf("[%s] Error revoking API key: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	h.logger.Printf("[%s] Revoked API key %s\n", traceID, keyID)
+	w.WriteHeader(http.StatusNoContent)
+}
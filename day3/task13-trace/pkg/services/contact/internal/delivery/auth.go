@@ -0,0 +1,214 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"go/pkg/auth"
+)
+
+const (
+	ScopeContactsRead  = "contacts:read"
+	ScopeContactsWrite = "contacts:write"
+	ScopeGroupsRead    = "groups:read"
+	ScopeGroupsWrite   = "groups:write"
+)
+
+// APIKeyAuthenticator resolves the plaintext API keys machine clients send;
+// usecase.APIKeyUseCase implements it.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error)
+}
+
+// Principal is who a request acts as, from a bearer token or an API key.
+type Principal struct {
+	Subject      string
+	TenantID     string
+	Scopes       []string
+	Roles        []string
+	SharedGroups []string
+	// APIKeyID is set when the principal authenticated with an API key.
+	APIKeyID string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// WithContext stores the principal under "subject", "scopes", "tenantID",
+// "roles", "sharedGroups" and, for API keys, "apiKeyID".
+func (p *Principal) WithContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, "subject", p.Subject)
+	ctx = context.WithValue(ctx, "scopes", p.Scopes)
+	ctx = context.WithValue(ctx, "tenantID", p.TenantID)
+	ctx = context.WithValue(ctx, "roles", p.Roles)
+	ctx = context.WithValue(ctx, "sharedGroups", p.SharedGroups)
+	if p.APIKeyID != "" {
+		ctx = context.WithValue(ctx, "apiKeyID", p.APIKeyID)
+	}
+	return ctx
+}
+
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator accepts JWTs as "Bearer <token>" and API keys as either
+// "ApiKey <key>" or "Bearer <key>".
+type Authenticator struct {
+	verifier *auth.Verifier
+	apiKeys  APIKeyAuthenticator
+}
+
+func NewAuthenticator(verifier *auth.Verifier, apiKeys APIKeyAuthenticator) *Authenticator {
+	return &Authenticator{verifier: verifier, apiKeys: apiKeys}
+}
+
+// Authenticate resolves the value of an Authorization header. It returns
+// ErrNoCredentials when there is none in a supported scheme.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization string) (*Principal, error) {
+	scheme, credentials, ok := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+	if !ok || credentials == "" {
+		return nil, ErrNoCredentials
+	}
+
+	switch {
+	case strings.EqualFold(scheme, "ApiKey"),
+		strings.EqualFold(scheme, "Bearer") && auth.LooksLikeAPIKey(credentials):
+		key, err := a.apiKeys.AuthenticateAPIKey(ctx, credentials)
+		if err != nil {
+			return nil, err
+		}
+		return apiKeyPrincipal(key), nil
+	case strings.EqualFold(scheme, "Bearer"):
+		claims, err := a.verifier.Verify(credentials)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{
+			Subject:      claims.Subject,
+			TenantID:     TenantID(claims),
+			Scopes:       claims.Scopes,
+			Roles:        claims.Roles,
+			SharedGroups: claims.SharedGroups,
+		}, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+// apiKeyPrincipal gives read-only keys the read scopes and the viewer role,
+// read-write keys every scope and the editor role.
+func apiKeyPrincipal(key *auth.APIKey) *Principal {
+	p := &Principal{Subject: "apikey:" + key.ID, TenantID: key.TenantID, APIKeyID: key.ID}
+	if key.Access == auth.AccessReadWrite {
+		p.Scopes = []string{ScopeContactsRead, ScopeContactsWrite, ScopeGroupsRead, ScopeGroupsWrite}
+		p.Roles = []string{"editor"}
+	} else {
+		p.Scopes = []string{ScopeContactsRead, ScopeGroupsRead}
+		p.Roles = []string{"viewer"}
+	}
+	return p
+}
+
+// CredentialProblem describes why credentials were rejected, for the
+// client. ok is false for failures that are not the client's fault.
+func CredentialProblem(err error) (description string, ok bool) {
+	switch {
+	case errors.Is(err, auth.ErrTokenExpired):
+		return "token expired", true
+	case errors.Is(err, auth.ErrInvalidToken):
+		return "invalid token", true
+	case errors.Is(err, auth.ErrAPIKeyExpired):
+		return "API key expired", true
+	case errors.Is(err, auth.ErrAPIKeyRevoked):
+		return "API key revoked", true
+	case errors.Is(err, auth.ErrInvalidAPIKey):
+		return "invalid API key", true
+	}
+	return "", false
+}
+
+// AuthMiddleware requires a bearer token or API key on every path except
+// publicPaths and puts the principal into the request context. Reads under
+// /contacts and /groups need the matching read scope, everything else
+// there the write scope. The change feed, which carries both, needs both
+// read scopes.
+func AuthMiddleware(next http.Handler, authenticator *Authenticator, logger *log.Logger, publicPaths ...string) http.Handler {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if public[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(r.Context(), r.Header.Get("Authorization"))
+		if errors.Is(err, ErrNoCredentials) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="contacts"`)
+			w.Header().Add("WWW-Authenticate", `ApiKey realm="contacts"`)
+			http.Error(w, "bearer token or API key required", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			logger.Printf("Rejected credentials for %s %s: %v\n", r.Method, r.URL.Path, err)
+			description, ok := CredentialProblem(err)
+			if !ok {
+				http.Error(w, "authentication failed", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="contacts", error="invalid_token", error_description=%q`, description))
+			http.Error(w, description, http.StatusUnauthorized)
+			return
+		}
+
+		for _, scope := range requiredScopes(r) {
+			if !principal.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="contacts", error="insufficient_scope", scope=%q`, scope))
+				http.Error(w, "missing scope "+scope, http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(principal.WithContext(r.Context())))
+	})
+}
+
+// TenantID is the tenant a request acts for. Tokens without a tenant claim
+// get a personal address book keyed by their subject.
+func TenantID(claims *auth.Claims) string {
+	if claims.Tenant != "" {
+		return claims.Tenant
+	}
+	return "user:" + claims.Subject
+}
+
+func requiredScopes(r *http.Request) []string {
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	// Custom methods such as /contacts:batch belong to their collection.
+	resource, _, _ = strings.Cut(resource, ":")
+	switch {
+	case resource == "contacts" && read:
+		return []string{ScopeContactsRead}
+	case resource == "contacts":
+		return []string{ScopeContactsWrite}
+	case resource == "groups" && read:
+		return []string{ScopeGroupsRead}
+	case resource == "groups":
+		return []string{ScopeGroupsWrite}
+	case resource == "changes":
+		return []string{ScopeContactsRead, ScopeGroupsRead}
+	}
+	return nil
+}
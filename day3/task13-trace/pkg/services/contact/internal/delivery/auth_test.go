@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go/pkg/auth"
+)
+
+var authTestSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// keyTable answers API key lookups from a map of plaintext keys to keys or
+// errors.
+type keyTable map[string]interface{}
+
+func (k keyTable) AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error) {
+	switch v := k[plaintext].(type) {
+	case *auth.APIKey:
+		return v, nil
+	case error:
+		return nil, v
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func testToken(t *testing.T, expiresAt time.Time, scopes ...string) string {
+	token, err := auth.SignHS256(&auth.Claims{Subject: "user-1", Tenant: testTenant, Scopes: scopes, ExpiresAt: expiresAt}, authTestSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	verifier, err := auth.NewVerifier(authTestSecret, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := keyTable{
+		"ak_readonly": &auth.APIKey{ID: "key-1", TenantID: testTenant, Access: auth.AccessReadOnly},
+		"ak_revoked":  auth.ErrAPIKeyRevoked,
+		"ak_broken":   errors.New("connection refused"),
+	}
+	var principal *Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = &Principal{}
+		principal.Subject, _ = r.Context().Value("subject").(string)
+		principal.TenantID, _ = r.Context().Value("tenantID").(string)
+	})
+	handler := AuthMiddleware(next, NewAuthenticator(verifier, keys), log.New(io.Discard, "", 0), "/openapi.json")
+
+	later := time.Now().Add(time.Hour)
+	challenge := []string{`Bearer realm="contacts"`, `ApiKey realm="contacts"`}
+	invalid := func(description string) []string {
+		return []string{`Bearer realm="contacts", error="invalid_token", error_description="` + description + `"`}
+	}
+	insufficient := func(scope string) []string {
+		return []string{`Bearer realm="contacts", error="insufficient_scope", scope="` + scope + `"`}
+	}
+
+	tests := []struct {
+		name          string
+		method, path  string
+		authorization string
+		wantStatus    int
+		wantChallenge []string
+		// wantSubject is who the request reaches the handler as; "-" is
+		// for requests that must not reach it.
+		wantSubject string
+	}{
+		{"public path", "GET", "/openapi.json", "", http.StatusOK, nil, ""},
+		{"no credentials", "GET", "/contacts", "", http.StatusUnauthorized, challenge, "-"},
+		{"unsupported scheme", "GET", "/contacts", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, challenge, "-"},
+		{"empty bearer", "GET", "/contacts", "Bearer ", http.StatusUnauthorized, challenge, "-"},
+		{"invalid token", "GET", "/contacts", "Bearer not.a.token", http.StatusUnauthorized, invalid("invalid token"), "-"},
+		{"expired token", "GET", "/contacts", "Bearer " + testToken(t, time.Now().Add(-time.Hour), ScopeContactsRead), http.StatusUnauthorized, invalid("token expired"), "-"},
+		{"unknown API key", "GET", "/contacts", "ApiKey ak_unknown", http.StatusUnauthorized, invalid("invalid API key"), "-"},
+		{"revoked API key", "GET", "/contacts", "Bearer ak_revoked", http.StatusUnauthorized, invalid("API key revoked"), "-"},
+		{"key store failure", "GET", "/contacts", "ApiKey ak_broken", http.StatusInternalServerError, nil, "-"},
+		{"token with scope", "GET", "/contacts/1", "Bearer " + testToken(t, later, ScopeContactsRead), http.StatusOK, nil, "user-1"},
+		{"token without write scope", "PUT", "/contacts/1", "Bearer " + testToken(t, later, ScopeContactsRead), http.StatusForbidden, insufficient(ScopeContactsWrite), "-"},
+		{"batch needs write scope", "POST", "/contacts:batch", "Bearer " + testToken(t, later, ScopeContactsRead), http.StatusForbidden, insufficient(ScopeContactsWrite), "-"},
+		{"changes need both read scopes", "GET", "/changes", "Bearer " + testToken(t, later, ScopeContactsRead), http.StatusForbidden, insufficient(ScopeGroupsRead), "-"},
+		{"read-only key reads", "GET", "/groups", "ApiKey ak_readonly", http.StatusOK, nil, "apikey:key-1"},
+		{"read-only key writes", "POST", "/groups", "ApiKey ak_readonly", http.StatusForbidden, insufficient(ScopeGroupsWrite), "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Values("WWW-Authenticate"); !reflect.DeepEqual(got, tt.wantChallenge) {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+			switch {
+			case tt.wantSubject == "-":
+				if principal != nil {
+					t.Errorf("request reached the handler as %+v", principal)
+				}
+			case principal == nil:
+				t.Errorf("request did not reach the handler")
+			case principal.Subject != tt.wantSubject || tt.wantSubject != "" && principal.TenantID != testTenant:
+				t.Errorf("principal = %+v, want subject %q of %s", principal, tt.wantSubject, testTenant)
+			}
+		})
+	}
+}
+
+func TestRequiredScopes(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         []string
+	}{
+		{"GET", "/contacts", []string{ScopeContactsRead}},
+		{"HEAD", "/contacts/1/avatar", []string{ScopeContactsRead}},
+		{"POST", "/contacts", []string{ScopeContactsWrite}},
+		{"DELETE", "/contacts/1", []string{ScopeContactsWrite}},
+		{"POST", "/contacts:batch", []string{ScopeContactsWrite}},
+		{"POST", "/contacts/sync", []string{ScopeContactsWrite}},
+		{"GET", "/groups/1/contacts", []string{ScopeGroupsRead}},
+		{"POST", "/groups/1/contacts", []string{ScopeGroupsWrite}},
+		{"GET", "/changes", []string{ScopeContactsRead, ScopeGroupsRead}},
+		{"GET", "/apikeys", nil},
+		{"POST", "/webhooks", nil},
+		{"GET", "/contactsx", nil},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := requiredScopes(r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("requiredScopes(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
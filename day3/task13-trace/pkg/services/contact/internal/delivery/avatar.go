@@ -0,0 +1,141 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go/pkg/services/contact/internal/avatar"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+// avatarMaxAge is how long clients may show a photo without asking again.
+// The URL stays the same when the photo changes, so it is short; after it,
+// the ETag makes asking again cheap.
+const avatarMaxAge = 5 * time.Minute
+
+type AvatarHandler struct {
+	useCase usecase.AvatarUseCase
+	logger  *log.Logger
+}
+
+func NewAvatarHandler(useCase usecase.AvatarUseCase, logger *log.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleUpload serves POST /contacts/{id}/avatar. The body is the JPEG or
+// PNG photo itself; its type is told from the content.
+func (h *AvatarHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, avatar.MaxUploadSize))
+	if err != nil {
+		h.logger.Printf("[%s] Error reading avatar: %v\n", traceID, err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	a, err := h.useCase.SetAvatar(r.Context(), r.PathValue("id"), data)
+	if err != nil {
+		h.logger.Printf("[%s] Error setting avatar: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// HandleGet serves GET /contacts/{id}/avatar?size=, the photo or one of its
+// thumbnails. Requests with a matching If-None-Match get 304.
+func (h *AvatarHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = avatar.SizeOriginal
+	}
+	if !avatar.ValidSize(size) {
+		http.Error(w, "size must be original, small or medium", http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.useCase.GetAvatar(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.Printf("[%s] Error getting avatar: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	etag := `"` + a.ETag + "-" + size + `"`
+	setCacheHeaders := func() {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(avatarMaxAge.Seconds())))
+		w.Header().Set("Last-Modified", a.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		setCacheHeaders()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	photo, err := h.useCase.OpenAvatar(r.Context(), a, size)
+	if err != nil {
+		h.logger.Printf("[%s] Error reading avatar: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	defer photo.Close()
+
+	setCacheHeaders()
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, photo); err != nil {
+		h.logger.Printf("[%s] Error writing avatar: %v\n", traceID, err)
+	}
+}
+
+// HandleDelete serves DELETE /contacts/{id}/avatar.
+func (h *AvatarHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	if err := h.useCase.DeleteAvatar(r.Context(), r.PathValue("id")); err != nil {
+		h.logger.Printf("[%s] Error deleting avatar: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag or is "*".
+// Weak validators match their strong counterparts, as RFC 9110 requires
+// for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+const maxBatchRequestSize = 1 << 20
+
+type BatchHandler struct {
+	useCase usecase.BatchUseCase
+	logger  *log.Logger
+}
+
+func NewBatchHandler(useCase usecase.BatchUseCase, logger *log.Logger) *BatchHandler {
+	return &BatchHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves POST /contacts:batch. A batch that ran gets 200 even if
+// operations failed; the results and Committed tell what was applied.
+func (h *BatchHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	var req domain.BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchRequestSize)).Decode(&req); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	response, err := h.useCase.ApplyBatch(r.Context(), &req)
+	if err != nil {
+		h.logger.Printf("[%s] Error applying batch: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	if !response.Committed {
+		h.logger.Printf("[%s] Batch rolled back\n", traceID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,142 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+// streamKeepAlive is how long the stream waits for changes before it sends
+// a comment, which keeps proxies from closing an idle connection.
+const streamKeepAlive = 25 * time.Second
+
+type ChangeHandler struct {
+	useCase usecase.ChangeUseCase
+	logger  *log.Logger
+}
+
+func NewChangeHandler(useCase usecase.ChangeUseCase, logger *log.Logger) *ChangeHandler {
+	return &ChangeHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves GET /changes?since=&limit=&wait=. With wait, in
+// seconds, the request is held until there is a change or wait runs out.
+func (h *ChangeHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	query := r.URL.Query()
+	since, err := queryInt(query.Get("since"))
+	if err != nil {
+		http.Error(w, "since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	wait, err := queryInt(query.Get("wait"))
+	if err != nil {
+		http.Error(w, "wait: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.useCase.GetChanges(r.Context(), since, int(limit), time.Duration(wait)*time.Second)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing changes: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// HandleStream serves GET /changes/stream as Server-Sent Events: one event
+// per change with the change's Seq as its id, starting after ?since= or
+// the Last-Event-ID a reconnecting client sends.
+func (h *ChangeHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("since")
+	}
+	since, err := queryInt(cursor)
+	if err != nil {
+		http.Error(w, "since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The first page is read before the headers go out, so that a refused
+	// request still gets a plain error response.
+	changes, err := h.useCase.GetChanges(r.Context(), since, 0, 0)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing changes: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	for {
+		if len(changes) == 0 {
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		for _, change := range changes {
+			if err = writeChangeEvent(w, change); err != nil {
+				break
+			}
+			since = change.Seq
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			h.logger.Printf("[%s] Change stream closed: %v\n", traceID, err)
+			return
+		}
+
+		changes, err = h.useCase.GetChanges(r.Context(), since, 0, streamKeepAlive)
+		if err != nil {
+			if r.Context().Err() == nil {
+				h.logger.Printf("[%s] Error listing changes: %v\n", traceID, err)
+			}
+			return
+		}
+	}
+}
+
+func writeChangeEvent(w http.ResponseWriter, change *domain.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Type, data)
+	return err
+}
+
+// queryInt parses an optional integer query value; empty means 0.
+func queryInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,225 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"go/pkg/services/contact/internal/contactcsv"
+	"go/pkg/services/contact/internal/domain"
+)
+
+const (
+	maxCSVImportSize = 50 << 20
+	csvBatchSize     = 500
+)
+
+type csvRowError struct {
+	Row   int
+	Line  int    `json:",omitempty"`
+	Field string `json:",omitempty"`
+	Error string
+}
+
+type csvImportReport struct {
+	DryRun   bool
+	Rows     int
+	Imported int
+	Failed   int
+	Errors   []csvRowError
+}
+
+type csvBatch struct {
+	contacts []*domain.Contact
+	rows     []int
+}
+
+func csvDelimiter(r *http.Request) (rune, bool) {
+	d := r.URL.Query().Get("delimiter")
+	if d == "" {
+		return ',', true
+	}
+	if d == `\t` {
+		return '\t', true
+	}
+	c, size := utf8.DecodeRuneInString(d)
+	return c, size == len(d) && c != '"' && c != '\r' && c != '\n'
+}
+
+func rowError(row int, err error) csvRowError {
+	e := csvRowError{Row: row, Error: err.Error()}
+	var rowErr *contactcsv.RowError
+	if errors.As(err, &rowErr) {
+		e.Line = rowErr.Line
+		e.Error = rowErr.Err.Error()
+	}
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		e.Field = validationErr.Field
+		e.Error = validationErr.Reason
+	}
+	return e
+}
+
+// HandleImportCSV serves POST /contacts/import.csv. The column mapping is an
+// optional JSON object in the "mapping" query parameter, e.g.
+// {"Name":"FullName","Mobile":"PhoneNumber"}; dry_run=true validates only.
+// Invalid rows are reported; any other error fails the request, leaving the
+// batches before it imported.
+func (h *ContactHandler) HandleImportCSV(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	var mapping contactcsv.Mapping
+	if m := r.URL.Query().Get("mapping"); m != "" {
+		if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+			http.Error(w, "invalid mapping: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	comma, ok := csvDelimiter(r)
+	if !ok {
+		http.Error(w, "invalid delimiter", http.StatusBadRequest)
+		return
+	}
+
+	reader, err := contactcsv.NewReader(http.MaxBytesReader(w, r.Body, maxCSVImportSize), mapping, comma)
+	if err != nil {
+		h.logger.Printf("[%s] Error reading CSV header: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := csvImportReport{DryRun: dryRun, Errors: []csvRowError{}}
+	batch := csvBatch{}
+
+	flush := func() error {
+		if len(batch.contacts) == 0 {
+			return nil
+		}
+		err := h.useCase.CreateContacts(r.Context(), batch.contacts)
+		if err != nil {
+			h.logger.Printf("[%s] Error importing rows %d-%d: %v\n", traceID, batch.rows[0], batch.rows[len(batch.rows)-1], err)
+			if !errors.Is(err, domain.ErrValidation) {
+				return err
+			}
+			for _, row := range batch.rows {
+				report.Errors = append(report.Errors, rowError(row, err))
+			}
+			report.Failed += len(batch.rows)
+		} else {
+			report.Imported += len(batch.contacts)
+		}
+		batch = csvBatch{}
+		return nil
+	}
+
+	for {
+		contact, row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *contactcsv.RowError
+		if err != nil && !errors.As(err, &rowErr) {
+			h.logger.Printf("[%s] Error reading CSV: %v\n", traceID, err)
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		report.Rows++
+		if err == nil {
+			err = h.useCase.ValidateContact(r.Context(), contact)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, rowError(row, err))
+			report.Failed++
+			continue
+		}
+		if dryRun {
+			continue
+		}
+
+		batch.contacts = append(batch.contacts, contact)
+		batch.rows = append(batch.rows, row)
+		if len(batch.contacts) >= csvBatchSize {
+			if err := flush(); err != nil {
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// customFieldNames returns the sorted names of the custom fields any of the
+// contacts has, which become the export's custom field columns.
+func customFieldNames(contacts []*domain.Contact) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, contact := range contacts {
+		for name := range contact.CustomFields {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *ContactHandler) HandleExportCSV(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	comma, ok := csvDelimiter(r)
+	if !ok {
+		http.Error(w, "invalid delimiter", http.StatusBadRequest)
+		return
+	}
+
+	contacts, err := h.listContacts(r)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing contacts: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contactcsv.MediaType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="contacts.csv"`)
+
+	writer := contactcsv.NewWriter(w, comma)
+	if err := writer.WriteHeader(customFieldNames(contacts)); err != nil {
+		h.logger.Printf("[%s] Error writing CSV: %v\n", traceID, err)
+		return
+	}
+	for _, contact := range contacts {
+		if err := writer.Write(contact); err != nil {
+			h.logger.Printf("[%s] Error writing CSV: %v\n", traceID, err)
+			return
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		h.logger.Printf("[%s] Error writing CSV: %v\n", traceID, err)
+	}
+}
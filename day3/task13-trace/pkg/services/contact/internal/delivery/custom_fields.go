@@ -0,0 +1,129 @@
+package delivery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+// customFieldParam prefixes the query parameters that filter by a custom
+// field, as in ?field.score=5.
+const customFieldParam = "field."
+
+type CustomFieldHandler struct {
+	useCase usecase.CustomFieldUseCase
+	logger  *log.Logger
+}
+
+func NewCustomFieldHandler(useCase usecase.CustomFieldUseCase, logger *log.Logger) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves /contacts/fields: GET lists the tenant's custom fields,
+// POST defines one and DELETE ?name= removes one along with its values.
+func (h *CustomFieldHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getCustomFields(w, r)
+	case http.MethodPost:
+		h.defineCustomField(w, r)
+	case http.MethodDelete:
+		h.deleteCustomField(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomFieldHandler) getCustomFields(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	fields, err := h.useCase.GetCustomFields(r.Context())
+	if err != nil {
+		h.logger.Printf("[%s] Error listing custom fields: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fields)
+}
+
+func (h *CustomFieldHandler) defineCustomField(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	var field domain.CustomField
+	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.DefineCustomField(r.Context(), &field); err != nil {
+		h.logger.Printf("[%s] Error defining custom field: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(field)
+}
+
+func (h *CustomFieldHandler) deleteCustomField(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.DeleteCustomField(r.Context(), name); err != nil {
+		h.logger.Printf("[%s] Error deleting custom field: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// contactFilter reads the tag and field.<name> query parameters. A contact
+// matches when it has every tag and every field value given.
+func contactFilter(r *http.Request) *domain.ContactFilter {
+	filter := &domain.ContactFilter{}
+	for key, values := range r.URL.Query() {
+		switch {
+		case key == "tag":
+			filter.Tags = append(filter.Tags, values...)
+		case strings.HasPrefix(key, customFieldParam):
+			if filter.CustomFields == nil {
+				filter.CustomFields = map[string]interface{}{}
+			}
+			filter.CustomFields[strings.TrimPrefix(key, customFieldParam)] = values[0]
+		}
+	}
+	if len(filter.Tags) == 0 && len(filter.CustomFields) == 0 {
+		return nil
+	}
+	return filter
+}
+
+// listContacts returns the contacts the request's filter selects, or all
+// of them without one.
+func (h *ContactHandler) listContacts(r *http.Request) ([]*domain.Contact, error) {
+	if filter := contactFilter(r); filter != nil {
+		return h.useCase.FindContacts(r.Context(), filter)
+	}
+	return h.useCase.GetAllContacts(r.Context())
+}
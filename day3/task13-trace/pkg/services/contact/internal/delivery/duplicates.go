@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type mergeRequest struct {
+	SurvivorID   string
+	DuplicateIDs []string
+}
+
+func (h *ContactHandler) HandleDuplicates(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	groups, err := h.useCase.FindDuplicates(r.Context())
+	if err != nil {
+		h.logger.Printf("[%s] Error finding duplicates: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+func (h *ContactHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	var req mergeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contact, err := h.useCase.MergeContacts(r.Context(), req.SurvivorID, req.DuplicateIDs)
+	if err != nil {
+		h.logger.Printf("[%s] Error merging contacts: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
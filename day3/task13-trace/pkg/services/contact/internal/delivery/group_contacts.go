@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HandleGroupContacts serves GET /groups/contacts?id= with the members of
+// one group.
+func (h *GroupHandler) HandleGroupContacts(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	groupID := r.URL.Query().Get("id")
+	if groupID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	contacts, err := h.useCase.GetGroupContacts(r.Context(), groupID)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing group contacts: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contacts)
+}
+
+// HandleGroupSubtree serves GET /groups/subtree?id= with the group followed
+// by all groups below it.
+func (h *GroupHandler) HandleGroupSubtree(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	groupID := r.URL.Query().Get("id")
+	if groupID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.useCase.GetGroupSubtree(r.Context(), groupID)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing group subtree: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// HandleEffectiveMembers serves GET /groups/members?id= with the members of
+// the group and of all groups below it.
+func (h *GroupHandler) HandleEffectiveMembers(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	groupID := r.URL.Query().Get("id")
+	if groupID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	contacts, err := h.useCase.GetEffectiveMembers(r.Context(), groupID)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing effective members: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contacts)
+}
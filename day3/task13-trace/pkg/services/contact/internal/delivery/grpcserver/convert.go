@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"go/pkg/services/contact/api/contactpb"
+	"go/pkg/services/contact/internal/domain"
+)
+
+func contactFromProto(c *contactpb.Contact) *domain.Contact {
+	if c == nil {
+		return &domain.Contact{}
+	}
+	return &domain.Contact{
+		ID:          c.GetId(),
+		FullName:    c.GetFullName(),
+		FirstName:   c.GetFirstName(),
+		Patronymic:  c.GetPatronymic(),
+		PhoneNumber: c.GetPhoneNumber(),
+		Email:       c.GetEmail(),
+		Address: domain.Address{
+			Street:     c.GetAddress().GetStreet(),
+			Locality:   c.GetAddress().GetLocality(),
+			Region:     c.GetAddress().GetRegion(),
+			PostalCode: c.GetAddress().GetPostalCode(),
+			Country:    c.GetAddress().GetCountry(),
+		},
+	}
+}
+
+func contactToProto(c *domain.Contact) *contactpb.Contact {
+	return &contactpb.Contact{
+		Id:          c.ID,
+		FullName:    c.FullName,
+		FirstName:   c.FirstName,
+		Patronymic:  c.Patronymic,
+		PhoneNumber: c.PhoneNumber,
+		Email:       c.Email,
+		Address: &contactpb.Address{
+			Street:     c.Address.Street,
+			Locality:   c.Address.Locality,
+			Region:     c.Address.Region,
+			PostalCode: c.Address.PostalCode,
+			Country:    c.Address.Country,
+		},
+	}
+}
+
+func groupFromProto(g *contactpb.Group) *domain.Group {
+	return &domain.Group{
+		ID:   g.GetId(),
+		Name: g.GetName(),
+	}
+}
+
+func groupToProto(g *domain.Group) *contactpb.Group {
+	return &contactpb.Group{
+		Id:   g.ID,
+		Name: g.Name,
+	}
+}
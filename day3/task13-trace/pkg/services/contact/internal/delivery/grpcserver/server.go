@@ -0,0 +1,285 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"go/pkg/services/contact/api/contactpb"
+	"go/pkg/services/contact/internal/delivery"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// NewServer builds a gRPC server exposing the contact and group use cases
+// together with the standard health and reflection services. The contact and
+// group services require the same credentials and scopes as HTTP.
+func NewServer(contactUseCase usecase.ContactUseCase, groupUseCase usecase.GroupUseCase, authenticator *delivery.Authenticator, logger *log.Logger) (*grpc.Server, *health.Server) {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(traceInterceptor(logger), authInterceptor(authenticator, logger)))
+
+	contactpb.RegisterContactServiceServer(server, &contactServer{useCase: contactUseCase})
+	contactpb.RegisterGroupServiceServer(server, &groupServer{useCase: groupUseCase})
+
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus(contactpb.ContactService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(contactpb.GroupService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	reflection.Register(server)
+
+	return server, healthServer
+}
+
+// traceInterceptor mirrors the HTTP handlers: it takes the trace ID from the
+// x-trace-id metadata or generates one, and logs every call with it.
+func traceInterceptor(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		traceID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-trace-id"); len(values) > 0 {
+				traceID = values[0]
+			}
+		}
+		if traceID == "" {
+			traceID = uuid.New().String()
+		}
+
+		ctx = context.WithValue(ctx, "traceID", traceID)
+		ctx = context.WithValue(ctx, "requestID", uuid.New())
+
+		logger.Printf("[%s] gRPC %s\n", traceID, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			logger.Printf("[%s] Error in %s: %v\n", traceID, info.FullMethod, err)
+		}
+		return resp, toStatus(err)
+	}
+}
+
+// authInterceptor checks the bearer token or API key in the authorization
+// metadata of contact and group calls and stores the principal in the
+// context like the HTTP middleware does.
+func authInterceptor(authenticator *delivery.Authenticator, logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok := methodScope(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		authorization := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				authorization = values[0]
+			}
+		}
+
+		principal, err := authenticator.Authenticate(ctx, authorization)
+		if errors.Is(err, delivery.ErrNoCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "bearer token or API key required")
+		}
+		if err != nil {
+			logger.Printf("Rejected credentials for %s: %v\n", info.FullMethod, err)
+			if description, ok := delivery.CredentialProblem(err); ok {
+				return nil, status.Error(codes.Unauthenticated, description)
+			}
+			return nil, status.Error(codes.Internal, "authentication failed")
+		}
+		if !principal.HasScope(scope) {
+			return nil, status.Error(codes.PermissionDenied, "missing scope "+scope)
+		}
+
+		return handler(principal.WithContext(ctx), req)
+	}
+}
+
+// methodScope maps a full gRPC method name to the scope it requires. Calls
+// outside the contact and group services, e.g. health checks, need none.
+func methodScope(fullMethod string) (string, bool) {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	read := strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") || strings.HasPrefix(method, "Lookup")
+	switch {
+	case service == contactpb.ContactService_ServiceDesc.ServiceName && read:
+		return delivery.ScopeContactsRead, true
+	case service == contactpb.ContactService_ServiceDesc.ServiceName:
+		return delivery.ScopeContactsWrite, true
+	case service == contactpb.GroupService_ServiceDesc.ServiceName && read:
+		return delivery.ScopeGroupsRead, true
+	case service == contactpb.GroupService_ServiceDesc.ServiceName:
+		return delivery.ScopeGroupsWrite, true
+	}
+	return "", false
+}
+
+func toStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+type contactServer struct {
+	contactpb.UnimplementedContactServiceServer
+	useCase usecase.ContactUseCase
+}
+
+func (s *contactServer) CreateContact(ctx context.Context, req *contactpb.CreateContactRequest) (*contactpb.Contact, error) {
+	contact := contactFromProto(req.GetContact())
+	if err := s.useCase.CreateContact(ctx, contact); err != nil {
+		return nil, err
+	}
+	return contactToProto(contact), nil
+}
+
+func (s *contactServer) GetContact(ctx context.Context, req *contactpb.GetContactRequest) (*contactpb.Contact, error) {
+	contact, err := s.useCase.GetContactByID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return contactToProto(contact), nil
+}
+
+func (s *contactServer) UpdateContact(ctx context.Context, req *contactpb.UpdateContactRequest) (*contactpb.Contact, error) {
+	contact := contactFromProto(req.GetContact())
+	// The message has neither tags, custom fields nor dates, so an update
+	// keeps the contact's.
+	current, err := s.useCase.GetContactByID(ctx, contact.ID)
+	if err != nil {
+		return nil, err
+	}
+	contact.Tags = current.Tags
+	contact.CustomFields = current.CustomFields
+	contact.Birthday = current.Birthday
+	contact.Anniversaries = current.Anniversaries
+	if err := s.useCase.UpdateContact(ctx, contact); err != nil {
+		return nil, err
+	}
+	return contactToProto(contact), nil
+}
+
+func (s *contactServer) DeleteContact(ctx context.Context, req *contactpb.DeleteContactRequest) (*emptypb.Empty, error) {
+	if err := s.useCase.DeleteContact(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *contactServer) ListContacts(ctx context.Context, req *contactpb.ListContactsRequest) (*contactpb.ListContactsResponse, error) {
+	contacts, err := s.useCase.GetAllContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &contactpb.ListContactsResponse{Contacts: make([]*contactpb.Contact, 0, len(contacts))}
+	for _, contact := range contacts {
+		resp.Contacts = append(resp.Contacts, contactToProto(contact))
+	}
+	return resp, nil
+}
+
+func (s *contactServer) LookupContactByPhone(ctx context.Context, req *contactpb.LookupContactByPhoneRequest) (*contactpb.Contact, error) {
+	contact, err := s.useCase.LookupContactByPhone(ctx, req.GetPhoneNumber())
+	if err != nil {
+		return nil, err
+	}
+	return contactToProto(contact), nil
+}
+
+type groupServer struct {
+	contactpb.UnimplementedGroupServiceServer
+	useCase usecase.GroupUseCase
+}
+
+func (s *groupServer) CreateGroup(ctx context.Context, req *contactpb.CreateGroupRequest) (*contactpb.Group, error) {
+	group := groupFromProto(req.GetGroup())
+	if err := s.useCase.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return groupToProto(group), nil
+}
+
+func (s *groupServer) GetGroup(ctx context.Context, req *contactpb.GetGroupRequest) (*contactpb.Group, error) {
+	group, err := s.useCase.GetGroupByID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return groupToProto(group), nil
+}
+
+func (s *groupServer) UpdateGroup(ctx context.Context, req *contactpb.UpdateGroupRequest) (*contactpb.Group, error) {
+	group := groupFromProto(req.GetGroup())
+	// The message has neither parent nor rule, so an update keeps the
+	// group's.
+	current, err := s.useCase.GetGroupByID(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	group.ParentID = current.ParentID
+	group.Rule = current.Rule
+	if err := s.useCase.UpdateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return groupToProto(group), nil
+}
+
+func (s *groupServer) DeleteGroup(ctx context.Context, req *contactpb.DeleteGroupRequest) (*emptypb.Empty, error) {
+	if err := s.useCase.DeleteGroup(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *groupServer) ListGroups(ctx context.Context, req *contactpb.ListGroupsRequest) (*contactpb.ListGroupsResponse, error) {
+	groups, err := s.useCase.GetAllGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &contactpb.ListGroupsResponse{Groups: make([]*contactpb.Group, 0, len(groups))}
+	for _, group := range groups {
+		resp.Groups = append(resp.Groups, groupToProto(group))
+	}
+	return resp, nil
+}
+
+func (s *groupServer) ListGroupContacts(ctx context.Context, req *contactpb.ListGroupContactsRequest) (*contactpb.ListContactsResponse, error) {
+	contacts, err := s.useCase.GetGroupContacts(ctx, req.GetGroupId())
+	if err != nil {
+		return nil, err
+	}
+	resp := &contactpb.ListContactsResponse{Contacts: make([]*contactpb.Contact, 0, len(contacts))}
+	for _, contact := range contacts {
+		resp.Contacts = append(resp.Contacts, contactToProto(contact))
+	}
+	return resp, nil
+}
+
+func (s *groupServer) AddContactToGroup(ctx context.Context, req *contactpb.AddContactToGroupRequest) (*emptypb.Empty, error) {
+	if err := s.useCase.AddContactToGroup(ctx, req.GetContactId(), req.GetGroupId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
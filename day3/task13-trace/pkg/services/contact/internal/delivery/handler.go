@@ -3,7 +3,9 @@ package delivery
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
+	"go/pkg/services/contact/internal/domain"
 	"go/pkg/services/contact/internal/usecase"
 	"log"
 	"net/http"
@@ -24,7 +26,7 @@ func NewContactHandler(useCase usecase.ContactUseCase, logger *log.Logger) *Cont
 }
 
 
-func (h *ContactHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+func traceRequest(r *http.Request) (*http.Request, string) {
     
     requestID := uuid.New()
 
@@ -38,19 +40,39 @@ func (h *ContactHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
     ctx := context.WithValue(r.Context(), "traceID", traceID)
     ctx = context.WithValue(ctx, "requestID", requestID)
 
+    return r.WithContext(ctx), traceID
+}
+
+
+func errorStatus(err error) int {
+    switch {
+    case errors.Is(err, domain.ErrNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, domain.ErrValidation):
+        return http.StatusBadRequest
+    case errors.Is(err, domain.ErrForbidden):
+        return http.StatusForbidden
+    }
+    return http.StatusInternalServerError
+}
+
+
+func (h *ContactHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+    r, traceID := traceRequest(r)
+
 	
 	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
 
 	
 	switch r.Method {
 	case http.MethodGet:
-		h.getContact(w, r.WithContext(ctx))
+		h.getContact(w, r)
 	case http.MethodPost:
-		h.createContact(w, r.WithContext(ctx))
+		h.createContact(w, r)
 	case http.MethodPut:
-		h.updateContact(w, r.WithContext(ctx))
+		h.updateContact(w, r)
 	case http.MethodDelete:
-		h.deleteContact(w, r.WithContext(ctx))
+		h.deleteContact(w, r)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -66,14 +88,26 @@ func (h *ContactHandler) getContact(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("[%s] Getting contact\n", traceID)
 
 	
-	requestID := r.Context().Value("requestID").(uuid.UUID)
+	contactID := r.URL.Query().Get("id")
+	if contactID == "" {
+		contacts, err := h.listContacts(r)
+		if err != nil {
+			h.logger.Printf("[%s] Error listing contacts: %v\n", traceID, err)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(contacts)
+		return
+	}
 
 	
-	contact, err := h.useCase.GetContact(requestID)
+	contact, err := h.useCase.GetContactByID(r.Context(), contactID)
 	if err != nil {
 		
 		h.logger.Printf("[%s] Error getting contact: %v\n", traceID, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -91,7 +125,7 @@ func (h *ContactHandler) createContact(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("[%s] Creating contact\n", traceID)
 
 	
-	var contact usecase.Contact
+	var contact domain.Contact
 	err := json.NewDecoder(r.Body).Decode(&contact)
 	if err != nil {
 		
@@ -101,17 +135,17 @@ func (h *ContactHandler) createContact(w http.ResponseWriter, r *http.Request) {
 	}
 
 	
-	err = h.useCase.CreateContact(&contact)
+	err = h.useCase.CreateContact(r.Context(), &contact)
 	if err != nil {
 		
 		h.logger.Printf("[%s] Error creating contact: %v\n", traceID, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(contact)
 }
 
@@ -124,7 +158,7 @@ func (h *ContactHandler) updateContact(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("[%s] Updating contact\n", traceID)
 
 	
-	var contact usecase.Contact
+	var contact domain.Contact
 	err := json.NewDecoder(r.Body).Decode(&contact)
 	if err != nil {
 		
@@ -138,13 +172,13 @@ func (h *ContactHandler) updateContact(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		
 		h.logger.Printf("[%s] Error updating contact: %v\n", traceID, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(contact)
 }
 
@@ -158,18 +192,16 @@ func (h *ContactHandler) deleteContact(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("[%s] Deleting contact\n", traceID)
 
 	
-	err := h.useCase.DeleteContact(r.Context())
+	err := h.useCase.DeleteContact(r.Context(), r.URL.Query().Get("id"))
 	if err != nil {
 		
 		h.logger.Printf("[%s] Error deleting contact: %v\n", traceID, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	
 	w.WriteHeader(http.StatusNoContent)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(nil)
 }
 
 
@@ -189,18 +221,7 @@ func NewGroupHandler(useCase usecase.GroupUseCase, logger *log.Logger) *GroupHan
 
 
 func (h *GroupHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
-    
-    requestID := uuid.New()
-
-    
-    traceID := r.Header.Get("X-Trace-ID")
-    if traceID == "" {
-        traceID = uuid.New().String()
-    }
-
-    
-    ctx := context.WithValue(r.Context(), "traceID", traceID)
-    ctx = context.WithValue(ctx, "requestID", requestID)
+    r, traceID := traceRequest(r)
 
     
     h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
@@ -209,16 +230,16 @@ func (h *GroupHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
     switch r.Method {
     case http.MethodGet:
         h.logger.Println("GET /group")
-        h.getGroup(w, r.WithContext(ctx))
+        h.getGroup(w, r)
     case http.MethodPost:
         h.logger.Println("POST /group")
-        h.createGroup(w, r.WithContext(ctx))
+        h.createGroup(w, r)
     case http.MethodPut:
         h.logger.Println("PUT /group")
-        h.updateGroup(w, r.WithContext(ctx))
+        h.updateGroup(w, r)
     case http.MethodDelete:
         h.logger.Println("DELETE /group")
-        h.deleteGroup(w, r.WithContext(ctx))
+        h.deleteGroup(w, r)
     default:
         http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
     }
@@ -233,14 +254,26 @@ func (h *GroupHandler) getGroup(w http.ResponseWriter, r *http.Request) {
     h.logger.Printf("[%s] Getting group\n", traceID)
 
     
-    requestID := r.Context().Value("requestID").(uuid.UUID)
+    groupID := r.URL.Query().Get("id")
+    if groupID == "" {
+        groups, err := h.useCase.GetAllGroups(r.Context())
+        if err != nil {
+            h.logger.Printf("[%s] Error listing groups: %v\n", traceID, err)
+            http.Error(w, err.Error(), errorStatus(err))
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(groups)
+        return
+    }
 
     
-    group, err := h.useCase.GetGroup(requestID)
+    group, err := h.useCase.GetGroupByID(r.Context(), groupID)
     if err != nil {
         
         h.logger.Printf("[%s] Error getting group: %v\n", traceID, err)
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), errorStatus(err))
         return
     }
 
@@ -258,7 +291,7 @@ func (h *GroupHandler) createGroup(w http.ResponseWriter, r *http.Request) {
     h.logger.Printf("[%s] Creating group\n", traceID)
 
     
-    var group usecase.Group
+    var group domain.Group
     err := json.NewDecoder(r.Body).Decode(&group)
     if err != nil {
         
@@ -268,17 +301,17 @@ func (h *GroupHandler) createGroup(w http.ResponseWriter, r *http.Request) {
     }
 
     
-    err = h.useCase.CreateGroup(&group)
+    err = h.useCase.CreateGroup(r.Context(), &group)
     if err != nil {
         
         h.logger.Printf("[%s] Error creating group: %v\n", traceID, err)
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), errorStatus(err))
         return
     }
 
     
-    w.WriteHeader(http.StatusCreated)
     w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
     json.NewEncoder(w).Encode(group)
 }
 
@@ -291,7 +324,7 @@ func (h *GroupHandler) updateGroup(w http.ResponseWriter, r *http.Request) {
     h.logger.Printf("[%s] Updating group\n", traceID)
 
     
-    var group usecase.Group
+    var group domain.Group
     err := json.NewDecoder(r.Body).Decode(&group)
     if err != nil {
         
@@ -301,17 +334,17 @@ func (h *GroupHandler) updateGroup(w http.ResponseWriter, r *http.Request) {
     }
 
     
-    err = h.useCase.UpdateGroup(&group)
+    err = h.useCase.UpdateGroup(r.Context(), &group)
     if err != nil {
         
         h.logger.Printf("[%s] Error updating group: %v\n", traceID, err)
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), errorStatus(err))
         return
     }
 
     
-    w.WriteHeader(http.StatusOK)
     w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
     json.NewEncoder(w).Encode(group)
 }
 
@@ -324,16 +357,14 @@ func (h *GroupHandler) deleteGroup(w http.ResponseWriter, r *http.Request) {
     h.logger.Printf("[%s] Deleting group\n", traceID)
 
     
-    err := h.useCase.DeleteGroup(r.Context())
+    err := h.useCase.DeleteGroup(r.Context(), r.URL.Query().Get("id"))
     if err != nil {
         
         h.logger.Printf("[%s] Error deleting group: %v\n", traceID, err)
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), errorStatus(err))
         return
     }
 
     
     w.WriteHeader(http.StatusNoContent)
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(nil)
-}
\ No newline at end of file
+}
@@ -0,0 +1,124 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go/pkg/idempotency"
+)
+
+// replayedHeaders are the response headers stored with a response and
+// replayed with it.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// IdempotencyMiddleware honours the Idempotency-Key header on the routes
+// given as "METHOD /path". The first response for a key of the tenant is
+// stored until ttl has passed and replayed, marked Idempotent-Replayed,
+// for retries; a retry whose body differs gets 422 and one that arrives
+// while the first request is running 409. 5xx responses are not stored, so
+// such requests can be retried.
+func IdempotencyMiddleware(next http.Handler, store idempotency.Store, ttl time.Duration, logger *log.Logger, routes ...string) http.Handler {
+	covered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		covered[route] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		tenantID, _ := r.Context().Value("tenantID").(string)
+		if key == "" || tenantID == "" || !covered[r.Method+" "+r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+
+		claim, record, err := store.Begin(r.Context(), tenantID, key, fingerprint, time.Now(), ttl)
+		if err != nil {
+			logger.Printf("Idempotency store error for key %q: %v\n", key, err)
+			http.Error(w, "could not check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			case !record.Completed():
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				for _, name := range replayedHeaders {
+					if value := record.Header.Get(name); value != "" {
+						w.Header().Set(name, value)
+					}
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.Status)
+				w.Write(record.Body)
+			}
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The response is recorded even when the client is already gone;
+		// it is the one most likely to retry.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			err = store.Release(ctx, claim)
+		} else {
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			err = store.Complete(ctx, claim, rec.status, header, rec.body.Bytes())
+		}
+		if err != nil {
+			logger.Printf("Could not store the response for Idempotency-Key %q: %v\n", key, err)
+		}
+	})
+}
+
+// recordingWriter passes a response through and keeps a copy of its
+// status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,126 @@
+package delivery
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go/pkg/idempotency"
+)
+
+// memoryIdempotencyStore keeps records the way the Postgres store does:
+// Complete and Release only apply to the claim that holds the key.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, tenantID, key, fingerprint string, now time.Time, ttl time.Duration) (*idempotency.Claim, *idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[tenantID+"/"+key]; ok && record.ExpiresAt.After(now) &&
+		(record.Completed() || record.CreatedAt.After(now.Add(-idempotency.PendingTimeout))) {
+		return nil, record, nil
+	}
+	s.records[tenantID+"/"+key] = &idempotency.Record{TenantID: tenantID, Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	return &idempotency.Claim{TenantID: tenantID, Key: key, Fingerprint: fingerprint, CreatedAt: now}, nil, nil
+}
+
+func (s *memoryIdempotencyStore) held(claim *idempotency.Claim) *idempotency.Record {
+	record := s.records[claim.TenantID+"/"+claim.Key]
+	if record == nil || record.Completed() || record.Fingerprint != claim.Fingerprint || !record.CreatedAt.Equal(claim.CreatedAt) {
+		return nil
+	}
+	return record
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, claim *idempotency.Claim, status int, header http.Header, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record := s.held(claim); record != nil {
+		record.Status, record.Header, record.Body = status, header, body
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, claim *idempotency.Claim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held(claim) != nil {
+		delete(s.records, claim.TenantID+"/"+claim.Key)
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*idempotency.Record{}}
+	calls := 0
+	status := http.StatusCreated
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, `{"ID":"1"}`)
+	})
+	server := IdempotencyMiddleware(handler, store, time.Hour, log.New(io.Discard, "", 0), "POST /contacts")
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/contacts", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		req = req.WithContext(context.WithValue(req.Context(), "tenantID", "tenant-1"))
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("k1", `{"FullName": "Ivan"}`)
+	retry := send("k1", `{"FullName":"Ivan"}`)
+	if calls != 1 || retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+		retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry = %d %q %v after %d calls, want the first response replayed", retry.Code, retry.Body, retry.Header(), calls)
+	}
+	if rec := send("k1", `{"FullName":"Petr"}`); rec.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("retry with another body = %d", rec.Code)
+	}
+
+	// A 5xx releases the key, so that the retry runs again.
+	status = http.StatusServiceUnavailable
+	send("k2", `{}`)
+	status = http.StatusCreated
+	if rec := send("k2", `{}`); rec.Code != http.StatusCreated || calls != 3 || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after 5xx = %d after %d calls, want the request run again", rec.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareKeepsClaimedOverKey(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*idempotency.Record{}}
+	var retry *idempotency.Claim
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The request runs past PendingTimeout and a retry claims the key
+		// over before it responds.
+		retry, _, _ = store.Begin(r.Context(), "tenant-1", "k", "retry", time.Now().Add(2*idempotency.PendingTimeout), time.Hour)
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := IdempotencyMiddleware(handler, store, time.Hour, log.New(io.Discard, "", 0), "POST /contacts")
+
+	req := httptest.NewRequest("POST", "/contacts", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "k")
+	server.ServeHTTP(httptest.NewRecorder(), req.WithContext(context.WithValue(req.Context(), "tenantID", "tenant-1")))
+
+	if retry == nil {
+		t.Fatal("the retry did not claim the key over")
+	}
+	if record := store.held(retry); record == nil {
+		t.Errorf("the first request completed the key claimed by the retry: %+v", store.records["tenant-1/k"])
+	}
+}
@@ -0,0 +1,146 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// rejectingContactUseCase fails to create contacts by the name of the
+// error it fails with.
+type rejectingContactUseCase struct {
+	fakeContactUseCase
+	created []string
+}
+
+var importErrors = map[string]error{
+	"Invalid":   &domain.ValidationError{Field: "Email", Reason: "is invalid"},
+	"Forbidden": domain.ErrForbidden,
+	"Broken":    errors.New("connection refused"),
+}
+
+func (uc *rejectingContactUseCase) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := importErrors[contact.FullName]; err != nil {
+		return err
+	}
+	uc.created = append(uc.created, contact.FullName)
+	return uc.fakeContactUseCase.CreateContact(ctx, contact)
+}
+
+// CreateContacts creates all of contacts or, like the real use case, none.
+func (uc *rejectingContactUseCase) CreateContacts(ctx context.Context, contacts []*domain.Contact) error {
+	for _, contact := range contacts {
+		if err := importErrors[contact.FullName]; err != nil {
+			return err
+		}
+	}
+	for _, contact := range contacts {
+		if err := uc.CreateContact(ctx, contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func vcards(names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		if name == "" {
+			b.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\nFN;broken\r\nEND:VCARD\r\n")
+			continue
+		}
+		b.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:" + name + "\r\nEND:VCARD\r\n")
+	}
+	return b.String()
+}
+
+func TestImportVCardErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		cards       []string
+		wantStatus  int
+		wantCreated []string
+		// wantFailed is the number of cards reported as failed.
+		wantFailed int
+	}{
+		{"malformed and invalid cards", []string{"Ada", "", "Invalid", "Bob"}, http.StatusOK, []string{"Ada", "Bob"}, 2},
+		{"forbidden", []string{"Ada", "Forbidden", "Bob"}, http.StatusForbidden, []string{"Ada"}, 0},
+		{"store failure", []string{"Broken", "Ada"}, http.StatusInternalServerError, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &rejectingContactUseCase{}
+			handler := NewContactHandler(uc, log.New(io.Discard, "", 0))
+			r := httptest.NewRequest("POST", "/contacts/import", strings.NewReader(vcards(tt.cards...)))
+			w := httptest.NewRecorder()
+			handler.HandleImportVCard(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if strings.Join(uc.created, ",") != strings.Join(tt.wantCreated, ",") {
+				t.Errorf("created %v, want %v", uc.created, tt.wantCreated)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var report vcardImportReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Imported != len(tt.wantCreated) || report.Failed != tt.wantFailed {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        []string
+		wantStatus  int
+		wantCreated []string
+		wantFailed  int
+	}{
+		{"invalid rows", []string{"Ada", "", "Invalid"}, http.StatusOK, nil, 3},
+		{"valid rows", []string{"Ada", "", "Bob"}, http.StatusOK, []string{"Ada", "Bob"}, 1},
+		{"forbidden", []string{"Ada", "Forbidden"}, http.StatusForbidden, nil, 0},
+		{"store failure", []string{"Ada", "Broken"}, http.StatusInternalServerError, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &rejectingContactUseCase{}
+			handler := NewContactHandler(uc, log.New(io.Discard, "", 0))
+			body := "FullName\n\"" + strings.Join(tt.rows, "\"\n\"") + "\"\n"
+			r := httptest.NewRequest("POST", "/contacts/import.csv", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.HandleImportCSV(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if strings.Join(uc.created, ",") != strings.Join(tt.wantCreated, ",") {
+				t.Errorf("created %v, want %v", uc.created, tt.wantCreated)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var report csvImportReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Imported != len(tt.wantCreated) || report.Failed != tt.wantFailed || len(report.Errors) != tt.wantFailed {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HandleLookup serves GET /contacts/lookup?phone= for caller-ID lookups.
+func (h *ContactHandler) HandleLookup(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	phoneNumber := r.URL.Query().Get("phone")
+	if phoneNumber == "" {
+		http.Error(w, "phone is required", http.StatusBadRequest)
+		return
+	}
+
+	contact, err := h.useCase.LookupContactByPhone(r.Context(), phoneNumber)
+	if err != nil {
+		h.logger.Printf("[%s] Error looking up contact: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
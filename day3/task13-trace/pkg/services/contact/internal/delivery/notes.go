@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+type NoteHandler struct {
+	useCase usecase.NoteUseCase
+	logger  *log.Logger
+}
+
+func NewNoteHandler(useCase usecase.NoteUseCase, logger *log.Logger) *NoteHandler {
+	return &NoteHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves /contacts/{id}/notes: GET ?type=&cursor=&limit= pages
+// through the contact's notes, latest first, and POST adds one.
+func (h *NoteHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getNotes(w, r)
+	case http.MethodPost:
+		h.addNote(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *NoteHandler) getNotes(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetNotes(r.Context(), r.PathValue("id"), query.Get("type"), query.Get("cursor"), int(limit))
+	if err != nil {
+		h.logger.Printf("[%s] Error listing notes: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *NoteHandler) addNote(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	var note domain.Note
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	note.ContactID = r.PathValue("id")
+
+	if err := h.useCase.AddNote(r.Context(), &note); err != nil {
+		h.logger.Printf("[%s] Error adding note: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(note)
+}
+
+// HandleHistory serves GET /contacts/{id}/history?cursor=&limit= with the
+// contact's history, latest first.
+func (h *NoteHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetHistory(r.Context(), r.PathValue("id"), query.Get("cursor"), int(limit))
+	if err != nil {
+		h.logger.Printf("[%s] Error listing history: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
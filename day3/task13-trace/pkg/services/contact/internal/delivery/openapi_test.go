@@ -0,0 +1,623 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/api/openapi"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+// The fakes below return fully populated values, so that every field the
+// handlers write is checked against the spec. An ID of "missing" is not
+// found.
+
+const (
+	testContactID = "7d2c8f0e-1b9a-4c3e-9f5d-2a6b8c0d4e1f"
+	testGroupID   = "3b1e9c7a-5d2f-4a8b-8c6e-0f4d2b9a7c5e"
+	testTenant    = "tenant-1"
+	missingID     = "missing"
+)
+
+var testTime = time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+func testContact() *domain.Contact {
+	return &domain.Contact{
+		ID:          testContactID,
+		OwnerID:     testTenant,
+		FullName:    "Ivanov Ivan Ivanovich",
+		FirstName:   "Ivan",
+		Patronymic:  "Ivanovich",
+		PhoneNumber: "+77011234567",
+		Email:       "ivan@example.com",
+		Address: domain.Address{
+			Street:     "Abay 1",
+			Locality:   "Almaty",
+			PostalCode: "050000",
+			Country:    "Kazakhstan",
+		},
+		Version:      3,
+		Tags:         []string{"vip"},
+		CustomFields: map[string]interface{}{"score": 5.0, "nickname": "Vanya"},
+		Birthday:     &domain.Date{Year: 1990, Month: time.May, Day: 17},
+		Anniversaries: []domain.Anniversary{
+			{Label: "Wedding", Date: domain.Date{Month: time.June, Day: 20}},
+		},
+	}
+}
+
+func testGroup() *domain.Group {
+	return &domain.Group{ID: testGroupID, OwnerID: testTenant, Name: "Friends", ParentID: "", Rule: `tag = "vip"`}
+}
+
+func found(id string) error {
+	if id == missingID {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+type fakeContactUseCase struct{}
+
+func (fakeContactUseCase) ValidateContact(ctx context.Context, contact *domain.Contact) error {
+	if strings.TrimSpace(contact.FullName) == "" {
+		return &domain.ValidationError{Field: "FullName", Reason: "is required"}
+	}
+	return nil
+}
+
+func (uc fakeContactUseCase) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := uc.ValidateContact(ctx, contact); err != nil {
+		return err
+	}
+	contact.ID, contact.OwnerID, contact.Version = testContactID, testTenant, 1
+	return nil
+}
+
+func (uc fakeContactUseCase) CreateContacts(ctx context.Context, contacts []*domain.Contact) error {
+	for _, contact := range contacts {
+		if err := uc.CreateContact(ctx, contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fakeContactUseCase) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := found(contact.ID); err != nil {
+		return err
+	}
+	contact.OwnerID, contact.Version = testTenant, 4
+	return nil
+}
+
+func (fakeContactUseCase) DeleteContact(ctx context.Context, contactID string) error {
+	return found(contactID)
+}
+
+func (fakeContactUseCase) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	if err := found(contactID); err != nil {
+		return nil, err
+	}
+	return testContact(), nil
+}
+
+func (fakeContactUseCase) LookupContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error) {
+	return testContact(), nil
+}
+
+func (fakeContactUseCase) GetAllContacts(ctx context.Context) ([]*domain.Contact, error) {
+	return []*domain.Contact{testContact(), {ID: testGroupID, OwnerID: testTenant, FullName: "Bare", Version: 1}}, nil
+}
+
+func (fakeContactUseCase) FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error) {
+	return []*domain.Contact{testContact()}, nil
+}
+
+func (fakeContactUseCase) FindDuplicates(ctx context.Context) ([]*domain.DuplicateGroup, error) {
+	return []*domain.DuplicateGroup{{Contacts: []*domain.Contact{testContact(), testContact()}, Reasons: []string{"name", "phone"}}}, nil
+}
+
+func (fakeContactUseCase) MergeContacts(ctx context.Context, survivorID string, duplicateIDs []string) (*domain.Contact, error) {
+	if err := found(survivorID); err != nil {
+		return nil, err
+	}
+	return testContact(), nil
+}
+
+func (fakeContactUseCase) GetUpcomingEvents(ctx context.Context, days int, loc *time.Location) ([]domain.UpcomingEvent, error) {
+	if days > usecase.MaxUpcomingDays {
+		return nil, &domain.ValidationError{Field: "days", Reason: "is too large"}
+	}
+	return testContact().UpcomingEvents(domain.Date{Year: 2024, Month: time.May, Day: 1}, days), nil
+}
+
+type fakeGroupUseCase struct{}
+
+func (fakeGroupUseCase) CreateGroup(ctx context.Context, group *domain.Group) error {
+	group.ID, group.OwnerID = testGroupID, testTenant
+	return nil
+}
+
+func (fakeGroupUseCase) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	group.OwnerID = testTenant
+	return found(group.ID)
+}
+
+func (fakeGroupUseCase) DeleteGroup(ctx context.Context, groupID string) error {
+	return found(groupID)
+}
+
+func (fakeGroupUseCase) GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error) {
+	if err := found(groupID); err != nil {
+		return nil, err
+	}
+	return testGroup(), nil
+}
+
+func (fakeGroupUseCase) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
+	return []*domain.Group{testGroup()}, nil
+}
+
+func (fakeGroupUseCase) GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	return []*domain.Contact{testContact()}, found(groupID)
+}
+
+func (fakeGroupUseCase) AddContactToGroup(ctx context.Context, contactID, groupID string) error {
+	return found(groupID)
+}
+
+func (fakeGroupUseCase) GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error) {
+	child := testGroup()
+	child.ID, child.ParentID = testContactID, testGroupID
+	return []*domain.Group{testGroup(), child}, found(groupID)
+}
+
+func (fakeGroupUseCase) GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	return []*domain.Contact{testContact()}, found(groupID)
+}
+
+type fakeCustomFieldUseCase struct{}
+
+func (fakeCustomFieldUseCase) DefineCustomField(ctx context.Context, field *domain.CustomField) error {
+	field.OwnerID = testTenant
+	return field.Validate()
+}
+
+func (fakeCustomFieldUseCase) GetCustomFields(ctx context.Context) ([]*domain.CustomField, error) {
+	return []*domain.CustomField{{Name: "score", OwnerID: testTenant, Type: domain.CustomFieldNumber}}, nil
+}
+
+func (fakeCustomFieldUseCase) DeleteCustomField(ctx context.Context, name string) error {
+	return found(name)
+}
+
+type fakeAvatarUseCase struct{}
+
+func testAvatar() *domain.Avatar {
+	return &domain.Avatar{
+		ContactID:   testContactID,
+		OwnerID:     testTenant,
+		ContentType: "image/png",
+		ETag:        "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Width:       640,
+		Height:      480,
+		UpdatedAt:   testTime,
+	}
+}
+
+func (fakeAvatarUseCase) SetAvatar(ctx context.Context, contactID string, data []byte) (*domain.Avatar, error) {
+	return testAvatar(), found(contactID)
+}
+
+func (fakeAvatarUseCase) GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error) {
+	return testAvatar(), found(contactID)
+}
+
+func (fakeAvatarUseCase) OpenAvatar(ctx context.Context, avatar *domain.Avatar, size string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("\x89PNG\r\n\x1a\n")), nil
+}
+
+func (fakeAvatarUseCase) DeleteAvatar(ctx context.Context, contactID string) error {
+	return found(contactID)
+}
+
+type fakeNoteUseCase struct{}
+
+func testNote() *domain.Note {
+	return &domain.Note{
+		ID:         testGroupID,
+		ContactID:  testContactID,
+		OwnerID:    testTenant,
+		Author:     "user-1",
+		Type:       domain.NoteTypeCall,
+		Body:       "Talked about the **offer**.",
+		OccurredAt: testTime,
+		CreatedAt:  testTime,
+	}
+}
+
+func (fakeNoteUseCase) AddNote(ctx context.Context, note *domain.Note) error {
+	if err := found(note.ContactID); err != nil {
+		return err
+	}
+	note.ID, note.OwnerID, note.Author, note.CreatedAt = testGroupID, testTenant, "user-1", testTime
+	if note.OccurredAt.IsZero() {
+		note.OccurredAt = testTime
+	}
+	return nil
+}
+
+func (fakeNoteUseCase) GetNotes(ctx context.Context, contactID, noteType, cursor string, limit int) (*domain.NotePage, error) {
+	if err := found(contactID); err != nil {
+		return nil, err
+	}
+	return &domain.NotePage{Notes: []*domain.Note{testNote()}, NextCursor: testGroupID}, nil
+}
+
+func (fakeNoteUseCase) GetHistory(ctx context.Context, contactID, cursor string, limit int) (*domain.HistoryPage, error) {
+	if err := found(contactID); err != nil {
+		return nil, err
+	}
+	entry := &domain.HistoryEntry{
+		ID:        testGroupID,
+		ContactID: contactID,
+		OwnerID:   testTenant,
+		Action:    domain.HistoryActionNoteAdded,
+		Details:   json.RawMessage(`{"NoteID":"` + testGroupID + `","Type":"call","Author":"user-1"}`),
+		CreatedAt: testTime,
+	}
+	return &domain.HistoryPage{Entries: []*domain.HistoryEntry{entry}}, nil
+}
+
+type fakeAPIKeyUseCase struct {
+	usecase.APIKeyUseCase
+}
+
+func testAPIKey() *auth.APIKey {
+	expires := testTime.Add(24 * time.Hour)
+	return &auth.APIKey{
+		ID:         testGroupID,
+		TenantID:   testTenant,
+		Name:       "ci",
+		Prefix:     "ak_12345678",
+		Access:     auth.AccessReadOnly,
+		CreatedBy:  "user-1",
+		CreatedAt:  testTime,
+		ExpiresAt:  &expires,
+		LastUsedAt: &expires,
+	}
+}
+
+func (fakeAPIKeyUseCase) IssueAPIKey(ctx context.Context, key *auth.APIKey) (string, error) {
+	issued := testAPIKey()
+	issued.Name, issued.Access, issued.ExpiresAt, issued.LastUsedAt = key.Name, key.Access, key.ExpiresAt, nil
+	*key = *issued
+	return "ak_12345678_secret", nil
+}
+
+func (fakeAPIKeyUseCase) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	return []*auth.APIKey{testAPIKey()}, nil
+}
+
+func (fakeAPIKeyUseCase) RevokeAPIKey(ctx context.Context, keyID string) error {
+	return found(keyID)
+}
+
+type fakeWebhookUseCase struct{}
+
+func (fakeWebhookUseCase) CreateWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	subscription.ID, subscription.OwnerID, subscription.CreatedAt = testGroupID, testTenant, testTime
+	subscription.Secret = ""
+	return nil
+}
+
+func (fakeWebhookUseCase) GetWebhooks(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return []*domain.WebhookSubscription{{
+		ID:        testGroupID,
+		OwnerID:   testTenant,
+		URL:       "https://hooks.example.com/contacts",
+		Events:    []string{"contact.*"},
+		CreatedAt: testTime,
+	}}, nil
+}
+
+func (fakeWebhookUseCase) DeleteWebhook(ctx context.Context, subscriptionID string) error {
+	return found(subscriptionID)
+}
+
+func (fakeWebhookUseCase) GetWebhookDeliveries(ctx context.Context, subscriptionID, status string) ([]*domain.WebhookDelivery, error) {
+	next := testTime.Add(time.Minute)
+	return []*domain.WebhookDelivery{{
+		ID:             testContactID,
+		OwnerID:        testTenant,
+		SubscriptionID: subscriptionID,
+		EventID:        42,
+		EventType:      "contact.updated",
+		Status:         domain.DeliveryPending,
+		Attempts:       2,
+		NextAttemptAt:  &next,
+		CreatedAt:      testTime,
+		Log: []domain.WebhookAttempt{
+			{Attempt: 1, AttemptedAt: testTime, ResponseStatus: 503, DurationMS: 120},
+			{Attempt: 2, AttemptedAt: testTime, Error: "connection refused", DurationMS: 3},
+		},
+	}}, found(subscriptionID)
+}
+
+type fakeChangeUseCase struct{}
+
+func (fakeChangeUseCase) GetChanges(ctx context.Context, since int64, limit int, wait time.Duration) ([]*domain.Change, error) {
+	if since > 0 {
+		// Ends the stream after its first page.
+		return nil, context.Canceled
+	}
+	return []*domain.Change{
+		{Seq: 1, Type: domain.EventContactCreated, OccurredAt: testTime, Data: json.RawMessage(`{"ID":"` + testContactID + `"}`)},
+		{Seq: 2, Type: domain.EventGroupContactAdded, OccurredAt: testTime, Data: json.RawMessage(`{"GroupID":"` + testGroupID + `","ContactID":"` + testContactID + `"}`)},
+	}, nil
+}
+
+type fakeSyncUseCase struct{}
+
+func (fakeSyncUseCase) Sync(ctx context.Context, request *domain.SyncRequest) (*domain.SyncResponse, error) {
+	return &domain.SyncResponse{
+		Results: []domain.SyncResult{
+			{ID: testContactID, Status: domain.SyncApplied, Version: 4},
+			{ID: testGroupID, Status: domain.SyncConflict, Conflicts: []domain.FieldConflict{{
+				Field: "Email", ClientValue: "a@example.com", ServerValue: "b@example.com", ServerVersion: 3, Resolution: "server",
+			}}},
+			{ID: missingID, Status: domain.SyncRejected, Error: "not found"},
+		},
+		Contacts: []*domain.Contact{testContact()},
+		Deleted:  []string{testGroupID},
+		Token:    "token-2",
+		HasMore:  false,
+	}, nil
+}
+
+type fakeBatchUseCase struct{}
+
+func (fakeBatchUseCase) ApplyBatch(ctx context.Context, request *domain.BatchRequest) (*domain.BatchResponse, error) {
+	response := &domain.BatchResponse{Mode: domain.BatchAtomic, Committed: true}
+	for _, op := range request.Operations {
+		result := domain.BatchResult{Op: op.Op, ID: op.ID, Status: domain.BatchSucceeded}
+		if op.Op != domain.BatchDelete {
+			result.ID, result.Contact = testContactID, testContact()
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+// newTestServer serves the handlers on the routes main serves them on,
+// behind the spec middleware with response checking on. Anything the
+// middleware reports is written to mismatches.
+func newTestServer(t *testing.T, mismatches io.Writer) http.Handler {
+	t.Helper()
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discard := log.New(io.Discard, "", 0)
+	contactHandler := NewContactHandler(fakeContactUseCase{}, discard)
+	customFieldHandler := NewCustomFieldHandler(fakeCustomFieldUseCase{}, discard)
+	avatarHandler := NewAvatarHandler(fakeAvatarUseCase{}, discard)
+	noteHandler := NewNoteHandler(fakeNoteUseCase{}, discard)
+	groupHandler := NewGroupHandler(fakeGroupUseCase{}, discard)
+	apiKeyHandler := NewAPIKeyHandler(fakeAPIKeyUseCase{}, discard)
+	webhookHandler := NewWebhookHandler(fakeWebhookUseCase{}, discard)
+	changeHandler := NewChangeHandler(fakeChangeUseCase{}, discard)
+	syncHandler := NewSyncHandler(fakeSyncUseCase{}, discard)
+	batchHandler := NewBatchHandler(fakeBatchUseCase{}, discard)
+
+	routes := []struct {
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"/contacts", contactHandler.HandleHTTP},
+		{"GET /contacts/export.vcf", contactHandler.HandleExportVCard},
+		{"POST /contacts/import", contactHandler.HandleImportVCard},
+		{"GET /contacts/export.csv", contactHandler.HandleExportCSV},
+		{"POST /contacts/import.csv", contactHandler.HandleImportCSV},
+		{"GET /contacts/duplicates", contactHandler.HandleDuplicates},
+		{"GET /contacts/lookup", contactHandler.HandleLookup},
+		{"GET /contacts/upcoming", contactHandler.HandleUpcoming},
+		{"POST /contacts/merge", contactHandler.HandleMerge},
+		{"POST /contacts/sync", syncHandler.HandleHTTP},
+		{"POST /contacts:batch", batchHandler.HandleHTTP},
+		{"GET /contacts/fields", customFieldHandler.HandleHTTP},
+		{"POST /contacts/fields", customFieldHandler.HandleHTTP},
+		{"DELETE /contacts/fields", customFieldHandler.HandleHTTP},
+		{"GET /contacts/{file}", contactHandler.HandleContactVCard},
+		{"POST /contacts/{id}/avatar", avatarHandler.HandleUpload},
+		{"GET /contacts/{id}/avatar", avatarHandler.HandleGet},
+		{"DELETE /contacts/{id}/avatar", avatarHandler.HandleDelete},
+		{"GET /contacts/{id}/notes", noteHandler.HandleHTTP},
+		{"POST /contacts/{id}/notes", noteHandler.HandleHTTP},
+		{"GET /contacts/{id}/history", noteHandler.HandleHistory},
+		{"/groups", groupHandler.HandleHTTP},
+		{"GET /groups/contacts", groupHandler.HandleGroupContacts},
+		{"GET /groups/subtree", groupHandler.HandleGroupSubtree},
+		{"GET /groups/members", groupHandler.HandleEffectiveMembers},
+		{"/apikeys", apiKeyHandler.HandleHTTP},
+		{"/webhooks", webhookHandler.HandleHTTP},
+		{"GET /webhooks/deliveries", webhookHandler.HandleDeliveries},
+		{"GET /changes", changeHandler.HandleHTTP},
+		{"GET /changes/stream", changeHandler.HandleStream},
+		{"GET /openapi.json", spec.ServeHTTP},
+	}
+	mux := http.NewServeMux()
+	patterns := make([]string, 0, len(routes))
+	for _, route := range routes {
+		mux.HandleFunc(route.pattern, route.handler)
+		patterns = append(patterns, route.pattern)
+	}
+	if err := spec.CheckRoutes(patterns); err != nil {
+		t.Fatalf("test routes do not match the spec: %v", err)
+	}
+	return spec.Middleware(mux, log.New(mismatches, "", 0), true)
+}
+
+func TestHandlersMatchSpec(t *testing.T) {
+	var mismatches bytes.Buffer
+	server := newTestServer(t, &mismatches)
+
+	const vcardBody = "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Ivan\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:4.0\r\nEMAIL:x@example.com\r\nEND:VCARD\r\n"
+	const csvBody = "Full name,Phone\r\nIvan,+77011234567\r\n,\r\n"
+
+	tests := []struct {
+		method      string
+		target      string
+		contentType string
+		header      http.Header
+		body        string
+		status      int
+	}{
+		{"GET", "/contacts", "", nil, "", http.StatusOK},
+		{"GET", "/contacts?tag=vip&field.score=5", "", nil, "", http.StatusOK},
+		{"GET", "/contacts?id=" + testContactID, "", nil, "", http.StatusOK},
+		{"GET", "/contacts?id=" + missingID, "", nil, "", http.StatusNotFound},
+		{"POST", "/contacts", "application/json", nil,
+			`{"FullName":"Ivanov Ivan","PhoneNumber":"+77011234567","Tags":["vip"],"CustomFields":{"score":5},"Birthday":"--05-17","Anniversaries":[{"Label":"Wedding","Date":"2015-06-20"}]}`,
+			http.StatusCreated},
+		{"POST", "/contacts", "application/json", nil, `{"FullName":" "}`, http.StatusBadRequest},
+		{"POST", "/contacts", "application/json", nil, `{"Name":"Ivan"}`, http.StatusBadRequest},
+		{"PUT", "/contacts", "application/json", nil, `{"ID":"` + testContactID + `","FullName":"Ivanov Ivan"}`, http.StatusOK},
+		{"PUT", "/contacts", "application/json", nil, `{"ID":"` + missingID + `","FullName":"Ivanov Ivan"}`, http.StatusNotFound},
+		{"DELETE", "/contacts?id=" + testContactID, "", nil, "", http.StatusNoContent},
+		{"DELETE", "/contacts?id=" + missingID, "", nil, "", http.StatusNotFound},
+
+		{"GET", "/contacts/" + testContactID + ".vcf", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/" + testContactID + ".vcf?version=3.0", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/" + missingID + ".vcf", "", nil, "", http.StatusNotFound},
+		{"GET", "/contacts/export.vcf", "", nil, "", http.StatusOK},
+		{"POST", "/contacts/import", "text/vcard", nil, vcardBody, http.StatusOK},
+		{"GET", "/contacts/export.csv", "", nil, "", http.StatusOK},
+		{"POST", "/contacts/import.csv?mapping=" + url.QueryEscape(`{"Full name":"FullName","Phone":"PhoneNumber"}`), "text/csv", nil, csvBody, http.StatusOK},
+		{"POST", "/contacts/import.csv?dry_run=true", "text/csv", nil, "FullName\r\nIvan\r\n", http.StatusOK},
+
+		{"GET", "/contacts/duplicates", "", nil, "", http.StatusOK},
+		{"POST", "/contacts/merge", "application/json", nil, `{"SurvivorID":"` + testContactID + `","DuplicateIDs":["` + testGroupID + `"]}`, http.StatusOK},
+		{"POST", "/contacts/merge", "application/json", nil, `{"SurvivorID":"` + missingID + `","DuplicateIDs":["` + testGroupID + `"]}`, http.StatusNotFound},
+		{"GET", "/contacts/lookup?phone=%2B77011234567", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/lookup", "", nil, "", http.StatusBadRequest},
+		{"GET", "/contacts/upcoming?days=60&tz=Asia/Almaty", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/upcoming?tz=Nowhere/Else", "", nil, "", http.StatusBadRequest},
+
+		{"GET", "/contacts/fields", "", nil, "", http.StatusOK},
+		{"POST", "/contacts/fields", "application/json", nil, `{"Name":"score","Type":"number"}`, http.StatusCreated},
+		{"DELETE", "/contacts/fields?name=score", "", nil, "", http.StatusNoContent},
+		{"DELETE", "/contacts/fields?name=" + missingID, "", nil, "", http.StatusNotFound},
+
+		{"POST", "/contacts/" + testContactID + "/avatar", "image/png", nil, "\x89PNG\r\n\x1a\n", http.StatusOK},
+		{"GET", "/contacts/" + testContactID + "/avatar?size=small", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/" + testContactID + "/avatar", "", http.Header{"If-None-Match": {`"` + testAvatar().ETag + `-original"`}}, "", http.StatusNotModified},
+		{"GET", "/contacts/" + missingID + "/avatar", "", nil, "", http.StatusNotFound},
+		{"DELETE", "/contacts/" + testContactID + "/avatar", "", nil, "", http.StatusNoContent},
+
+		{"GET", "/contacts/" + testContactID + "/notes?type=call&limit=10", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/" + missingID + "/notes", "", nil, "", http.StatusNotFound},
+		{"GET", "/contacts/" + testContactID + "/history?limit=10", "", nil, "", http.StatusOK},
+		{"GET", "/contacts/" + missingID + "/history", "", nil, "", http.StatusNotFound},
+		{"POST", "/contacts/" + testContactID + "/notes", "application/json", nil, `{"Type":"meeting","Body":"Lunch","OccurredAt":"2024-02-29T13:00:00Z"}`, http.StatusCreated},
+		{"POST", "/contacts/" + testContactID + "/notes", "application/json", nil, `{"Type":"letter","Body":"Hi"}`, http.StatusBadRequest},
+
+		{"POST", "/contacts/sync", "application/json", nil,
+			`{"Token":"token-1","Strategy":"lww","Changes":[{"Op":"update","ID":"` + testContactID + `","BaseVersion":3,"Fields":{"Email":"a@example.com"}}]}`,
+			http.StatusOK},
+		{"POST", "/contacts:batch", "application/json", nil,
+			`{"Mode":"atomic","Operations":[{"Op":"create","Contact":{"FullName":"Ivan"}},{"Op":"delete","ID":"` + testGroupID + `"}]}`,
+			http.StatusOK},
+
+		{"GET", "/groups", "", nil, "", http.StatusOK},
+		{"GET", "/groups?id=" + testGroupID, "", nil, "", http.StatusOK},
+		{"POST", "/groups", "application/json", nil, `{"Name":"Friends","Rule":"tag = \"vip\""}`, http.StatusCreated},
+		{"PUT", "/groups", "application/json", nil, `{"ID":"` + testGroupID + `","Name":"Close friends"}`, http.StatusOK},
+		{"DELETE", "/groups?id=" + missingID, "", nil, "", http.StatusNotFound},
+		{"GET", "/groups/contacts?id=" + testGroupID, "", nil, "", http.StatusOK},
+		{"GET", "/groups/subtree?id=" + testGroupID, "", nil, "", http.StatusOK},
+		{"GET", "/groups/members?id=" + testGroupID, "", nil, "", http.StatusOK},
+		{"GET", "/groups/members", "", nil, "", http.StatusBadRequest},
+
+		{"GET", "/apikeys", "", nil, "", http.StatusOK},
+		{"POST", "/apikeys", "application/json", nil, `{"Name":"ci","Access":"read-write","ExpiresAt":"2025-01-01T00:00:00Z"}`, http.StatusCreated},
+		{"DELETE", "/apikeys?id=" + testGroupID, "", nil, "", http.StatusNoContent},
+
+		{"GET", "/webhooks", "", nil, "", http.StatusOK},
+		{"POST", "/webhooks", "application/json", nil, `{"URL":"https://hooks.example.com/contacts","Secret":"0123456789abcdef","Events":["contact.*"]}`, http.StatusCreated},
+		{"DELETE", "/webhooks?id=" + testGroupID, "", nil, "", http.StatusNoContent},
+		{"GET", "/webhooks/deliveries?id=" + testGroupID + "&status=pending", "", nil, "", http.StatusOK},
+
+		{"GET", "/changes?since=0&limit=10", "", nil, "", http.StatusOK},
+		{"GET", "/changes?since=abc", "", nil, "", http.StatusBadRequest},
+		{"GET", "/changes/stream", "", nil, "", http.StatusOK},
+		{"GET", "/openapi.json", "", nil, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			mismatches.Reset()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if mismatches.Len() > 0 {
+				t.Errorf("response does not match the spec:\n%s", mismatches.String())
+			}
+		})
+	}
+}
+
+// TestSpecMiddlewareReportsDrift makes sure the check above can fail: a
+// handler that writes an undocumented field or status is reported.
+func TestSpecMiddlewareReportsDrift(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	drifted := map[string]http.HandlerFunc{
+		"undocumented field": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID":"1","OwnerID":"t","Name":"Friends","Colour":"red"}]`))
+		},
+		"wrong type": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID":1,"OwnerID":"t","Name":"Friends"}]`))
+		},
+		"undocumented status": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "teapot", http.StatusTeapot)
+		},
+	}
+	for name, handler := range drifted {
+		t.Run(name, func(t *testing.T) {
+			var mismatches bytes.Buffer
+			server := spec.Middleware(handler, log.New(&mismatches, "", 0), true)
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/groups", nil))
+			if mismatches.Len() == 0 {
+				t.Error("mismatch was not reported")
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package delivery
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go/pkg/ratelimit"
+)
+
+// AddressRateLimitMiddleware limits every request by client IP address
+// with the buckets in store and the limits in config. It runs outside
+// AuthMiddleware, so that requests without or with bad credentials are
+// limited before they cost a token check or key lookup, and
+// RateLimitMiddleware limits authenticated clients once more inside it.
+func AddressRateLimitMiddleware(next http.Handler, store ratelimit.Store, config *ratelimit.Config, logger *log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if takeToken(w, r, store, config, clientAddress(r, config.TrustForwardedFor), logger) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// RateLimitMiddleware limits authenticated requests per API key, or else
+// per token subject, so it has to run inside AuthMiddleware. Requests
+// without a principal are left to AddressRateLimitMiddleware.
+func RateLimitMiddleware(next http.Handler, store ratelimit.Store, config *ratelimit.Config, logger *log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := principalClient(r)
+		if client == "" || takeToken(w, r, store, config, client, logger) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// takeToken takes a token from the client's bucket for the request and
+// reports whether the request may go on; otherwise it has answered 429 with
+// Retry-After. The RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset
+// and RateLimit-Policy headers describe the last bucket taken from. Requests
+// go through when the store fails.
+func takeToken(w http.ResponseWriter, r *http.Request, store ratelimit.Store, config *ratelimit.Config, client string, logger *log.Logger) bool {
+	name, limit, ok := config.Match(r.Method, r.URL.Path)
+	if !ok {
+		return true
+	}
+
+	result, err := store.Take(r.Context(), name+"|"+client, limit, time.Now())
+	if err != nil {
+		logger.Printf("Rate limit store error for %s: %v\n", client, err)
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+	h.Set("RateLimit-Policy", limit.String())
+
+	if !result.Allowed {
+		logger.Printf("Rate limited %s on %s %s (%s)\n", client, r.Method, r.URL.Path, name)
+		h.Set("Retry-After", ceilSeconds(result.RetryAfter))
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+func principalClient(r *http.Request) string {
+	if apiKeyID, _ := r.Context().Value("apiKeyID").(string); apiKeyID != "" {
+		return "apikey:" + apiKeyID
+	}
+	if subject, _ := r.Context().Value("subject").(string); subject != "" {
+		return "sub:" + subject
+	}
+	return ""
+}
+
+func clientAddress(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,88 @@
+package delivery
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/ratelimit"
+)
+
+// countingKeys knows one API key and counts the lookups made.
+type countingKeys struct {
+	lookups int
+}
+
+func (k *countingKeys) AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error) {
+	k.lookups++
+	if plaintext != "ak_good" {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	return &auth.APIKey{ID: "key-1", TenantID: testTenant, Access: auth.AccessReadOnly}, nil
+}
+
+func newRateLimitedServer(keys *countingKeys) http.Handler {
+	config := &ratelimit.Config{Default: &ratelimit.Limit{Requests: 2, Per: time.Hour}}
+	store := ratelimit.NewMemoryStore()
+	logger := log.New(io.Discard, "", 0)
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler = RateLimitMiddleware(handler, store, config, logger)
+	handler = AuthMiddleware(handler, NewAuthenticator(nil, keys), logger, "/openapi.json")
+	return AddressRateLimitMiddleware(handler, store, config, logger)
+}
+
+func rateLimitedRequest(server http.Handler, addr, key string) int {
+	r := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+	r.RemoteAddr = addr + ":1234"
+	if key != "" {
+		r.Header.Set("Authorization", "ApiKey "+key)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	keys := &countingKeys{}
+	server := newRateLimitedServer(keys)
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if got := rateLimitedRequest(server, "192.0.2.1", "ak_bad"); got != want {
+			t.Errorf("bad key request %d = %d, want %d", i, got, want)
+		}
+	}
+	if keys.lookups != 2 {
+		t.Errorf("%d key lookups, want 2: limited requests must not reach the key store", keys.lookups)
+	}
+	if got := rateLimitedRequest(server, "192.0.2.1", ""); got != http.StatusTooManyRequests {
+		t.Errorf("request without credentials = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := rateLimitedRequest(server, "192.0.2.2", "ak_bad"); got != http.StatusUnauthorized {
+		t.Errorf("bad key from another address = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
+
+func TestRateLimitPerPrincipal(t *testing.T) {
+	server := newRateLimitedServer(&countingKeys{})
+
+	// Each address has tokens left, but the key runs out.
+	tests := []struct {
+		addr string
+		want int
+	}{
+		{"192.0.2.1", http.StatusOK},
+		{"192.0.2.2", http.StatusOK},
+		{"192.0.2.3", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if got := rateLimitedRequest(server, tt.addr, "ak_good"); got != tt.want {
+			t.Errorf("request from %s = %d, want %d", tt.addr, got, tt.want)
+		}
+	}
+}
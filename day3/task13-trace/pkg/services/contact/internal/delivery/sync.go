@@ -0,0 +1,48 @@
+package delivery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+type SyncHandler struct {
+	useCase usecase.SyncUseCase
+	logger  *log.Logger
+}
+
+func NewSyncHandler(useCase usecase.SyncUseCase, logger *log.Logger) *SyncHandler {
+	return &SyncHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves POST /contacts/sync: it applies the client's offline
+// changes and returns what changed on the server since the client's token.
+func (h *SyncHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	var req domain.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.useCase.Sync(r.Context(), &req)
+	if err != nil {
+		h.logger.Printf("[%s] Error syncing contacts: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
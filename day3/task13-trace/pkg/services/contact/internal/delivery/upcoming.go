@@ -0,0 +1,49 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultUpcomingDays = 30
+
+// HandleUpcoming serves GET /contacts/upcoming?days=&tz=, the birthdays
+// and anniversaries coming round within days, 30 by default. Days start
+// at midnight in the IANA time zone tz, UTC by default.
+func (h *ContactHandler) HandleUpcoming(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	query := r.URL.Query()
+	days := defaultUpcomingDays
+	if value := query.Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "days must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	loc := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			http.Error(w, "tz is not a known time zone", http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, err := h.useCase.GetUpcomingEvents(r.Context(), days, loc)
+	if err != nil {
+		h.logger.Printf("[%s] Error getting upcoming events: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
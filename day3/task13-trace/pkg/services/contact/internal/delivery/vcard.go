@@ -0,0 +1,156 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/vcard"
+)
+
+const maxVCardImportSize = 10 << 20
+
+type vcardImportResult struct {
+	Card      int
+	ContactID string `json:",omitempty"`
+	FullName  string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+type vcardImportReport struct {
+	Imported int
+	Failed   int
+	Results  []vcardImportResult
+}
+
+func vcardVersion(r *http.Request) (string, bool) {
+	switch v := r.URL.Query().Get("version"); v {
+	case "", "4", vcard.Version4:
+		return vcard.Version4, true
+	case "3", vcard.Version3:
+		return vcard.Version3, true
+	default:
+		return "", false
+	}
+}
+
+// HandleContactVCard serves GET /contacts/{file} where file is "<id>.vcf".
+func (h *ContactHandler) HandleContactVCard(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	contactID, ok := strings.CutSuffix(r.PathValue("file"), ".vcf")
+	if !ok || contactID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	version, ok := vcardVersion(r)
+	if !ok {
+		http.Error(w, "unsupported vCard version", http.StatusBadRequest)
+		return
+	}
+
+	contact, err := h.useCase.GetContactByID(r.Context(), contactID)
+	if err != nil {
+		h.logger.Printf("[%s] Error getting contact: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", vcard.MediaType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+contact.ID+`.vcf"`)
+	if err := vcard.NewEncoder(w).Encode(vcard.FromContact(contact, version)); err != nil {
+		h.logger.Printf("[%s] Error encoding vCard: %v\n", traceID, err)
+	}
+}
+
+func (h *ContactHandler) HandleExportVCard(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	version, ok := vcardVersion(r)
+	if !ok {
+		http.Error(w, "unsupported vCard version", http.StatusBadRequest)
+		return
+	}
+
+	contacts, err := h.listContacts(r)
+	if err != nil {
+		h.logger.Printf("[%s] Error listing contacts: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", vcard.MediaType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="contacts.vcf"`)
+
+	enc := vcard.NewEncoder(w)
+	for _, contact := range contacts {
+		if err := enc.Encode(vcard.FromContact(contact, version)); err != nil {
+			h.logger.Printf("[%s] Error encoding vCard: %v\n", traceID, err)
+			return
+		}
+	}
+}
+
+// HandleImportVCard serves POST /contacts/import. Malformed cards and
+// contacts the use case rejects as invalid are reported per card; any other
+// error fails the request, leaving the cards before it imported.
+func (h *ContactHandler) HandleImportVCard(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	report := vcardImportReport{Results: []vcardImportResult{}}
+	dec := vcard.NewDecoder(http.MaxBytesReader(w, r.Body, maxVCardImportSize))
+	for i := 1; ; i++ {
+		card, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !errors.Is(err, vcard.ErrMalformed) && !errors.Is(err, vcard.ErrUnsupportedVersion) {
+			h.logger.Printf("[%s] Error reading vCard stream: %v\n", traceID, err)
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		result := vcardImportResult{Card: i}
+		var contact *domain.Contact
+		if err == nil {
+			contact, err = vcard.ToContact(card)
+		}
+		if err == nil {
+			result.FullName = contact.FullName
+			err = h.useCase.CreateContact(r.Context(), contact)
+			if err != nil && !errors.Is(err, domain.ErrValidation) {
+				h.logger.Printf("[%s] Error importing card %d: %v\n", traceID, i, err)
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+		}
+
+		if err != nil {
+			h.logger.Printf("[%s] Error importing card %d: %v\n", traceID, i, err)
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.ContactID = contact.ID
+			report.Imported++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
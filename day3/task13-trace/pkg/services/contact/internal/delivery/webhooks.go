@@ -0,0 +1,118 @@
+package delivery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/usecase"
+)
+
+type WebhookHandler struct {
+	useCase usecase.WebhookUseCase
+	logger  *log.Logger
+}
+
+func NewWebhookHandler(useCase usecase.WebhookUseCase, logger *log.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// HandleHTTP serves /webhooks: GET lists the subscriptions, POST creates
+// one and DELETE ?id= removes one.
+func (h *WebhookHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getWebhooks(w, r)
+	case http.MethodPost:
+		h.createWebhook(w, r)
+	case http.MethodDelete:
+		h.deleteWebhook(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	subscriptions, err := h.useCase.GetWebhooks(r.Context())
+	if err != nil {
+		h.logger.Printf("[%s] Error listing webhooks: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	var subscription domain.WebhookSubscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		h.logger.Printf("[%s] Error decoding request body: %v\n", traceID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.CreateWebhook(r.Context(), &subscription); err != nil {
+		h.logger.Printf("[%s] Error creating webhook: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+func (h *WebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	traceID := r.Context().Value("traceID").(string)
+
+	subscriptionID := r.URL.Query().Get("id")
+	if subscriptionID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.DeleteWebhook(r.Context(), subscriptionID); err != nil {
+		h.logger.Printf("[%s] Error deleting webhook: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDeliveries serves GET /webhooks/deliveries?id=&status= with the
+// delivery log of a subscription.
+func (h *WebhookHandler) HandleDeliveries(w http.ResponseWriter, r *http.Request) {
+	r, traceID := traceRequest(r)
+
+	h.logger.Printf("[%s] %s %s %s\n", traceID, r.Method, r.URL.Path, r.Proto)
+
+	subscriptionID := r.URL.Query().Get("id")
+	if subscriptionID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.useCase.GetWebhookDeliveries(r.Context(), subscriptionID, r.URL.Query().Get("status"))
+	if err != nil {
+		h.logger.Printf("[%s] Error listing webhook deliveries: %v\n", traceID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
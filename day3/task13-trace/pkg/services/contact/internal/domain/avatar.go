@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Avatar describes a contact's photo. The photo and its thumbnails are kept
+// in blob storage; see usecase.AvatarUseCase.
+type Avatar struct {
+	ContactID   string
+	OwnerID     string
+	ContentType string
+	// ETag identifies the photo: the hex SHA-256 of the upload.
+	ETag      string
+	Width     int
+	Height    int
+	UpdatedAt time.Time
+}
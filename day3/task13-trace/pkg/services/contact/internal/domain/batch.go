@@ -0,0 +1,54 @@
+package domain
+
+// Batch modes. BatchAtomic applies all operations in one transaction or
+// none of them; BatchBestEffort applies each on its own.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best-effort"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation creates or updates Contact, or deletes the contact with
+// ID.
+type BatchOperation struct {
+	Op      string
+	ID      string   `json:",omitempty"`
+	Contact *Contact `json:",omitempty"`
+}
+
+type BatchRequest struct {
+	Mode       string
+	Operations []BatchOperation
+}
+
+// Batch item statuses. An atomic batch that fails reports the operations
+// before the failed one as rolled back and the ones after it as skipped.
+const (
+	BatchSucceeded  = "succeeded"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped"
+)
+
+// BatchResult is the outcome of the operation at the same index. Contact
+// is the contact as stored by a create or update that succeeded.
+type BatchResult struct {
+	Op      string
+	ID      string `json:",omitempty"`
+	Status  string
+	Contact *Contact `json:",omitempty"`
+	Error   string   `json:",omitempty"`
+}
+
+// BatchResponse reports a batch. Committed is false when nothing of an
+// atomic batch was applied.
+type BatchResponse struct {
+	Mode      string
+	Committed bool
+	Results   []BatchResult
+}
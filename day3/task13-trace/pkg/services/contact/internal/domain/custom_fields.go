@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Custom field types.
+const (
+	CustomFieldString  = "string"
+	CustomFieldNumber  = "number"
+	CustomFieldDate    = "date"
+	CustomFieldBoolean = "boolean"
+)
+
+// DateLayout is how the values of date fields are written.
+const DateLayout = "2006-01-02"
+
+const (
+	// MaxCustomFields bounds the fields a tenant may define.
+	MaxCustomFields = 100
+
+	maxCustomValueLength = 1000
+)
+
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// CustomField defines a field the tenant's contacts may have. Values of a
+// field are strings, float64s or bools, as JSON decodes them; dates are
+// strings in DateLayout.
+type CustomField struct {
+	Name    string
+	OwnerID string
+	Type    string
+}
+
+func (f *CustomField) Validate() error {
+	if !customFieldName.MatchString(f.Name) {
+		return &ValidationError{Field: "Name", Reason: "must be a lowercase letter followed by up to 63 lowercase letters, digits or underscores"}
+	}
+	switch f.Type {
+	case CustomFieldString, CustomFieldNumber, CustomFieldDate, CustomFieldBoolean:
+		return nil
+	}
+	return &ValidationError{Field: "Type", Reason: "must be string, number, date or boolean"}
+}
+
+// Check returns value as a value of the field. A number, date or boolean
+// may also be given as text, as imports and query parameters carry them.
+func (f *CustomField) Check(value interface{}) (interface{}, error) {
+	invalid := &ValidationError{Field: "CustomFields." + f.Name, Reason: "must be a " + f.Type}
+	text, isText := value.(string)
+
+	switch f.Type {
+	case CustomFieldString:
+		if !isText {
+			return nil, invalid
+		}
+		if utf8.RuneCountInString(text) > maxCustomValueLength {
+			return nil, &ValidationError{Field: invalid.Field, Reason: "is too long"}
+		}
+		return text, nil
+	case CustomFieldNumber:
+		if isText {
+			n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+			if err != nil {
+				return nil, invalid
+			}
+			value = n
+		}
+		n, ok := value.(float64)
+		if !ok || math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, invalid
+		}
+		return n, nil
+	case CustomFieldDate:
+		t, err := time.Parse(DateLayout, strings.TrimSpace(text))
+		if !isText || err != nil {
+			return nil, &ValidationError{Field: invalid.Field, Reason: "must be a date as YYYY-MM-DD"}
+		}
+		return t.Format(DateLayout), nil
+	case CustomFieldBoolean:
+		if isText {
+			b, err := strconv.ParseBool(strings.TrimSpace(text))
+			if err != nil {
+				return nil, invalid
+			}
+			value = b
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, invalid
+		}
+		return b, nil
+	}
+	return nil, invalid
+}
+
+// FormatCustomValue writes a value of a custom field as text, the way
+// Check reads it back.
+func FormatCustomValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// CheckCustomFields checks the contact's custom fields against the fields
+// the tenant defined and brings their values into the stored form. A nil
+// value removes the field.
+func (c *Contact) CheckCustomFields(fields []*CustomField) error {
+	defined := make(map[string]*CustomField, len(fields))
+	for _, f := range fields {
+		defined[f.Name] = f
+	}
+
+	names := make([]string, 0, len(c.CustomFields))
+	for name := range c.CustomFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checked := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		value := c.CustomFields[name]
+		if value == nil {
+			continue
+		}
+		f := defined[name]
+		if f == nil {
+			return &ValidationError{Field: "CustomFields." + name, Reason: "is not defined"}
+		}
+		v, err := f.Check(value)
+		if err != nil {
+			return err
+		}
+		checked[name] = v
+	}
+	if len(checked) == 0 {
+		checked = nil
+	}
+	c.CustomFields = checked
+	return nil
+}
+
+// Clone returns a copy of c that shares no tags, custom fields or dates
+// with it.
+func (c *Contact) Clone() *Contact {
+	clone := *c
+	if c.Tags != nil {
+		clone.Tags = append([]string(nil), c.Tags...)
+	}
+	if c.Birthday != nil {
+		birthday := *c.Birthday
+		clone.Birthday = &birthday
+	}
+	if c.Anniversaries != nil {
+		clone.Anniversaries = append([]Anniversary(nil), c.Anniversaries...)
+	}
+	if c.CustomFields != nil {
+		clone.CustomFields = make(map[string]interface{}, len(c.CustomFields))
+		for name, value := range c.CustomFields {
+			clone.CustomFields[name] = value
+		}
+	}
+	return &clone
+}
+
+// ContactFilter selects the contacts that have all of Tags and the given
+// values of CustomFields and, if EventDays is set, a birthday or
+// anniversary on one of its days, written as Contact.EventDays writes them.
+type ContactFilter struct {
+	Tags         []string
+	CustomFields map[string]interface{}
+	EventDays    []string
+}
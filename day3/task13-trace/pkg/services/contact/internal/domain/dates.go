@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxAnniversaries = 20
+	maxLabelLength   = 64
+)
+
+// Kinds of UpcomingEvent.
+const (
+	EventBirthday    = "birthday"
+	EventAnniversary = "anniversary"
+)
+
+// Date is a calendar date whose year may be unknown, as with a birthday
+// given without one; Year is then 0. It is written 2006-01-02, or --01-02
+// without a year as in vCard.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseDate parses a date written as Date.String writes it, or in the
+// basic forms 20060102 and --0102 of vCard 4.
+func ParseDate(s string) (Date, error) {
+	digits, yearless := strings.CutPrefix(s, "--")
+	if yearless && len(digits) == 5 && digits[2] == '-' ||
+		!yearless && len(digits) == 10 && digits[4] == '-' && digits[7] == '-' {
+		digits = strings.ReplaceAll(digits, "-", "")
+	}
+	want := 8
+	if yearless {
+		want = 4
+	}
+	if len(digits) != want || strings.Trim(digits, "0123456789") != "" {
+		return Date{}, fmt.Errorf("invalid date %q, want 2006-01-02 or --01-02", s)
+	}
+
+	n, _ := strconv.Atoi(digits)
+	d := Date{Year: n / 10000, Month: time.Month(n / 100 % 100), Day: n % 100}
+	if !d.valid() {
+		return Date{}, fmt.Errorf("invalid date %q", s)
+	}
+	return d, nil
+}
+
+// DateOf returns the date t falls on in its location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+func (d Date) String() string {
+	if d.Year == 0 {
+		return fmt.Sprintf("--%02d-%02d", d.Month, d.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Date) valid() bool {
+	if d.Year < 0 || d.Year > 9999 || d.Month < time.January || d.Month > time.December || d.Day < 1 {
+		return false
+	}
+	// 2000 is a leap year, so a date without a year may be February 29.
+	year := d.Year
+	if year == 0 {
+		year = 2000
+	}
+	return d.Day <= daysIn(d.Month, year)
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// In returns the day the date comes round on in year. February 29 comes
+// round on February 28 in common years.
+func (d Date) In(year int) Date {
+	day := d.Day
+	if n := daysIn(d.Month, year); day > n {
+		day = n
+	}
+	return Date{Year: year, Month: d.Month, Day: day}
+}
+
+// AddDays returns the date n days after d, which must have a year.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.time().AddDate(0, 0, n))
+}
+
+// before reports whether d is earlier than e; both must have a year.
+func (d Date) before(e Date) bool {
+	return d.time().Before(e.time())
+}
+
+func (d Date) time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// monthDay is the month and day of d as kept for lookups, "01-02".
+func (d Date) monthDay() string {
+	return fmt.Sprintf("%02d-%02d", d.Month, d.Day)
+}
+
+// Anniversary is a yearly date of a contact other than the birthday, such
+// as a wedding, named by Label.
+type Anniversary struct {
+	Label string
+	Date  Date
+}
+
+// EventDays returns the month and day, "01-02", of the contact's birthday
+// and anniversaries, sorted and without repeats.
+func (c *Contact) EventDays() []string {
+	var days []string
+	if c.Birthday != nil {
+		days = append(days, c.Birthday.monthDay())
+	}
+	for _, a := range c.Anniversaries {
+		days = append(days, a.Date.monthDay())
+	}
+	sort.Strings(days)
+	unique := days[:0]
+	for i, day := range days {
+		if i == 0 || day != days[i-1] {
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
+
+// EventDaysWithin returns the months and days of EventDays that come round
+// from today up to days later. February 29 counts with February 28 in
+// common years.
+func EventDaysWithin(today Date, days int) []string {
+	var within []string
+	for i := 0; i <= days; i++ {
+		day := today.AddDays(i)
+		within = append(within, day.monthDay())
+		if day.Month == time.February && day.Day == 28 && daysIn(time.February, day.Year) == 28 {
+			within = append(within, "02-29")
+		}
+	}
+	return within
+}
+
+// UpcomingEvent is the next time a contact's birthday or anniversary comes
+// round. On is that day and DaysUntil how far it is from the day the
+// event was looked up; Years is the age the contact turns, or the
+// anniversary's count, if Date has a year.
+type UpcomingEvent struct {
+	ContactID string
+	FullName  string
+	Kind      string
+	Label     string `json:",omitempty"`
+	Date      Date
+	On        Date
+	DaysUntil int
+	Years     int `json:",omitempty"`
+}
+
+// UpcomingEvents returns the contact's events that come round from today
+// up to days later, birthday first.
+func (c *Contact) UpcomingEvents(today Date, days int) []UpcomingEvent {
+	var events []UpcomingEvent
+	add := func(kind, label string, date Date) {
+		on := date.In(today.Year)
+		if on.before(today) {
+			on = date.In(today.Year + 1)
+		}
+		until := int(on.time().Sub(today.time()) / (24 * time.Hour))
+		if until > days {
+			return
+		}
+		event := UpcomingEvent{
+			ContactID: c.ID,
+			FullName:  c.FullName,
+			Kind:      kind,
+			Label:     label,
+			Date:      date,
+			On:        on,
+			DaysUntil: until,
+		}
+		if date.Year > 0 && on.Year > date.Year {
+			event.Years = on.Year - date.Year
+		}
+		events = append(events, event)
+	}
+
+	if c.Birthday != nil {
+		add(EventBirthday, "", *c.Birthday)
+	}
+	for _, a := range c.Anniversaries {
+		add(EventAnniversary, a.Label, a.Date)
+	}
+	return events
+}
+
+// Reminder tells a tenant about an upcoming event of one of its contacts.
+type Reminder struct {
+	OwnerID string
+	UpcomingEvent
+}
+
+func validateDates(c *Contact) error {
+	if c.Birthday != nil && !c.Birthday.valid() {
+		return &ValidationError{Field: "Birthday", Reason: "is not a valid date"}
+	}
+	if len(c.Anniversaries) > MaxAnniversaries {
+		return &ValidationError{Field: "Anniversaries", Reason: "must hold at most " + strconv.Itoa(MaxAnniversaries) + " dates"}
+	}
+	seen := make(map[string]bool, len(c.Anniversaries))
+	for _, a := range c.Anniversaries {
+		if strings.TrimSpace(a.Label) == "" || utf8.RuneCountInString(a.Label) > maxLabelLength {
+			return &ValidationError{Field: "Anniversaries", Reason: "labels must be 1 to " + strconv.Itoa(maxLabelLength) + " characters long"}
+		}
+		// Commas and semicolons separate anniversaries in CSV, and equals
+		// signs their labels from their dates.
+		if strings.ContainsAny(a.Label, ",;=") || strings.IndexFunc(a.Label, unicode.IsControl) >= 0 {
+			return &ValidationError{Field: "Anniversaries", Reason: "labels must not contain commas, semicolons, equals signs or control characters"}
+		}
+		if seen[a.Label] {
+			return &ValidationError{Field: "Anniversaries", Reason: "label " + a.Label + " is used more than once"}
+		}
+		seen[a.Label] = true
+		if !a.Date.valid() {
+			return &ValidationError{Field: "Anniversaries", Reason: "date of " + a.Label + " is not a valid date"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func date(t *testing.T, s string) Date {
+	t.Helper()
+	d, err := ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestEventDaysWithin(t *testing.T) {
+	tests := []struct {
+		today string
+		days  int
+		want  []string
+	}{
+		{"2023-06-01", 0, []string{"06-01"}},
+		{"2023-02-27", 2, []string{"02-27", "02-28", "02-29", "03-01"}},
+		{"2024-02-27", 2, []string{"02-27", "02-28", "02-29"}},
+		{"2023-12-30", 3, []string{"12-30", "12-31", "01-01", "01-02"}},
+	}
+	for _, tt := range tests {
+		if got := EventDaysWithin(date(t, tt.today), tt.days); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("EventDaysWithin(%s, %d) = %v, want %v", tt.today, tt.days, got, tt.want)
+		}
+	}
+
+	// A year ahead covers every day, whether or not the year has a
+	// February 29.
+	for _, today := range []string{"2023-01-01", "2023-03-01", "2024-03-01"} {
+		seen := map[string]bool{}
+		for _, day := range EventDaysWithin(date(t, today), 365) {
+			seen[day] = true
+		}
+		if len(seen) != 366 {
+			t.Errorf("EventDaysWithin(%s, 365) covers %d days, want 366", today, len(seen))
+		}
+	}
+}
+
+func TestUpcomingEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		today    string
+		days     int
+		birthday string
+		// wantOn is empty if the birthday does not come round in time.
+		wantOn    string
+		wantUntil int
+		wantYears int
+	}{
+		{"today", "2023-06-01", 0, "1980-06-01", "2023-06-01", 0, 43},
+		{"tomorrow", "2023-05-31", 1, "1980-06-01", "2023-06-01", 1, 43},
+		{"out of reach", "2023-05-30", 1, "1980-06-01", "", 0, 0},
+		{"yesterday, a year ahead", "2023-06-02", 365, "1980-06-01", "2024-06-01", 365, 44},
+		{"yesterday, within 364 days", "2023-06-02", 364, "1980-06-01", "", 0, 0},
+		{"February 29 in a common year", "2023-02-27", 7, "1992-02-29", "2023-02-28", 1, 31},
+		{"February 29 in a leap year", "2024-02-27", 7, "1992-02-29", "2024-02-29", 2, 32},
+		{"February 29 a year ahead", "2023-03-01", 365, "1992-02-29", "2024-02-29", 365, 32},
+		{"across the new year", "2023-12-30", 7, "--01-02", "2024-01-02", 3, 0},
+		{"without a year", "2023-06-01", 0, "--06-01", "2023-06-01", 0, 0},
+		{"born this year", "2023-06-01", 90, "2023-08-01", "2023-08-01", 61, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			birthday := date(t, tt.birthday)
+			contact := &Contact{ID: "c1", FullName: "Ada", Birthday: &birthday}
+			events := contact.UpcomingEvents(date(t, tt.today), tt.days)
+			if tt.wantOn == "" {
+				if len(events) != 0 {
+					t.Errorf("UpcomingEvents = %+v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("UpcomingEvents = %+v, want one", events)
+			}
+			event := events[0]
+			if event.On.String() != tt.wantOn || event.DaysUntil != tt.wantUntil || event.Years != tt.wantYears {
+				t.Errorf("UpcomingEvents = on %s in %d days, %d years; want on %s in %d days, %d years",
+					event.On, event.DaysUntil, event.Years, tt.wantOn, tt.wantUntil, tt.wantYears)
+			}
+			if event.Kind != EventBirthday || event.Date != birthday || event.ContactID != "c1" {
+				t.Errorf("UpcomingEvents = %+v", event)
+			}
+		})
+	}
+}
+
+func TestUpcomingAnniversaries(t *testing.T) {
+	birthday := date(t, "1980-06-03")
+	contact := &Contact{
+		Birthday: &birthday,
+		Anniversaries: []Anniversary{
+			{Label: "wedding", Date: date(t, "2010-06-01")},
+			{Label: "graduation", Date: date(t, "2002-07-01")},
+		},
+	}
+	events := contact.UpcomingEvents(date(t, "2023-06-01"), 7)
+	if len(events) != 2 {
+		t.Fatalf("UpcomingEvents = %+v, want the birthday and the wedding", events)
+	}
+	if events[0].Kind != EventBirthday || events[0].DaysUntil != 2 {
+		t.Errorf("first event = %+v, want the birthday in 2 days", events[0])
+	}
+	if events[1].Kind != EventAnniversary || events[1].Label != "wedding" || events[1].DaysUntil != 0 || events[1].Years != 13 {
+		t.Errorf("second event = %+v, want the 13th wedding anniversary today", events[1])
+	}
+}
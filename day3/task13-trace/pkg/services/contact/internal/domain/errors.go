@@ -0,0 +1,28 @@
+package domain
+
+import "errors"
+
+var (
+    ErrNotFound   = errors.New("not found")
+    ErrValidation = errors.New("validation failed")
+    ErrForbidden  = errors.New("forbidden")
+    // ErrVersionConflict means a contact changed since the version a write
+    // was based on.
+    ErrVersionConflict = errors.New("version conflict")
+    // ErrIDInUse means a contact with the ID a client chose exists, in
+    // whichever tenant; callers must not tell the tenants apart.
+    ErrIDInUse = errors.New("ID in use")
+)
+
+type ValidationError struct {
+    Field  string
+    Reason string
+}
+
+func (e *ValidationError) Error() string {
+    return e.Field + ": " + e.Reason
+}
+
+func (e *ValidationError) Unwrap() error {
+    return ErrValidation
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types as stored in the outbox and sent to subscribers.
+const (
+	EventContactCreated    = "contact.created"
+	EventContactUpdated    = "contact.updated"
+	EventContactDeleted    = "contact.deleted"
+	EventGroupCreated      = "group.created"
+	EventGroupUpdated      = "group.updated"
+	EventGroupDeleted      = "group.deleted"
+	EventGroupContactAdded = "group.contact_added"
+)
+
+var EventTypes = []string{
+	EventContactCreated, EventContactUpdated, EventContactDeleted,
+	EventGroupCreated, EventGroupUpdated, EventGroupDeleted, EventGroupContactAdded,
+}
+
+// DomainEvent is a change to contacts or groups. The repositories write
+// every change's events to the outbox in the change's transaction; the
+// event's JSON form becomes the Data of the stored Event.
+type DomainEvent interface {
+	EventType() string
+}
+
+type ContactCreated struct {
+	Contact
+}
+
+type ContactUpdated struct {
+	Contact
+}
+
+type ContactDeleted struct {
+	ID string
+}
+
+type GroupCreated struct {
+	Group
+}
+
+type GroupUpdated struct {
+	Group
+}
+
+type GroupDeleted struct {
+	ID string
+}
+
+const MembershipAdded = "added"
+
+// GroupMembershipChanged reports a contact joining a group; Change is
+// MembershipAdded.
+type GroupMembershipChanged struct {
+	GroupID   string
+	ContactID string
+	Change    string
+}
+
+func (ContactCreated) EventType() string { return EventContactCreated }
+func (ContactUpdated) EventType() string { return EventContactUpdated }
+func (ContactDeleted) EventType() string { return EventContactDeleted }
+func (GroupCreated) EventType() string   { return EventGroupCreated }
+func (GroupUpdated) EventType() string   { return EventGroupUpdated }
+func (GroupDeleted) EventType() string   { return EventGroupDeleted }
+
+func (e GroupMembershipChanged) EventType() string { return "group.contact_" + e.Change }
+
+// Event is a DomainEvent as recorded in the outbox. Seq numbers the events
+// of a tenant in commit order.
+type Event struct {
+	ID         int64
+	OwnerID    string
+	Seq        int64
+	Type       string
+	OccurredAt time.Time
+	Data       json.RawMessage
+}
+
+// Change is an entry of a tenant's change feed.
+type Change struct {
+	Seq        int64
+	Type       string
+	OccurredAt time.Time
+	Data       json.RawMessage
+}
@@ -1,14 +1,74 @@
 package domain
 
+import (
+    "encoding/json"
+    "time"
+)
+
 type Contact struct {
     ID        string
+    OwnerID   string
     FullName  string
     FirstName string
     Patronymic string
     PhoneNumber string
+    Email       string
+    Address     Address
+    // Version starts at 1 and goes up with every write to the contact.
+    Version     int64
+    // Tags are free-form labels, kept sorted.
+    Tags        []string `json:",omitempty"`
+    // CustomFields holds values of the fields the tenant defined, by name;
+    // see CustomField.
+    CustomFields map[string]interface{} `json:",omitempty"`
+    // Birthday is nil if unknown; its year may be unknown too.
+    Birthday      *Date         `json:",omitempty"`
+    Anniversaries []Anniversary `json:",omitempty"`
+}
+
+type Address struct {
+    Street     string
+    Locality   string
+    Region     string
+    PostalCode string
+    Country    string
 }
 
 type Group struct {
-    ID   string
-    Name string
+    ID      string
+    OwnerID string
+    Name    string
+    // ParentID is the group this one sits under, empty for a root group.
+    ParentID string
+    // Rule, if set, makes this a smart group: its members are the contacts
+    // matching the rule, written as package rule describes.
+    Rule string
 }
+
+type DuplicateGroup struct {
+    Contacts []*Contact
+    Reasons  []string
+}
+
+// HistoryEntry records something done to a contact. Details is a JSON
+// object whose fields depend on Action.
+type HistoryEntry struct {
+    ID        string
+    ContactID string
+    OwnerID   string
+    Action    string
+    Details   json.RawMessage
+    CreatedAt time.Time
+}
+
+// HistoryPage is a page of a contact's history, latest first. NextCursor
+// asks for the next page and is empty on the last one.
+type HistoryPage struct {
+    Entries    []*HistoryEntry
+    NextCursor string `json:",omitempty"`
+}
+
+const (
+    HistoryActionMerged    = "merged"
+    HistoryActionNoteAdded = "note_added"
+)
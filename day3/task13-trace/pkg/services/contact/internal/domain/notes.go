@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	NoteTypeNote    = "note"
+	NoteTypeCall    = "call"
+	NoteTypeMeeting = "meeting"
+	NoteTypeEmail   = "email"
+)
+
+// NoteTypes lists the kinds of interaction a note can record.
+var NoteTypes = []string{NoteTypeNote, NoteTypeCall, NoteTypeMeeting, NoteTypeEmail}
+
+const MaxNoteLength = 10000
+
+// Note records an interaction with a contact, such as a call or a meeting,
+// or just a remark. Body is markdown, kept as written; clients render it.
+// OccurredAt is when the interaction took place and CreatedAt when it was
+// logged. Author is the subject the note was added by.
+type Note struct {
+	ID         string
+	ContactID  string
+	OwnerID    string
+	Author     string
+	Type       string
+	Body       string
+	OccurredAt time.Time
+	CreatedAt  time.Time
+}
+
+// NotePage is a page of a contact's notes, latest first. NextCursor asks
+// for the next page and is empty on the last one.
+type NotePage struct {
+	Notes      []*Note
+	NextCursor string `json:",omitempty"`
+}
+
+// ValidNoteType reports whether t is one of NoteTypes.
+func ValidNoteType(t string) bool {
+	for _, noteType := range NoteTypes {
+		if t == noteType {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Note) Validate() error {
+	if !ValidNoteType(n.Type) {
+		return &ValidationError{Field: "Type", Reason: "must be one of " + strings.Join(NoteTypes, ", ")}
+	}
+	if strings.TrimSpace(n.Body) == "" {
+		return &ValidationError{Field: "Body", Reason: "is required"}
+	}
+	if utf8.RuneCountInString(n.Body) > MaxNoteLength {
+		return &ValidationError{Field: "Body", Reason: "must be at most " + strconv.Itoa(MaxNoteLength) + " characters long"}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package domain
+
+import "time"
+
+// ContactFields names the contact fields that sync tracks and merges one by
+// one.
+var ContactFields = []string{
+	"FullName", "FirstName", "Patronymic", "PhoneNumber", "Email",
+	"Address.Street", "Address.Locality", "Address.Region", "Address.PostalCode", "Address.Country",
+}
+
+func (c *Contact) fieldRef(name string) *string {
+	switch name {
+	case "FullName":
+		return &c.FullName
+	case "FirstName":
+		return &c.FirstName
+	case "Patronymic":
+		return &c.Patronymic
+	case "PhoneNumber":
+		return &c.PhoneNumber
+	case "Email":
+		return &c.Email
+	case "Address.Street":
+		return &c.Address.Street
+	case "Address.Locality":
+		return &c.Address.Locality
+	case "Address.Region":
+		return &c.Address.Region
+	case "Address.PostalCode":
+		return &c.Address.PostalCode
+	case "Address.Country":
+		return &c.Address.Country
+	}
+	return nil
+}
+
+// Field returns the value of the field named as in ContactFields.
+func (c *Contact) Field(name string) (string, bool) {
+	ref := c.fieldRef(name)
+	if ref == nil {
+		return "", false
+	}
+	return *ref, true
+}
+
+// SetField sets the field named as in ContactFields and reports whether
+// there is such a field.
+func (c *Contact) SetField(name, value string) bool {
+	ref := c.fieldRef(name)
+	if ref == nil {
+		return false
+	}
+	*ref = value
+	return true
+}
+
+// FieldStamp is the version in which a contact field last changed and when
+// that change was made.
+type FieldStamp struct {
+	Version    int64
+	ModifiedAt time.Time
+}
+
+// FieldClock holds the stamps of a contact's fields. A field without one
+// has not changed since the contact was created.
+type FieldClock map[string]FieldStamp
+
+// Stamp records stamp for every field that differs between before and
+// after.
+func (clock FieldClock) Stamp(before, after *Contact, stamp FieldStamp) {
+	for _, name := range ContactFields {
+		old, _ := before.Field(name)
+		value, _ := after.Field(name)
+		if old != value {
+			clock[name] = stamp
+		}
+	}
+}
+
+// Sync strategies decide a field both the client and the server changed.
+// SyncReport keeps the server's value; SyncLastWriterWins keeps the value
+// changed last, and the server's on a tie.
+const (
+	SyncReport         = "report"
+	SyncLastWriterWins = "lww"
+)
+
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncChange is a change a client made offline. Fields holds the fields it
+// set, named as in ContactFields; BaseVersion is the version of the contact
+// the client changed, and ModifiedAt when it did so.
+type SyncChange struct {
+	Op          string
+	ID          string
+	BaseVersion int64
+	ModifiedAt  time.Time
+	Fields      map[string]string
+}
+
+// SyncRequest pushes a client's changes and pulls the changes after Token,
+// which is empty on the first sync.
+type SyncRequest struct {
+	Token    string
+	Strategy string
+	Changes  []SyncChange
+}
+
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// FieldConflict is a field the server changed after the client's base
+// version and the client changed as well. Resolution is "server" or
+// "client", whichever value the contact now has.
+type FieldConflict struct {
+	Field         string
+	ClientValue   string
+	ServerValue   string
+	ServerVersion int64
+	Resolution    string
+}
+
+// SyncResult is the outcome of the SyncChange at the same index.
+// SyncConflict means some of the change did not make it; Conflicts says
+// which fields, or Error why nothing did.
+type SyncResult struct {
+	ID        string
+	Status    string
+	Version   int64           `json:",omitempty"`
+	Conflicts []FieldConflict `json:",omitempty"`
+	Error     string          `json:",omitempty"`
+}
+
+// SyncResponse carries the results of the pushed changes and the changes
+// after the request's token: the contacts as they are now and the IDs of
+// the deleted ones. The client keeps Token for its next sync and syncs
+// again right away while HasMore is set.
+type SyncResponse struct {
+	Results  []SyncResult
+	Contacts []*Contact
+	Deleted  []string
+	Token    string
+	HasMore  bool
+}
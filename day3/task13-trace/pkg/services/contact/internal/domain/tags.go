@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags      = 50
+	maxTagLength = 64
+)
+
+// NormalizeTags trims the tags and drops empty and repeated ones. The
+// result is sorted and shares nothing with tags.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func validateTags(tags []string) error {
+	if len(tags) > MaxTags {
+		return &ValidationError{Field: "Tags", Reason: "must hold at most " + strconv.Itoa(MaxTags) + " tags"}
+	}
+	for _, tag := range tags {
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return &ValidationError{Field: "Tags", Reason: "must be 1 to " + strconv.Itoa(maxTagLength) + " characters long"}
+		}
+		// Commas and semicolons separate tags in CSV and vCard.
+		if strings.ContainsAny(tag, ",;") || strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+			return &ValidationError{Field: "Tags", Reason: "must not contain commas, semicolons or control characters"}
+		}
+	}
+	return nil
+}
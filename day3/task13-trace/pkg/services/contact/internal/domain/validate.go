@@ -0,0 +1,54 @@
+package domain
+
+import (
+    "net/mail"
+    "strings"
+    "unicode/utf8"
+)
+
+const maxFieldLength = 255
+
+func (c *Contact) Validate() error {
+    if strings.TrimSpace(c.FullName) == "" {
+        return &ValidationError{Field: "FullName", Reason: "is required"}
+    }
+
+    fields := []struct {
+        name  string
+        value string
+    }{
+        {"FullName", c.FullName},
+        {"FirstName", c.FirstName},
+        {"Patronymic", c.Patronymic},
+        {"PhoneNumber", c.PhoneNumber},
+        {"Email", c.Email},
+    }
+    for _, f := range fields {
+        if utf8.RuneCountInString(f.value) > maxFieldLength {
+            return &ValidationError{Field: f.name, Reason: "is too long"}
+        }
+    }
+
+    // The same characters phone.Parser accepts, so a number that parses
+    // is never rejected here.
+    if c.PhoneNumber != "" && strings.Trim(c.PhoneNumber, "+0123456789 ()-./") != "" {
+        return &ValidationError{Field: "PhoneNumber", Reason: "contains invalid characters"}
+    }
+
+    if c.Email != "" {
+        addr, err := mail.ParseAddress(c.Email)
+        if err != nil || addr.Address != c.Email {
+            return &ValidationError{Field: "Email", Reason: "is not a valid address"}
+        }
+    }
+
+    if err := validateTags(c.Tags); err != nil {
+        return err
+    }
+
+    if err := validateDates(c); err != nil {
+        return err
+    }
+
+    return nil
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookSubscription sends the events matching Events to URL. Events hold
+// event types, "contact.*"-style prefixes or "*". Secret signs the payloads
+// and is never returned once stored.
+type WebhookSubscription struct {
+	ID        string
+	OwnerID   string
+	URL       string
+	Secret    string `json:",omitempty"`
+	Events    []string
+	CreatedAt time.Time
+}
+
+// Matches reports whether the subscription wants events of eventType.
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, filter := range s.Events {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(filter, "*"); ok && strings.HasSuffix(prefix, ".") &&
+			strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryDead marks deliveries that failed every attempt.
+	DeliveryDead = "dead"
+)
+
+// WebhookDelivery is one event for one subscription, with the log of its
+// attempts.
+type WebhookDelivery struct {
+	ID             string
+	OwnerID        string
+	SubscriptionID string
+	EventID        int64
+	EventType      string
+	Status         string
+	Attempts       int
+	NextAttemptAt  *time.Time `json:",omitempty"`
+	CreatedAt      time.Time
+	Log            []WebhookAttempt
+}
+
+type WebhookAttempt struct {
+	Attempt        int
+	AttemptedAt    time.Time
+	ResponseStatus int    `json:",omitempty"`
+	Error          string `json:",omitempty"`
+	DurationMS     int64
+}
+
+// WebhookDispatch is a delivery due for an attempt with everything needed
+// to make it.
+type WebhookDispatch struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+	Event    Event
+}
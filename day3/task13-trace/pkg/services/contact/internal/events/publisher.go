@@ -0,0 +1,138 @@
+// Package events relays the domain events in the outbox to publishers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// Publisher hands events to consumers. An error makes the relay publish
+// the same events again later, so consumers must tolerate duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, events []domain.Event) error
+}
+
+// Publishers publishes to each publisher in turn and stops at the first
+// error.
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, events []domain.Event) error {
+	for _, p := range ps {
+		if err := p.Publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type subscriber struct {
+	ch   chan domain.Event
+	done chan struct{}
+}
+
+// ChannelPublisher is an in-process event bus. Every subscriber receives
+// every event published while it is subscribed.
+type ChannelPublisher struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]bool
+}
+
+func NewChannelPublisher() *ChannelPublisher {
+	return &ChannelPublisher{subscribers: make(map[*subscriber]bool)}
+}
+
+// Subscribe returns a channel of events with room for buffer of them and a
+// function ending the subscription. A subscriber that falls behind holds
+// up Publish, and with it the relay, until it catches up or unsubscribes.
+func (p *ChannelPublisher) Subscribe(buffer int) (<-chan domain.Event, func()) {
+	sub := &subscriber{ch: make(chan domain.Event, buffer), done: make(chan struct{})}
+	p.mu.Lock()
+	p.subscribers[sub] = true
+	p.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			close(sub.done)
+			p.mu.Lock()
+			delete(p.subscribers, sub)
+			p.mu.Unlock()
+		})
+	}
+}
+
+func (p *ChannelPublisher) Publish(ctx context.Context, events []domain.Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for sub := range p.subscribers {
+		for _, event := range events {
+			select {
+			case sub.ch <- event:
+			case <-sub.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// WriterPublisher writes events as JSON lines, e.g. to stdout or a file
+// for local use.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// OpenSink returns the WriterPublisher named by spec, "stdout" or
+// "file:<path>"; files are appended to.
+func OpenSink(spec string) (*WriterPublisher, error) {
+	if spec == "stdout" {
+		return NewWriterPublisher(os.Stdout), nil
+	}
+	path, ok := strings.CutPrefix(spec, "file:")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("events: unknown sink %q, want stdout or file:<path>", spec)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterPublisher{w: f, closer: f}, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, events []domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	enc := json.NewEncoder(p.w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if f, ok := p.w.(*os.File); ok && f != os.Stdout {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (p *WriterPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
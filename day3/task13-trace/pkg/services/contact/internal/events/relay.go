@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go/pkg/services/contact/internal/repository"
+)
+
+// Relay moves the events in the outbox to a Publisher, oldest first, and
+// marks them published. Events are published at least once.
+type Relay struct {
+	PollInterval time.Duration
+	BatchSize    int
+
+	repo      repository.OutboxRepository
+	publisher Publisher
+	logger    *log.Logger
+}
+
+func NewRelay(repo repository.OutboxRepository, publisher Publisher, logger *log.Logger) *Relay {
+	return &Relay{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		repo:         repo,
+		publisher:    publisher,
+		logger:       logger,
+	}
+}
+
+// Run relays until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Printf("Event relay error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes the events pending now and returns their number.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.repo.PublishPending(ctx, r.BatchSize, r.publisher.Publish)
+		total += n
+		if err != nil || n < r.BatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package reminder reminds tenants of the birthdays and anniversaries of
+// their contacts as the days draw near.
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// Notifier delivers reminders. An error makes the scheduler send the same
+// reminders again later.
+type Notifier interface {
+	Notify(ctx context.Context, reminders []domain.Reminder) error
+}
+
+// LogNotifier writes reminders to a logger, e.g. stdout for local use.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminders []domain.Reminder) error {
+	for _, reminder := range reminders {
+		n.logger.Printf("Reminder for tenant %s: %s\n", reminder.OwnerID, describe(reminder.UpcomingEvent))
+	}
+	return nil
+}
+
+// describe says in a sentence when the event comes round, e.g. "Ivan
+// Petrov's birthday is tomorrow, 2026-10-20 (turns 40)".
+func describe(event domain.UpcomingEvent) string {
+	what := "birthday"
+	if event.Kind == domain.EventAnniversary {
+		what = event.Label
+	}
+
+	var when string
+	switch event.DaysUntil {
+	case 0:
+		when = "today"
+	case 1:
+		when = "tomorrow"
+	default:
+		when = fmt.Sprintf("in %d days", event.DaysUntil)
+	}
+
+	text := fmt.Sprintf("%s's %s is %s, %s", event.FullName, what, when, event.On)
+	switch {
+	case event.Years == 0:
+	case event.Kind == domain.EventBirthday:
+		text += fmt.Sprintf(" (turns %d)", event.Years)
+	default:
+		text += fmt.Sprintf(" (%d years)", event.Years)
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package reminder
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// Scheduler looks for the birthdays and anniversaries that come round
+// within LeadDays and hands a reminder for each to a Notifier, once per
+// time the date comes round. Days start at midnight in Location. The
+// fields may be changed before Run.
+type Scheduler struct {
+	Interval  time.Duration
+	BatchSize int
+	// LeadDays is how many days ahead a reminder goes out; with 0 it goes
+	// out on the day.
+	LeadDays int
+	Location *time.Location
+
+	repo     repository.ReminderRepository
+	notifier Notifier
+	logger   *log.Logger
+	now      func() time.Time
+}
+
+func NewScheduler(repo repository.ReminderRepository, notifier Notifier, logger *log.Logger) *Scheduler {
+	return &Scheduler{
+		Interval:  time.Hour,
+		BatchSize: 500,
+		Location:  time.UTC,
+		repo:      repo,
+		notifier:  notifier,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Run sends reminders until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RemindOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Printf("Reminder scheduler error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RemindOnce sends the reminders due today that were not sent yet and
+// returns their number.
+func (s *Scheduler) RemindOnce(ctx context.Context) (int, error) {
+	today := domain.DateOf(s.now().In(s.Location))
+	// Reminders of days gone by can no longer come up.
+	if _, err := s.repo.PurgeReminders(ctx, today); err != nil {
+		return 0, err
+	}
+
+	days := domain.EventDaysWithin(today, s.LeadDays)
+	total, after := 0, ""
+	for {
+		contacts, err := s.repo.GetContactsWithEventDays(ctx, days, after, s.BatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		var reminders []domain.Reminder
+		for _, contact := range contacts {
+			for _, event := range contact.UpcomingEvents(today, s.LeadDays) {
+				reminders = append(reminders, domain.Reminder{OwnerID: contact.OwnerID, UpcomingEvent: event})
+			}
+		}
+		n, err := s.repo.SendReminders(ctx, reminders, s.notifier.Notify)
+		total += n
+		if err != nil || len(contacts) < s.BatchSize {
+			return total, err
+		}
+		after = contacts[len(contacts)-1].ID
+	}
+}
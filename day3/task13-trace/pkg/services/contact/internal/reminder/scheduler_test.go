@@ -0,0 +1,159 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"testing"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+type sentKey struct {
+	contactID, kind, label string
+	on                     domain.Date
+}
+
+// memoryReminders keeps the sent reminders by the key the Postgres
+// repository uses: the event and the day it comes round on.
+type memoryReminders struct {
+	contacts []*domain.Contact
+	sent     map[sentKey]bool
+}
+
+func (r *memoryReminders) GetContactsWithEventDays(ctx context.Context, days []string, afterID string, limit int) ([]*domain.Contact, error) {
+	wanted := map[string]bool{}
+	for _, day := range days {
+		wanted[day] = true
+	}
+	sort.Slice(r.contacts, func(i, j int) bool { return r.contacts[i].ID < r.contacts[j].ID })
+	var contacts []*domain.Contact
+	for _, contact := range r.contacts {
+		if contact.ID <= afterID || len(contacts) == limit {
+			continue
+		}
+		for _, day := range contact.EventDays() {
+			if wanted[day] {
+				contacts = append(contacts, contact)
+				break
+			}
+		}
+	}
+	return contacts, nil
+}
+
+func (r *memoryReminders) SendReminders(ctx context.Context, reminders []domain.Reminder, send func(ctx context.Context, reminders []domain.Reminder) error) (int, error) {
+	var unsent []domain.Reminder
+	for _, reminder := range reminders {
+		if !r.sent[sentKey{reminder.ContactID, reminder.Kind, reminder.Label, reminder.On}] {
+			unsent = append(unsent, reminder)
+		}
+	}
+	if len(unsent) == 0 {
+		return 0, nil
+	}
+	if err := send(ctx, unsent); err != nil {
+		return 0, err
+	}
+	for _, reminder := range unsent {
+		r.sent[sentKey{reminder.ContactID, reminder.Kind, reminder.Label, reminder.On}] = true
+	}
+	return len(unsent), nil
+}
+
+func (r *memoryReminders) PurgeReminders(ctx context.Context, before domain.Date) (int64, error) {
+	var n int64
+	for key := range r.sent {
+		if key.on.String() < before.String() {
+			delete(r.sent, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// recordingNotifier keeps what it was asked to send and fails while err is
+// set.
+type recordingNotifier struct {
+	reminders []domain.Reminder
+	err       error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, reminders []domain.Reminder) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.reminders = append(n.reminders, reminders...)
+	return nil
+}
+
+func TestRemindOnce(t *testing.T) {
+	birthday, _ := domain.ParseDate("1992-02-29")
+	anniversary, _ := domain.ParseDate("2010-03-01")
+	repo := &memoryReminders{
+		contacts: []*domain.Contact{
+			{ID: "c1", OwnerID: "tenant-a", FullName: "Ada", Birthday: &birthday},
+			{ID: "c2", OwnerID: "tenant-b", FullName: "Bob", Anniversaries: []domain.Anniversary{{Label: "wedding", Date: anniversary}}},
+		},
+		sent: map[sentKey]bool{},
+	}
+	notifier := &recordingNotifier{}
+	scheduler := NewScheduler(repo, notifier, log.New(io.Discard, "", 0))
+	scheduler.LeadDays = 2
+	scheduler.BatchSize = 1
+	// Days start in UTC+3, so 22:00 UTC is already the next day.
+	scheduler.Location = time.FixedZone("UTC+3", 3*60*60)
+
+	tests := []struct {
+		now  string
+		want []string
+		err  error
+	}{
+		// February 27 in UTC+3. Ada's birthday comes round on February 28
+		// in 2023, and both events are within the lead days.
+		{"2023-02-26T22:00:00Z", []string{"c1 2023-02-28", "c2 2023-03-01"}, nil},
+		{"2023-02-27T22:00:00Z", nil, nil},
+		{"2023-02-28T08:00:00Z", nil, nil},
+		// A failed notification is sent again on the next run.
+		{"2024-02-27T08:00:00Z", nil, errors.New("mail server down")},
+		{"2024-02-27T09:00:00Z", []string{"c1 2024-02-29"}, nil},
+		{"2024-02-28T09:00:00Z", []string{"c2 2024-03-01"}, nil},
+		{"2024-02-29T09:00:00Z", nil, nil},
+	}
+	for _, tt := range tests {
+		now, err := time.Parse(time.RFC3339, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduler.now = func() time.Time { return now }
+		notifier.reminders, notifier.err = nil, tt.err
+
+		n, err := scheduler.RemindOnce(context.Background())
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: RemindOnce error = %v, want %v", tt.now, err, tt.err)
+		}
+		var got []string
+		for _, reminder := range notifier.reminders {
+			got = append(got, reminder.ContactID+" "+reminder.On.String())
+		}
+		if n != len(tt.want) || len(got) != len(tt.want) {
+			t.Errorf("%s: sent %d: %v, want %v", tt.now, n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: sent %v, want %v", tt.now, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for key := range repo.sent {
+		if key.on.Year == 2023 {
+			t.Errorf("reminder %+v of a day gone by was not purged", key)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/store/postgresql"
+)
+
+// apiKeyRepositoryImpl adapts the shared API key store to this service,
+// reporting a missing key as domain.ErrNotFound.
+type apiKeyRepositoryImpl struct {
+	*postgresql.APIKeyRepository
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepositoryImpl{
+		APIKeyRepository: postgresql.NewAPIKeyRepository(db),
+	}
+}
+
+func (r *apiKeyRepositoryImpl) GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error) {
+	key, err := r.APIKeyRepository.GetAPIKeyByHash(ctx, hash)
+	return key, apiKeyNotFound(err)
+}
+
+func (r *apiKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, tenantID, keyID string) (*auth.APIKey, error) {
+	key, err := r.APIKeyRepository.RevokeAPIKey(ctx, tenantID, keyID)
+	return key, apiKeyNotFound(err)
+}
+
+func apiKeyNotFound(err error) error {
+	if errors.Is(err, postgresql.ErrAPIKeyNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
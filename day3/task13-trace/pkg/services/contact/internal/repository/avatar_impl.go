@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type avatarRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAvatarRepository(db *sql.DB) AvatarRepository {
+	return &avatarRepositoryImpl{
+		db: db,
+	}
+}
+
+// SaveAvatar sets or replaces the avatar of a contact of the tenant.
+func (r *avatarRepositoryImpl) SaveAvatar(ctx context.Context, avatar *domain.Avatar) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	avatar.OwnerID = owner
+
+	query := `INSERT INTO avatars (contact_id, owner_id, content_type, etag, width, height)
+		SELECT id, owner_id, $3, $4, $5, $6 FROM contacts WHERE id = $1 AND owner_id = $2
+		ON CONFLICT (contact_id) DO UPDATE SET content_type = EXCLUDED.content_type,
+			etag = EXCLUDED.etag, width = EXCLUDED.width, height = EXCLUDED.height, updated_at = now()
+		RETURNING updated_at`
+	return insertForContact(ctx, r.db, query, avatar.ContactID, owner,
+		[]interface{}{avatar.ContentType, avatar.ETag, avatar.Width, avatar.Height}, &avatar.UpdatedAt)
+}
+
+func (r *avatarRepositoryImpl) GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT contact_id, owner_id, content_type, etag, width, height, updated_at FROM avatars
+		WHERE contact_id = $1 AND owner_id = $2`
+	avatar := &domain.Avatar{}
+	err = r.db.QueryRowContext(ctx, query, contactID, owner).Scan(&avatar.ContactID, &avatar.OwnerID,
+		&avatar.ContentType, &avatar.ETag, &avatar.Width, &avatar.Height, &avatar.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return avatar, nil
+}
+
+func (r *avatarRepositoryImpl) DeleteAvatar(ctx context.Context, contactID string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	query := "DELETE FROM avatars WHERE contact_id = $1 AND owner_id = $2"
+	result, err := r.db.ExecContext(ctx, query, contactID, owner)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// PurgeDeletedAvatars holds the row locks of the queued avatars while purge
+// runs, so concurrent sweepers purge disjoint batches.
+func (r *avatarRepositoryImpl) PurgeDeletedAvatars(ctx context.Context, limit int, purge func(ctx context.Context, avatars []*domain.Avatar) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, contact_id, owner_id, etag FROM deleted_avatars
+		ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	avatars := []*domain.Avatar{}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		avatar := &domain.Avatar{}
+		if err := rows.Scan(&id, &avatar.ContactID, &avatar.OwnerID, &avatar.ETag); err != nil {
+			return 0, err
+		}
+		avatars = append(avatars, avatar)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(avatars) == 0 {
+		return 0, nil
+	}
+
+	if err := purge(ctx, avatars); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM deleted_avatars WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(avatars), nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/golang/groupcache/singleflight"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// CacheStats counts the lookups served by a CachedContactRepository.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// cacheEntry holds a contact no caller has seen; callers get clones of it,
+// so changing one's tags, custom fields or dates cannot change the cache.
+type cacheEntry struct {
+	contact *domain.Contact
+	expires time.Time
+}
+
+// CachedContactRepository serves GetContactByID from an LRU cache whose
+// entries expire after a TTL and passes everything else to the wrapped
+// repository. Writes through it drop the contacts they change. The cache is
+// per process: with several instances, a change made through another one
+// shows up here when the entry expires.
+type CachedContactRepository struct {
+	ContactRepository
+
+	cache *lru.Cache
+	ttl   time.Duration
+	loads singleflight.Group
+
+	// generation is bumped by every invalidation. A load only stores its
+	// result if no invalidation happened since it started, so a read racing
+	// a write cannot put the old contact back.
+	mu         sync.Mutex
+	generation uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewCachedContactRepository(next ContactRepository, size int, ttl time.Duration) (*CachedContactRepository, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &CachedContactRepository{
+		ContactRepository: next,
+		cache:             cache,
+		ttl:               ttl,
+	}, nil
+}
+
+func (r *CachedContactRepository) Stats() CacheStats {
+	return CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Size:   r.cache.Len(),
+	}
+}
+
+// cacheKey scopes contactID to the tenant, the way the queries are.
+func cacheKey(owner, contactID string) string {
+	return owner + "/" + contactID
+}
+
+func (r *CachedContactRepository) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Within a transaction the contact may hold writes that are not
+	// committed yet; those must neither be cached nor shared.
+	if transactionFrom(ctx) != nil {
+		return r.ContactRepository.GetContactByID(ctx, contactID)
+	}
+	key := cacheKey(owner, contactID)
+
+	if value, ok := r.cache.Get(key); ok {
+		entry := value.(*cacheEntry)
+		if time.Now().Before(entry.expires) {
+			r.hits.Add(1)
+			return entry.contact.Clone(), nil
+		}
+		r.cache.Remove(key)
+	}
+	r.misses.Add(1)
+
+	r.mu.Lock()
+	generation := r.generation
+	r.mu.Unlock()
+
+	// Concurrent misses for a key share one query. The generation is part
+	// of the flight key so that reads after a write do not join a query
+	// started before it. The query runs without the first caller's
+	// cancellation, which would otherwise fail every caller sharing it.
+	loadCtx := context.WithoutCancel(ctx)
+	value, err := r.loads.Do(key+"@"+strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		contact, err := r.ContactRepository.GetContactByID(loadCtx, contactID)
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		if r.generation == generation {
+			r.cache.Add(key, &cacheEntry{contact: contact.Clone(), expires: time.Now().Add(r.ttl)})
+		}
+		r.mu.Unlock()
+		return contact, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*domain.Contact).Clone(), nil
+}
+
+func (r *CachedContactRepository) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	err := r.ContactRepository.UpdateContact(ctx, contact)
+	r.invalidate(ctx, contact.ID)
+	return err
+}
+
+func (r *CachedContactRepository) DeleteContact(ctx context.Context, contactID string) error {
+	err := r.ContactRepository.DeleteContact(ctx, contactID)
+	r.invalidate(ctx, contactID)
+	return err
+}
+
+func (r *CachedContactRepository) MergeContacts(ctx context.Context, survivor *domain.Contact, duplicateIDs []string, entry *domain.HistoryEntry) error {
+	err := r.ContactRepository.MergeContacts(ctx, survivor, duplicateIDs, entry)
+	r.invalidate(ctx, append([]string{survivor.ID}, duplicateIDs...)...)
+	return err
+}
+
+func (r *CachedContactRepository) CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error {
+	err := r.ContactRepository.CompareAndUpdateContact(ctx, contact, clock)
+	r.invalidate(ctx, contact.ID)
+	return err
+}
+
+func (r *CachedContactRepository) CompareAndDeleteContact(ctx context.Context, contactID string, version int64) error {
+	err := r.ContactRepository.CompareAndDeleteContact(ctx, contactID, version)
+	r.invalidate(ctx, contactID)
+	return err
+}
+
+// RemoveCustomField changes any number of contacts, so it drops all of the
+// tenant's.
+func (r *CachedContactRepository) RemoveCustomField(ctx context.Context, name string) error {
+	err := r.ContactRepository.RemoveCustomField(ctx, name)
+	owner, ownerErr := ownerID(ctx)
+	if ownerErr != nil {
+		return err
+	}
+	r.dropTenant(owner)
+	afterCommit(ctx, func() { r.dropTenant(owner) })
+	return err
+}
+
+// invalidate drops the cached contacts even when the write failed, as it
+// may have failed after committing. A write within a transaction drops
+// them again on commit, since reads in between still see the old contacts.
+func (r *CachedContactRepository) invalidate(ctx context.Context, contactIDs ...string) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return
+	}
+	r.drop(owner, contactIDs)
+	afterCommit(ctx, func() { r.drop(owner, contactIDs) })
+}
+
+func (r *CachedContactRepository) drop(owner string, contactIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	for _, id := range contactIDs {
+		r.cache.Remove(cacheKey(owner, id))
+	}
+}
+
+func (r *CachedContactRepository) dropTenant(owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	prefix := cacheKey(owner, "")
+	for _, key := range r.cache.Keys() {
+		if k, ok := key.(string); ok && strings.HasPrefix(k, prefix) {
+			r.cache.Remove(key)
+		}
+	}
+}
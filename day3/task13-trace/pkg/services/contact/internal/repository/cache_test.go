@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// fakeContactRepository holds one contact. When gate is set, loads signal
+// started and wait for gate before reading it.
+type fakeContactRepository struct {
+	ContactRepository
+
+	mu      sync.Mutex
+	contact domain.Contact
+	loads   int
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (r *fakeContactRepository) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	r.mu.Lock()
+	r.loads++
+	snapshot := r.contact
+	started, gate := r.started, r.gate
+	r.mu.Unlock()
+
+	if gate != nil {
+		started <- struct{}{}
+		<-gate
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *fakeContactRepository) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contact = *contact
+	return nil
+}
+
+func (r *fakeContactRepository) loadCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loads
+}
+
+func newTestCache(t *testing.T) (*CachedContactRepository, *fakeContactRepository, context.Context) {
+	next := &fakeContactRepository{
+		contact: domain.Contact{ID: "c1", OwnerID: "tenant-1", FullName: "Old", Version: 1},
+		started: make(chan struct{}),
+		gate:    make(chan struct{}),
+	}
+	cache, err := NewCachedContactRepository(next, 16, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache, next, context.WithValue(context.Background(), "tenantID", "tenant-1")
+}
+
+func TestCacheLoadOutlivesCanceledCaller(t *testing.T) {
+	cache, next, ctx := newTestCache(t)
+	callerCtx, cancel := context.WithCancel(ctx)
+
+	result := make(chan error)
+	go func() {
+		_, err := cache.GetContactByID(callerCtx, "c1")
+		result <- err
+	}()
+	<-next.started
+	cancel()
+	close(next.gate)
+
+	if err := <-result; err != nil {
+		t.Fatalf("GetContactByID = %v; the shared load was canceled with its first caller", err)
+	}
+	if _, err := cache.GetContactByID(ctx, "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := next.loadCount(); n != 1 {
+		t.Errorf("%d loads, want the second read served from the cache", n)
+	}
+}
+
+func TestCacheDropsLoadRacingWrite(t *testing.T) {
+	cache, next, ctx := newTestCache(t)
+
+	loaded := make(chan *domain.Contact)
+	go func() {
+		contact, err := cache.GetContactByID(ctx, "c1")
+		if err != nil {
+			t.Error(err)
+		}
+		loaded <- contact
+	}()
+	<-next.started
+
+	// The write lands while the load still holds the old contact.
+	if err := cache.UpdateContact(ctx, &domain.Contact{ID: "c1", OwnerID: "tenant-1", FullName: "New", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	next.mu.Lock()
+	gate := next.gate
+	next.gate = nil
+	next.mu.Unlock()
+	close(gate)
+	<-loaded
+
+	got, err := cache.GetContactByID(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FullName != "New" || got.Version != 2 {
+		t.Errorf("GetContactByID = %q v%d, want the written contact, not the one loaded before the write", got.FullName, got.Version)
+	}
+	if n := next.loadCount(); n != 2 {
+		t.Errorf("%d loads, want 2", n)
+	}
+}
+
+func TestCacheReturnsClones(t *testing.T) {
+	cache, next, ctx := newTestCache(t)
+	next.gate = nil
+
+	first, err := cache.GetContactByID(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.FullName = "Changed by the caller"
+	second, err := cache.GetContactByID(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.FullName != "Old" || next.loadCount() != 1 {
+		t.Errorf("cached contact = %q after %d loads", second.FullName, next.loadCount())
+	}
+}
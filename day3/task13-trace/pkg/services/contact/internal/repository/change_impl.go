@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+type changeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewChangeRepository(db *sql.DB) ChangeRepository {
+	return &changeRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *changeRepositoryImpl) GetChanges(ctx context.Context, since int64, limit int) ([]*domain.Change, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT seq, event_type, created_at, payload FROM outbox
+		WHERE owner_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, owner, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*domain.Change{}
+	for rows.Next() {
+		change := &domain.Change{}
+		if err := rows.Scan(&change.Seq, &change.Type, &change.OccurredAt, &change.Data); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type customFieldRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewCustomFieldRepository(db *sql.DB) CustomFieldRepository {
+	return &customFieldRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *customFieldRepositoryImpl) CreateCustomField(ctx context.Context, field *domain.CustomField) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	field.OwnerID = owner
+
+	query := "INSERT INTO custom_fields (owner_id, name, type) VALUES ($1, $2, $3)"
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, owner, field.Name, field.Type)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return &domain.ValidationError{Field: "Name", Reason: "is already defined"}
+	}
+	return err
+}
+
+func (r *customFieldRepositoryImpl) GetCustomFields(ctx context.Context) ([]*domain.CustomField, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT name, owner_id, type FROM custom_fields WHERE owner_id = $1 ORDER BY name"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []*domain.CustomField{}
+	for rows.Next() {
+		field := &domain.CustomField{}
+		if err := rows.Scan(&field.Name, &field.OwnerID, &field.Type); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// DeleteCustomField removes the definition only; the values contacts hold
+// are removed by ContactRepository.RemoveCustomField.
+func (r *customFieldRepositoryImpl) DeleteCustomField(ctx context.Context, name string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	query := "DELETE FROM custom_fields WHERE owner_id = $1 AND name = $2"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, owner, name)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
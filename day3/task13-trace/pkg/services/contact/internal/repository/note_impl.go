@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+const noteColumns = "id, contact_id, owner_id, author, type, body, occurred_at, created_at"
+
+type noteRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewNoteRepository(db *sql.DB) NoteRepository {
+	return &noteRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *noteRepositoryImpl) CreateNote(ctx context.Context, note *domain.Note, entry *domain.HistoryEntry) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	note.OwnerID = owner
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO contact_notes (id, contact_id, owner_id, author, type, body, occurred_at)
+		SELECT $3, id, owner_id, $4, $5, $6, $7 FROM contacts WHERE id = $1 AND owner_id = $2
+		RETURNING created_at`
+	if err := insertForContact(ctx, tx, query, note.ContactID, owner,
+		[]interface{}{note.ID, note.Author, note.Type, note.Body, note.OccurredAt}, &note.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := recordHistory(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *noteRepositoryImpl) GetNotes(ctx context.Context, contactID, noteType, afterID string, limit int) ([]*domain.Note, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + noteColumns + ` FROM contact_notes
+		WHERE contact_id = $1 AND owner_id = $2 AND ($3 = '' OR type = $3)
+			AND ($4 = '' OR (occurred_at, id) < (
+				SELECT occurred_at, id FROM contact_notes WHERE id = NULLIF($4, '')::uuid AND owner_id = $2))
+		ORDER BY occurred_at DESC, id DESC LIMIT $5`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, contactID, owner, noteType, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []*domain.Note{}
+	for rows.Next() {
+		note := &domain.Note{}
+		if err := rows.Scan(&note.ID, &note.ContactID, &note.OwnerID, &note.Author, &note.Type, &note.Body,
+			&note.OccurredAt, &note.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *noteRepositoryImpl) GetHistory(ctx context.Context, contactID, afterID string, limit int) ([]*domain.HistoryEntry, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, contact_id, owner_id, action, details, created_at FROM contact_history
+		WHERE contact_id = $1 AND owner_id = $2
+			AND ($3 = '' OR (created_at, id) < (
+				SELECT created_at, id FROM contact_history WHERE id = NULLIF($3, '')::uuid AND owner_id = $2))
+		ORDER BY created_at DESC, id DESC LIMIT $4`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, contactID, owner, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*domain.HistoryEntry{}
+	for rows.Next() {
+		entry := &domain.HistoryEntry{}
+		if err := rows.Scan(&entry.ID, &entry.ContactID, &entry.OwnerID, &entry.Action, &entry.Details,
+			&entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
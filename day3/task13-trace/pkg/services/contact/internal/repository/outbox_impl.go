@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type outboxRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepositoryImpl{
+		db: db,
+	}
+}
+
+// PublishPending holds the row locks of the events while publish runs, so
+// concurrent relays publish disjoint batches. An event is published again
+// when marking it fails, which makes delivery at-least-once.
+func (r *outboxRepositoryImpl) PublishPending(ctx context.Context, limit int, publish func(ctx context.Context, events []domain.Event) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, owner_id, seq, event_type, payload, created_at FROM outbox
+		WHERE published_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	events := []domain.Event{}
+	ids := []int64{}
+	for rows.Next() {
+		var event domain.Event
+		if err := rows.Scan(&event.ID, &event.OwnerID, &event.Seq, &event.Type, &event.Data, &event.OccurredAt); err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(ctx, events); err != nil {
+		return 0, err
+	}
+
+	query = "UPDATE outbox SET published_at = now() WHERE id = ANY($1)"
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
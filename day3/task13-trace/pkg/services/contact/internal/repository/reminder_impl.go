@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type reminderRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) ReminderRepository {
+	return &reminderRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *reminderRepositoryImpl) GetContactsWithEventDays(ctx context.Context, days []string, afterID string, limit int) ([]*domain.Contact, error) {
+	query := "SELECT " + contactColumns + ` FROM contacts
+		WHERE event_days && $1::text[] AND ($2 = '' OR id > NULLIF($2, '')::uuid)
+		ORDER BY id LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(days), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*domain.Contact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+type reminderKey struct {
+	contactID, kind, label, on string
+}
+
+// SendReminders holds the rows it records while send runs, so a
+// concurrent scheduler waits and then skips the same reminders. A
+// reminder is sent again when recording it fails, which makes sending
+// at-least-once.
+func (r *reminderRepositoryImpl) SendReminders(ctx context.Context, reminders []domain.Reminder, send func(ctx context.Context, reminders []domain.Reminder) error) (int, error) {
+	if len(reminders) == 0 {
+		return 0, nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	contactIDs := make([]string, len(reminders))
+	kinds := make([]string, len(reminders))
+	labels := make([]string, len(reminders))
+	days := make([]string, len(reminders))
+	for i, reminder := range reminders {
+		contactIDs[i] = reminder.ContactID
+		kinds[i] = reminder.Kind
+		labels[i] = reminder.Label
+		days[i] = reminder.On.String()
+	}
+	query := `INSERT INTO reminders_sent (contact_id, kind, label, occurs_on)
+		SELECT * FROM unnest($1::uuid[], $2::text[], $3::text[], $4::date[])
+		ON CONFLICT DO NOTHING
+		RETURNING contact_id::text, kind, label, occurs_on::text`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(contactIDs), pq.Array(kinds), pq.Array(labels), pq.Array(days))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	recorded := map[reminderKey]bool{}
+	for rows.Next() {
+		var key reminderKey
+		if err := rows.Scan(&key.contactID, &key.kind, &key.label, &key.on); err != nil {
+			return 0, err
+		}
+		recorded[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var unsent []domain.Reminder
+	for _, reminder := range reminders {
+		if recorded[reminderKey{reminder.ContactID, reminder.Kind, reminder.Label, reminder.On.String()}] {
+			unsent = append(unsent, reminder)
+		}
+	}
+	if len(unsent) == 0 {
+		return 0, nil
+	}
+
+	if err := send(ctx, unsent); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(unsent), nil
+}
+
+func (r *reminderRepositoryImpl) PurgeReminders(ctx context.Context, before domain.Date) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM reminders_sent WHERE occurs_on < $1::date", before.String())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,17 +1,122 @@
 package repository
 
-import "go/pkg/services/contact/internal/domain"
+import (
+    "context"
+    "time"
+
+    "go/pkg/auth"
+    "go/pkg/services/contact/internal/domain"
+)
 
 type ContactRepository interface {
-    CreateContact(contact *domain.Contact) error
-    UpdateContact(contact *domain.Contact) error
-    DeleteContact(contactID string) error
-    GetContactByID(contactID string) (*domain.Contact, error)
+    CreateContact(ctx context.Context, contact *domain.Contact) error
+    CreateContacts(ctx context.Context, contacts []*domain.Contact) error
+    UpdateContact(ctx context.Context, contact *domain.Contact) error
+    DeleteContact(ctx context.Context, contactID string) error
+    GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error)
+    GetContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error)
+    GetAllContacts(ctx context.Context) ([]*domain.Contact, error)
+    MergeContacts(ctx context.Context, survivor *domain.Contact, duplicateIDs []string, entry *domain.HistoryEntry) error
+    // GetContactClock returns a contact and the stamps of its fields.
+    GetContactClock(ctx context.Context, contactID string) (*domain.Contact, domain.FieldClock, error)
+    // CompareAndUpdateContact and CompareAndDeleteContact only write if the
+    // stored contact is still at the given version and return
+    // domain.ErrVersionConflict otherwise, also when it is gone.
+    CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error
+    CompareAndDeleteContact(ctx context.Context, contactID string, version int64) error
+    FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error)
+    RemoveCustomField(ctx context.Context, name string) error
+}
+
+type CustomFieldRepository interface {
+    CreateCustomField(ctx context.Context, field *domain.CustomField) error
+    GetCustomFields(ctx context.Context) ([]*domain.CustomField, error)
+    DeleteCustomField(ctx context.Context, name string) error
+}
+
+type AvatarRepository interface {
+    SaveAvatar(ctx context.Context, avatar *domain.Avatar) error
+    GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error)
+    DeleteAvatar(ctx context.Context, contactID string) error
+    // PurgeDeletedAvatars passes up to limit avatars of deleted contacts,
+    // across tenants, to purge and forgets them if it succeeds.
+    PurgeDeletedAvatars(ctx context.Context, limit int, purge func(ctx context.Context, avatars []*domain.Avatar) error) (int, error)
+}
+
+type NoteRepository interface {
+    // CreateNote adds a note to a contact of the tenant and records entry
+    // in the contact's history along with it.
+    CreateNote(ctx context.Context, note *domain.Note, entry *domain.HistoryEntry) error
+    // GetNotes returns up to limit notes of a contact, latest first, of
+    // the given type unless it is empty, and after the note with ID
+    // afterID unless that is empty.
+    GetNotes(ctx context.Context, contactID, noteType, afterID string, limit int) ([]*domain.Note, error)
+    // GetHistory returns up to limit history entries of a contact, latest
+    // first, after the entry with ID afterID unless that is empty.
+    GetHistory(ctx context.Context, contactID, afterID string, limit int) ([]*domain.HistoryEntry, error)
 }
 
 type GroupRepository interface {
-    CreateGroup(group *domain.Group) error
-    GetGroupByID(groupID string) (*domain.Group, error)
-    GetAllGroups() ([]*domain.Group, error)
-    AddContactToGroup(contactID, groupID string) error
+    CreateGroup(ctx context.Context, group *domain.Group) error
+    UpdateGroup(ctx context.Context, group *domain.Group) error
+    DeleteGroup(ctx context.Context, groupID string) error
+    GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error)
+    GetAllGroups(ctx context.Context) ([]*domain.Group, error)
+    GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error)
+    AddContactToGroup(ctx context.Context, contactID, groupID string) error
+    // GetGroupSubtree returns the group followed by its descendants,
+    // nearest first and by name within a level.
+    GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error)
+    // GetEffectiveMembers returns the contacts in the group or any of its
+    // descendants, each once.
+    GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error)
+}
+
+// APIKeyRepository reports a key it cannot find as domain.ErrNotFound.
+type APIKeyRepository interface {
+    CreateAPIKey(ctx context.Context, key *auth.APIKey) error
+    GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error)
+    GetAPIKeys(ctx context.Context, tenantID string) ([]*auth.APIKey, error)
+    RevokeAPIKey(ctx context.Context, tenantID, keyID string) (*auth.APIKey, error)
+    TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+}
+
+// WebhookRepository manages the subscriptions, deliveries and attempt log
+// of a tenant. FanOutEvents, ClaimDeliveries and RecordAttempt serve the
+// dispatcher and work across tenants.
+type WebhookRepository interface {
+    CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error
+    GetSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+    DeleteSubscription(ctx context.Context, subscriptionID string) error
+    GetDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*domain.WebhookDelivery, error)
+    FanOutEvents(ctx context.Context, limit int) (int, error)
+    ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDispatch, error)
+    RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error
+}
+
+// OutboxRepository feeds the event relay with the events of all tenants.
+type OutboxRepository interface {
+    // PublishPending passes up to limit unpublished events, oldest first,
+    // to publish and marks them published if it succeeds.
+    PublishPending(ctx context.Context, limit int, publish func(ctx context.Context, events []domain.Event) error) (int, error)
+}
+
+// ReminderRepository feeds the reminder scheduler with the contacts of all
+// tenants and remembers the reminders it sent.
+type ReminderRepository interface {
+    // GetContactsWithEventDays returns up to limit contacts whose birthday
+    // or anniversary falls on one of days, written "01-02", ordered by ID
+    // and starting after afterID.
+    GetContactsWithEventDays(ctx context.Context, days []string, afterID string, limit int) ([]*domain.Contact, error)
+    // SendReminders passes the reminders not sent before to send and
+    // records them as sent if it succeeds.
+    SendReminders(ctx context.Context, reminders []domain.Reminder, send func(ctx context.Context, reminders []domain.Reminder) error) (int, error)
+    // PurgeReminders forgets the reminders of days before the given one.
+    PurgeReminders(ctx context.Context, before domain.Date) (int64, error)
+}
+
+// ChangeRepository reads the change feed of the request's tenant.
+type ChangeRepository interface {
+    // GetChanges returns up to limit changes numbered above since, in order.
+    GetChanges(ctx context.Context, since int64, limit int) ([]*domain.Change, error)
 }
@@ -1,10 +1,196 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
 	"go/pkg/services/contact/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const contactColumns = `id, owner_id, full_name, first_name, patronymic, phone_number, email,
+	address_street, address_locality, address_region, address_postal_code, address_country, version,
+	tags, custom_fields, birthday, anniversaries`
+
+const groupColumns = "id, owner_id, name, COALESCE(parent_id::text, ''), rule"
+
+// groupSubtree selects the ids of group $1 of tenant $2 and of all groups
+// below it, with their depth below $1. The path of each row ends the
+// recursion even if the parent links ever formed a cycle.
+const groupSubtree = `WITH RECURSIVE subtree AS (
+		SELECT id, 0 AS depth, ARRAY[id] AS path FROM groups WHERE id = $1 AND owner_id = $2
+		UNION ALL
+		SELECT g.id, s.depth + 1, s.path || g.id FROM groups g JOIN subtree s ON g.parent_id = s.id
+		WHERE g.owner_id = $2 AND g.id <> ALL(s.path)
+	)`
+
+var errNoTenant = errors.New("repository: no tenant in context")
+
+// ownerID returns the tenant the request acts for, as stored under
+// "tenantID" by the delivery layer. Every query is scoped to it, and
+// repositories refuse to run unscoped when it is missing.
+func ownerID(ctx context.Context) (string, error) {
+	owner, _ := ctx.Value("tenantID").(string)
+	if owner == "" {
+		return "", errNoTenant
+	}
+	return owner, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type execQueryer interface {
+	execer
+	queryer
+}
+
+// scanContact scans the contactColumns of row and then into extra, for
+// queries that select more.
+func scanContact(row rowScanner, extra ...interface{}) (*domain.Contact, error) {
+	contact := &domain.Contact{}
+	var tagsJSON, customJSON, anniversariesJSON []byte
+	var birthday string
+	dest := append([]interface{}{&contact.ID, &contact.OwnerID, &contact.FullName, &contact.FirstName, &contact.Patronymic,
+		&contact.PhoneNumber, &contact.Email,
+		&contact.Address.Street, &contact.Address.Locality, &contact.Address.Region,
+		&contact.Address.PostalCode, &contact.Address.Country, &contact.Version,
+		&tagsJSON, &customJSON, &birthday, &anniversariesJSON}, extra...)
+	err := row.Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tagsJSON, &contact.Tags); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(customJSON, &contact.CustomFields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(anniversariesJSON, &contact.Anniversaries); err != nil {
+		return nil, err
+	}
+	if birthday != "" {
+		date, err := domain.ParseDate(birthday)
+		if err != nil {
+			return nil, err
+		}
+		contact.Birthday = &date
+	}
+	if len(contact.Tags) == 0 {
+		contact.Tags = nil
+	}
+	if len(contact.CustomFields) == 0 {
+		contact.CustomFields = nil
+	}
+	if len(contact.Anniversaries) == 0 {
+		contact.Anniversaries = nil
+	}
+	return contact, nil
+}
+
+// contactJSON encodes the tags and custom fields of contact for their
+// columns.
+func contactJSON(contact *domain.Contact) (tags, customFields []byte, err error) {
+	tagList := contact.Tags
+	if tagList == nil {
+		tagList = []string{}
+	}
+	if tags, err = json.Marshal(tagList); err != nil {
+		return nil, nil, err
+	}
+	fields := contact.CustomFields
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	if customFields, err = json.Marshal(fields); err != nil {
+		return nil, nil, err
+	}
+	return tags, customFields, nil
+}
+
+// contactDates encodes the birthday and anniversaries of contact for their
+// columns, along with the event_days that index them.
+func contactDates(contact *domain.Contact) (birthday string, anniversaries []byte, eventDays interface{}, err error) {
+	if contact.Birthday != nil {
+		birthday = contact.Birthday.String()
+	}
+	list := contact.Anniversaries
+	if list == nil {
+		list = []domain.Anniversary{}
+	}
+	if anniversaries, err = json.Marshal(list); err != nil {
+		return "", nil, nil, err
+	}
+	days := contact.EventDays()
+	if days == nil {
+		days = []string{}
+	}
+	return birthday, anniversaries, pq.Array(days), nil
+}
+
+func scanGroup(row rowScanner) (*domain.Group, error) {
+	group := &domain.Group{}
+	err := row.Scan(&group.ID, &group.OwnerID, &group.Name, &group.ParentID, &group.Rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func checkAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// enqueueEvent records event in the outbox. Callers pass the transaction
+// of the change it describes, so there is an event exactly for every
+// committed change.
+//
+// The event gets the next number of the tenant's sequence. The advisory
+// lock serializes the tenant's writers from here to their commit, so the
+// numbers are committed in order and the change feed never skips one.
+func enqueueEvent(ctx context.Context, db execer, owner string, event domain.DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('outbox:' || $1))", owner); err != nil {
+		return err
+	}
+	query := `INSERT INTO outbox (owner_id, seq, event_type, payload)
+		VALUES ($1, COALESCE((SELECT max(seq) FROM outbox WHERE owner_id = $1), 0) + 1, $2, $3)`
+	_, err = db.ExecContext(ctx, query, owner, event.EventType(), payload)
+	return err
+}
+
 type contactRepositoryImpl struct {
 	db *sql.DB
 }
@@ -15,32 +201,509 @@ func NewContactRepository(db *sql.DB) ContactRepository {
 	}
 }
 
-func (r *contactRepositoryImpl) SaveContact(contact *domain.Contact) error {
-	query := "INSERT INTO contacts (name, email) VALUES (?, ?)"
-	_, err := r.db.Exec(query, contact.Name, contact.Email)
+func (r *contactRepositoryImpl) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	contact.OwnerID = owner
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tags, customFields, err := contactJSON(contact)
+	if err != nil {
+		return err
+	}
+	birthday, anniversaries, eventDays, err := contactDates(contact)
+	if err != nil {
+		return err
+	}
+
+	// Clients that create contacts offline choose their IDs; everyone else
+	// leaves the ID to the database.
+	query := `INSERT INTO contacts (id, owner_id, full_name, first_name, patronymic, phone_number, email,
+		address_street, address_locality, address_region, address_postal_code, address_country,
+		tags, custom_fields, birthday, anniversaries, event_days)
+		VALUES (COALESCE(NULLIF($1, '')::uuid, gen_random_uuid()), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			$15, $16, $17)
+		RETURNING id, version`
+	err = tx.QueryRowContext(ctx, query, contact.ID, owner, contact.FullName, contact.FirstName, contact.Patronymic,
+		contact.PhoneNumber, contact.Email,
+		contact.Address.Street, contact.Address.Locality, contact.Address.Region,
+		contact.Address.PostalCode, contact.Address.Country, tags, customFields,
+		birthday, anniversaries, eventDays).Scan(&contact.ID, &contact.Version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrIDInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := enqueueEvent(ctx, tx, owner, domain.ContactCreated{Contact: *contact}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *contactRepositoryImpl) CreateContacts(ctx context.Context, contacts []*domain.Contact) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("contacts", "id", "owner_id", "full_name", "first_name", "patronymic",
+		"phone_number", "email", "address_street", "address_locality", "address_region",
+		"address_postal_code", "address_country", "tags", "custom_fields", "birthday", "anniversaries",
+		"event_days"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	ids := make([]string, len(contacts))
+	for i, contact := range contacts {
+		ids[i] = uuid.New().String()
+		tags, customFields, err := contactJSON(contact)
+		if err != nil {
+			return err
+		}
+		birthday, anniversaries, eventDays, err := contactDates(contact)
+		if err != nil {
+			return err
+		}
+		// COPY would send []byte as bytea, so the JSON goes as text.
+		_, err = stmt.ExecContext(ctx, ids[i], owner, contact.FullName, contact.FirstName, contact.Patronymic,
+			contact.PhoneNumber, contact.Email,
+			contact.Address.Street, contact.Address.Locality, contact.Address.Region,
+			contact.Address.PostalCode, contact.Address.Country, string(tags), string(customFields),
+			birthday, string(anniversaries), eventDays)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	for i, contact := range contacts {
+		created := *contact
+		created.ID = ids[i]
+		created.OwnerID = owner
+		created.Version = 1
+		if err := enqueueEvent(ctx, tx, owner, domain.ContactCreated{Contact: created}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i, contact := range contacts {
+		contact.ID = ids[i]
+		contact.OwnerID = owner
+	}
+	return nil
+}
+
+func (r *contactRepositoryImpl) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateContact(ctx, tx, contact); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateContact writes contact over the stored one and stamps the fields
+// it changes with the next version and the current time.
+func updateContact(ctx context.Context, db execQueryer, contact *domain.Contact) error {
+	current, clock, err := getContactClock(ctx, db, contact.ID, true)
+	if err != nil {
+		return err
+	}
+	clock.Stamp(current, contact, domain.FieldStamp{Version: current.Version + 1, ModifiedAt: time.Now()})
+	contact.Version = current.Version
+	return writeContact(ctx, db, contact, clock)
+}
+
+// getContactClock reads a contact with its field clock, locking the row
+// until the end of the transaction if forUpdate is set.
+func getContactClock(ctx context.Context, db queryer, contactID string, forUpdate bool) (*domain.Contact, domain.FieldClock, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	query := "SELECT " + contactColumns + ", field_clock FROM contacts WHERE id = $1 AND owner_id = $2"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var clockJSON []byte
+	contact, err := scanContact(db.QueryRowContext(ctx, query, contactID, owner), &clockJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clock := domain.FieldClock{}
+	if err := json.Unmarshal(clockJSON, &clock); err != nil {
+		return nil, nil, err
+	}
+	return contact, clock, nil
+}
+
+// writeContact stores contact and clock if the stored contact is still at
+// contact.Version, and moves contact to the next version.
+func writeContact(ctx context.Context, db execer, contact *domain.Contact, clock domain.FieldClock) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	clockJSON, err := json.Marshal(clock)
+	if err != nil {
+		return err
+	}
+	tags, customFields, err := contactJSON(contact)
+	if err != nil {
+		return err
+	}
+	birthday, anniversaries, eventDays, err := contactDates(contact)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE contacts SET full_name = $3, first_name = $4, patronymic = $5, phone_number = $6,
+		email = $7, address_street = $8, address_locality = $9, address_region = $10,
+		address_postal_code = $11, address_country = $12, field_clock = $13, tags = $15,
+		custom_fields = $16, birthday = $17, anniversaries = $18, event_days = $19, version = version + 1
+		WHERE id = $1 AND owner_id = $2 AND version = $14`
+	result, err := db.ExecContext(ctx, query, contact.ID, owner, contact.FullName, contact.FirstName,
+		contact.Patronymic, contact.PhoneNumber, contact.Email,
+		contact.Address.Street, contact.Address.Locality, contact.Address.Region,
+		contact.Address.PostalCode, contact.Address.Country, clockJSON, contact.Version, tags, customFields,
+		birthday, anniversaries, eventDays)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrVersionConflict
+	} else if err != nil {
+		return err
+	}
+	contact.OwnerID = owner
+	contact.Version++
+	return enqueueEvent(ctx, db, owner, domain.ContactUpdated{Contact: *contact})
+}
+
+func (r *contactRepositoryImpl) GetContactClock(ctx context.Context, contactID string) (*domain.Contact, domain.FieldClock, error) {
+	return getContactClock(ctx, conn(ctx, r.db), contactID, false)
+}
+
+func (r *contactRepositoryImpl) CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeContact(ctx, tx, contact, clock); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *contactRepositoryImpl) CompareAndDeleteContact(ctx context.Context, contactID string, version int64) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM contacts WHERE id = $1 AND owner_id = $2 AND version = $3"
+	result, err := tx.ExecContext(ctx, query, contactID, owner, version)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrVersionConflict
+	} else if err != nil {
+		return err
+	}
+
+	if err := enqueueEvent(ctx, tx, owner, domain.ContactDeleted{ID: contactID}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *contactRepositoryImpl) MergeContacts(ctx context.Context, survivor *domain.Contact, duplicateIDs []string, entry *domain.HistoryEntry) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateContact(ctx, tx, survivor); err != nil {
+		return err
+	}
+
+	// Duplicates of another tenant fail the delete below, which rolls this
+	// back as well.
+	query := `INSERT INTO group_contacts (group_id, contact_id)
+		SELECT group_id, $1 FROM group_contacts WHERE contact_id = ANY($2)
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, survivor.ID, pq.Array(duplicateIDs)); err != nil {
+		return err
+	}
+	query = "UPDATE contact_notes SET contact_id = $1 WHERE contact_id = ANY($2) AND owner_id = $3"
+	if _, err := tx.ExecContext(ctx, query, survivor.ID, pq.Array(duplicateIDs), owner); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM contacts WHERE id = ANY($1) AND owner_id = $2",
+		pq.Array(duplicateIDs), owner)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted != int64(len(duplicateIDs)) {
+		return domain.ErrNotFound
+	}
+	for _, id := range duplicateIDs {
+		if err := enqueueEvent(ctx, tx, owner, domain.ContactDeleted{ID: id}); err != nil {
+			return err
+		}
+	}
+
+	if err := recordHistory(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func recordHistory(ctx context.Context, db queryer, entry *domain.HistoryEntry) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	entry.OwnerID = owner
+
+	query := `INSERT INTO contact_history (contact_id, owner_id, action, details)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	return db.QueryRowContext(ctx, query, entry.ContactID, owner, entry.Action, string(entry.Details)).
+		Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// insertForContact runs query, an INSERT ... SELECT ... FROM contacts
+// WHERE id = $1 AND owner_id = $2 ... RETURNING that adds a row belonging
+// to a contact, with contactID, owner and args, and scans the returned row
+// into dest. Selecting the contact makes sure it belongs to the tenant;
+// the foreign key only tells that it exists. It returns ErrNotFound when
+// the contact is not the tenant's or is deleted meanwhile.
+func insertForContact(ctx context.Context, db queryer, query, contactID, owner string, args []interface{}, dest ...interface{}) error {
+	args = append([]interface{}{contactID, owner}, args...)
+	err := db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	var pqErr *pq.Error
+	if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == "23503") {
+		return domain.ErrNotFound
+	}
 	return err
 }
 
-func (r *contactRepositoryImpl) GetContactByID(contactID string) (*domain.Contact, error) {
-	query := "SELECT name, email FROM contacts WHERE id = ?"
-	row := r.db.QueryRow(query, contactID)
+func (r *contactRepositoryImpl) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE id = $1 AND owner_id = $2"
+	return scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, contactID, owner))
+}
 
-	contact := &domain.Contact{}
-	err := row.Scan(&contact.Name, &contact.Email)
+func (r *contactRepositoryImpl) GetContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE owner_id = $1 AND phone_number = $2 ORDER BY id LIMIT 1"
+	return scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, owner, phoneNumber))
+}
+
+func (r *contactRepositoryImpl) GetAllContacts(ctx context.Context) ([]*domain.Contact, error) {
+	owner, err := ownerID(ctx)
 	if err != nil {
 		return nil, err
 	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE owner_id = $1 ORDER BY full_name, id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*domain.Contact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
 
-	return contact, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func (r *contactRepositoryImpl) FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE owner_id = $1"
+	args := []interface{}{owner}
+	// Containment matches numbers by value, so 12 finds 12.0 as well.
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, tags)
+		query += " AND tags @> $" + strconv.Itoa(len(args)) + "::jsonb"
+	}
+	if len(filter.CustomFields) > 0 {
+		fields, err := json.Marshal(filter.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, fields)
+		query += " AND custom_fields @> $" + strconv.Itoa(len(args)) + "::jsonb"
+	}
+	if len(filter.EventDays) > 0 {
+		args = append(args, pq.Array(filter.EventDays))
+		query += " AND event_days && $" + strconv.Itoa(len(args)) + "::text[]"
+	}
+	query += " ORDER BY full_name, id"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*domain.Contact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
 }
 
-func (r *contactRepositoryImpl) DeleteContact(contactID string) error {
-	query := "DELETE FROM contacts WHERE id = ?"
-	_, err := r.db.Exec(query, contactID)
+// RemoveCustomField deletes the values of the custom field from all of the
+// tenant's contacts. Each contact changed moves to its next version.
+func (r *contactRepositoryImpl) RemoveCustomField(ctx context.Context, name string) error {
+	owner, err := ownerID(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE contacts SET custom_fields = custom_fields - $2, version = version + 1
+		WHERE owner_id = $1 AND custom_fields ? $2
+		RETURNING ` + contactColumns
+	rows, err := tx.QueryContext(ctx, query, owner, name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var changed []*domain.Contact
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, contact)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, contact := range changed {
+		if err := enqueueEvent(ctx, tx, owner, domain.ContactUpdated{Contact: *contact}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *contactRepositoryImpl) DeleteContact(ctx context.Context, contactID string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM contacts WHERE id = $1 AND owner_id = $2"
+	result, err := tx.ExecContext(ctx, query, contactID, owner)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	if err := enqueueEvent(ctx, tx, owner, domain.ContactDeleted{ID: contactID}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 type groupRepositoryImpl struct {
@@ -53,31 +716,289 @@ func NewGroupRepository(db *sql.DB) GroupRepository {
 	}
 }
 
-func (r *groupRepositoryImpl) SaveGroup(group *domain.Group) error {
-	query := "INSERT INTO groups (name) VALUES (?)"
-	_, err := r.db.Exec(query, group.Name)
+func (r *groupRepositoryImpl) CreateGroup(ctx context.Context, group *domain.Group) error {
+	owner, err := ownerID(ctx)
 	if err != nil {
 		return err
 	}
+	group.OwnerID = owner
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkParent(ctx, tx, owner, "", group.ParentID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO groups (owner_id, name, parent_id, rule) VALUES ($1, $2, NULLIF($3, '')::uuid, $4) RETURNING id"
+	if err := tx.QueryRowContext(ctx, query, owner, group.Name, group.ParentID, group.Rule).Scan(&group.ID); err != nil {
+		return err
+	}
+
+	if err := enqueueEvent(ctx, tx, owner, domain.GroupCreated{Group: *group}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *groupRepositoryImpl) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkParent(ctx, tx, owner, group.ID, group.ParentID); err != nil {
+		return err
+	}
+
+	query := "UPDATE groups SET name = $3, parent_id = NULLIF($4, '')::uuid, rule = $5 WHERE id = $1 AND owner_id = $2"
+	result, err := tx.ExecContext(ctx, query, group.ID, owner, group.Name, group.ParentID, group.Rule)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+	// A smart group's members follow its rule; the ones added by hand go.
+	if group.Rule != "" {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM group_contacts WHERE group_id = $1", group.ID); err != nil {
+			return err
+		}
+	}
+	group.OwnerID = owner
+
+	if err := enqueueEvent(ctx, tx, owner, domain.GroupUpdated{Group: *group}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *groupRepositoryImpl) DeleteGroup(ctx context.Context, groupID string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockHierarchy(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	// The group's children move up to its parent.
+	query := `UPDATE groups SET parent_id = (SELECT parent_id FROM groups WHERE id = $1 AND owner_id = $2)
+		WHERE parent_id = $1 AND owner_id = $2
+		RETURNING ` + groupColumns
+	rows, err := tx.QueryContext(ctx, query, groupID, owner)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	children := []*domain.Group{}
+	for rows.Next() {
+		child, err := scanGroup(rows)
+		if err != nil {
+			return err
+		}
+		children = append(children, child)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query = "DELETE FROM groups WHERE id = $1 AND owner_id = $2"
+	result, err := tx.ExecContext(ctx, query, groupID, owner)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := enqueueEvent(ctx, tx, owner, domain.GroupUpdated{Group: *child}); err != nil {
+			return err
+		}
+	}
+	if err := enqueueEvent(ctx, tx, owner, domain.GroupDeleted{ID: groupID}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockHierarchy serializes the tenant's changes to group parents until the
+// transaction ends. Without it, two moves checked at the same time could
+// together form a cycle.
+func lockHierarchy(ctx context.Context, tx execer, owner string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('groups:' || $1))", owner)
+	return err
+}
+
+// checkParent makes sure parentID, if set, is a group of the tenant that
+// groupID may move under: neither groupID itself nor one below it. Moving a
+// group to the top needs no check.
+func checkParent(ctx context.Context, tx dbConn, owner, groupID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if err := lockHierarchy(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM groups WHERE id = $1 AND owner_id = $2
+			UNION
+			SELECT g.id, g.parent_id FROM groups g JOIN ancestors a ON g.id = a.parent_id WHERE g.owner_id = $2
+		)
+		SELECT count(*), count(*) FILTER (WHERE id = NULLIF($3, '')::uuid) FROM ancestors`
+	var found, cycle int
+	if err := tx.QueryRowContext(ctx, query, parentID, owner, groupID).Scan(&found, &cycle); err != nil {
+		return err
+	}
+	if found == 0 {
+		return &domain.ValidationError{Field: "ParentID", Reason: "is not a group"}
+	}
+	if cycle > 0 {
+		return &domain.ValidationError{Field: "ParentID", Reason: "is the group itself or one below it"}
+	}
 	return nil
 }
 
-func (r *groupRepositoryImpl) GetGroupByID(groupID string) (*domain.Group, error) {
-	query := "SELECT name FROM groups WHERE id = ?"
-	row := r.db.QueryRow(query, groupID)
+func (r *groupRepositoryImpl) GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + groupColumns + " FROM groups WHERE id = $1 AND owner_id = $2"
+	return scanGroup(conn(ctx, r.db).QueryRowContext(ctx, query, groupID, owner))
+}
 
-	group := &domain.Group{}
-	err := row.Scan(&group.Name)
+func (r *groupRepositoryImpl) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
+	owner, err := ownerID(ctx)
 	if err != nil {
 		return nil, err
 	}
+	query := "SELECT " + groupColumns + " FROM groups WHERE owner_id = $1 ORDER BY name, id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return group, nil
+	groups := []*domain.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *groupRepositoryImpl) GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	group, err := r.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return r.members(ctx, []*domain.Group{group})
 }
 
-func (r *groupRepositoryImpl) GetAllGroups() ([]*domain.Group, error) {
-	query := "SELECT name FROM groups"
-	rows, err := r.db.Query(query)
+// members returns the contacts in any of groups, each once.
+func (r *groupRepositoryImpl) members(ctx context.Context, groups []*domain.Group) ([]*domain.Contact, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{owner}
+	condition, err := membersCondition(groups, &args)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE owner_id = $1 AND (" + condition + ") ORDER BY full_name, id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*domain.Contact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func (r *groupRepositoryImpl) AddContactToGroup(ctx context.Context, contactID, groupID string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Nothing is inserted unless both sides belong to the tenant and the
+	// group is not a smart one.
+	query := `INSERT INTO group_contacts (group_id, contact_id)
+		SELECT g.id, c.id FROM groups g, contacts c
+		WHERE g.id = $1 AND c.id = $2 AND g.owner_id = $3 AND c.owner_id = $3 AND g.rule = ''
+		ON CONFLICT DO NOTHING`
+	result, err := tx.ExecContext(ctx, query, groupID, contactID, owner)
+	if err != nil {
+		return err
+	}
+
+	// Adding a member twice is not a change.
+	if added, err := result.RowsAffected(); err != nil || added == 0 {
+		return err
+	}
+	event := domain.GroupMembershipChanged{GroupID: groupID, ContactID: contactID, Change: domain.MembershipAdded}
+	if err := enqueueEvent(ctx, tx, owner, event); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *groupRepositoryImpl) GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := groupSubtree + " SELECT " + groupColumns + ` FROM groups JOIN subtree USING (id)
+		WHERE owner_id = $2
+		ORDER BY depth, name, id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, groupID, owner)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +1006,7 @@ func (r *groupRepositoryImpl) GetAllGroups() ([]*domain.Group, error) {
 
 	groups := []*domain.Group{}
 	for rows.Next() {
-		group := &domain.Group{}
-		err := rows.Scan(&group.Name)
+		group, err := scanGroup(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -100,11 +1020,10 @@ func (r *groupRepositoryImpl) GetAllGroups() ([]*domain.Group, error) {
 	return groups, nil
 }
 
-func (r *groupRepositoryImpl) AddContactToGroup(contact *domain.Contact, groupID string) error {
-	query := "INSERT INTO group_contacts (group_id, contact_id) VALUES (?, ?)"
-	_, err := r.db.Exec(query, groupID, contact.ID)
+func (r *groupRepositoryImpl) GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	groups, err := r.GetGroupSubtree(ctx, groupID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return r.members(ctx, groups)
 }
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/rule"
+
+	"github.com/lib/pq"
+)
+
+// ruleColumns maps the contact fields rules compare to their columns.
+var ruleColumns = map[string]string{
+	"FullName":           "full_name",
+	"FirstName":          "first_name",
+	"Patronymic":         "patronymic",
+	"PhoneNumber":        "phone_number",
+	"Email":              "email",
+	"Address.Street":     "address_street",
+	"Address.Locality":   "address_locality",
+	"Address.Region":     "address_region",
+	"Address.PostalCode": "address_postal_code",
+	"Address.Country":    "address_country",
+}
+
+// ruleCondition renders expr as a condition on contacts that holds for
+// exactly the contacts expr.Match accepts. The values compared with are
+// appended to args and referred to by position. contains and startswith
+// use strpos rather than LIKE, so % and _ in values match themselves, and
+// lower() folds case as strings.ToLower does as long as the database's
+// LC_CTYPE is a UTF-8 locale; TestRuleConditionAgreesWithMatch checks both.
+func ruleCondition(expr rule.Expr, args *[]interface{}) (string, error) {
+	switch e := expr.(type) {
+	case *rule.Comparison:
+		if e.Field == rule.FieldTag {
+			return tagCondition(e, args)
+		}
+		column, ok := ruleColumns[e.Field]
+		if !ok {
+			return "", fmt.Errorf("repository: no column for rule field %q", e.Field)
+		}
+		*args = append(*args, e.Value)
+		param := "$" + strconv.Itoa(len(*args)) + "::text"
+		switch e.Op {
+		case rule.OpEqual:
+			return column + " = " + param, nil
+		case rule.OpNotEqual:
+			return column + " <> " + param, nil
+		case rule.OpContains:
+			return "strpos(lower(" + column + "), lower(" + param + ")) > 0", nil
+		case rule.OpStartsWith:
+			return "strpos(lower(" + column + "), lower(" + param + ")) = 1", nil
+		}
+		return "", fmt.Errorf("repository: unknown rule operator %q", e.Op)
+	case *rule.And:
+		return binaryCondition(e.Left, "AND", e.Right, args)
+	case *rule.Or:
+		return binaryCondition(e.Left, "OR", e.Right, args)
+	case *rule.Not:
+		condition, err := ruleCondition(e.Expr, args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + condition + ")", nil
+	}
+	return "", fmt.Errorf("repository: unknown rule expression %T", expr)
+}
+
+// tagCondition renders a comparison with the contact's tags.
+func tagCondition(e *rule.Comparison, args *[]interface{}) (string, error) {
+	*args = append(*args, e.Value)
+	param := "$" + strconv.Itoa(len(*args)) + "::text"
+	anyTag := "EXISTS (SELECT 1 FROM jsonb_array_elements_text(tags) AS tag WHERE "
+	switch e.Op {
+	case rule.OpEqual:
+		return "tags ? " + param, nil
+	case rule.OpNotEqual:
+		return "NOT (tags ? " + param + ")", nil
+	case rule.OpContains:
+		return anyTag + "strpos(lower(tag), lower(" + param + ")) > 0)", nil
+	case rule.OpStartsWith:
+		return anyTag + "strpos(lower(tag), lower(" + param + ")) = 1)", nil
+	}
+	return "", fmt.Errorf("repository: unknown rule operator %q", e.Op)
+}
+
+func binaryCondition(left rule.Expr, op string, right rule.Expr, args *[]interface{}) (string, error) {
+	l, err := ruleCondition(left, args)
+	if err != nil {
+		return "", err
+	}
+	r, err := ruleCondition(right, args)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// membersCondition renders the condition selecting the members of groups:
+// the contacts added to the manual ones and those matching the rules of the
+// smart ones.
+func membersCondition(groups []*domain.Group, args *[]interface{}) (string, error) {
+	var conditions, manual []string
+	for _, group := range groups {
+		if group.Rule == "" {
+			manual = append(manual, group.ID)
+			continue
+		}
+		expr, err := rule.Parse(group.Rule)
+		if err != nil {
+			return "", fmt.Errorf("repository: rule of group %s: %w", group.ID, err)
+		}
+		condition, err := ruleCondition(expr, args)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+	if len(manual) > 0 {
+		*args = append(*args, pq.Array(manual))
+		conditions = append(conditions, "id IN (SELECT contact_id FROM group_contacts WHERE group_id = ANY($"+strconv.Itoa(len(*args))+"::uuid[]))")
+	}
+	if len(conditions) == 0 {
+		return "FALSE", nil
+	}
+	return strings.Join(conditions, " OR "), nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/rule"
+)
+
+var ruleTestContacts = []*domain.Contact{
+	{
+		ID:          "1",
+		FullName:    "Ivanov Ivan",
+		PhoneNumber: "+77011234567",
+		Email:       "IVAN@Example.com",
+		Address:     domain.Address{Locality: "Алматы"},
+		Tags:        []string{"vip", "friends"},
+	},
+	{
+		ID:       "2",
+		FullName: "Ärger Ölaf",
+		Email:    "50%_off@shop.kz",
+		Tags:     []string{"50%", "a_b"},
+	},
+	{ID: "3", FullName: "Nobody"},
+	{
+		ID:       "4",
+		FullName: "ЁЖИК Ёжиков",
+		Address:  domain.Address{Street: "Straße 1"},
+		Tags:     []string{"VIP"},
+	},
+}
+
+var ruleTestRules = []string{
+	`FullName = "Ivanov Ivan"`,
+	`FullName != "Nobody"`,
+	`Email contains "example"`,
+	`Email contains ""`,
+	`Patronymic = ""`,
+	`Email startswith "ivan@"`,
+	`FullName contains "ärger"`,
+	`FullName startswith "ёж"`,
+	`Address.Locality contains "АЛМА"`,
+	`Address.Street contains "STRASSE"`,
+	`Address.Street contains "STRAẞE"`,
+	// % and _ are not wildcards.
+	`Email contains "%"`,
+	`Email contains "0%_o"`,
+	`Email contains "%off"`,
+	`Email startswith "50%"`,
+	`Email startswith "5_%"`,
+	`Tag contains "%"`,
+	`Tag contains "_"`,
+	`Tag startswith "a_"`,
+	`Tag startswith "_"`,
+	// Tag != holds when no tag is equal, including for untagged contacts.
+	`Tag = "vip"`,
+	`Tag = "VIP"`,
+	`Tag != "vip"`,
+	`not Tag = "vip"`,
+	`Tag contains "IP"`,
+	`Tag startswith "v"`,
+	`Tag != "vip" and not Tag contains "%"`,
+	`Tag = "vip" or FullName contains "ёжик"`,
+	`not (Tag = "vip" or Email = "")`,
+}
+
+func matchingIDs(expr rule.Expr) []string {
+	ids := []string{}
+	for _, c := range ruleTestContacts {
+		if expr.Match(c) {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+func TestRuleConditionPassesValuesAsParameters(t *testing.T) {
+	expr, err := rule.Parse(`Email contains "50%_" or not Tag != "x'y"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []interface{}{"first"}
+	condition, err := ruleCondition(expr, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(strpos(lower(email), lower($2::text)) > 0 OR NOT (NOT (tags ? $3::text)))"
+	if condition != want {
+		t.Errorf("condition = %s, want %s", condition, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"first", "50%_", "x'y"}) {
+		t.Errorf("args = %q", args)
+	}
+}
+
+func TestRuleConditionCoversMatchFields(t *testing.T) {
+	for _, field := range domain.ContactFields {
+		if _, ok := ruleColumns[field]; !ok {
+			t.Errorf("no column for %s", field)
+		}
+	}
+}
+
+// TestRuleConditionAgreesWithMatch runs the SQL made from each rule
+// against a copy of the contacts in Postgres and checks that it selects
+// the contacts Match accepts. It needs a database in TEST_DATABASE_URL.
+// lower() only agrees with strings.ToLower outside ASCII when the
+// database's LC_CTYPE is a UTF-8 locale, which this checks too.
+func TestRuleConditionAgreesWithMatch(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	// Temporary tables belong to a session, so everything runs on one
+	// connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TEMPORARY TABLE contacts (
+		id TEXT PRIMARY KEY, full_name TEXT NOT NULL, first_name TEXT NOT NULL, patronymic TEXT NOT NULL,
+		phone_number TEXT NOT NULL, email TEXT NOT NULL, address_street TEXT NOT NULL,
+		address_locality TEXT NOT NULL, address_region TEXT NOT NULL, address_postal_code TEXT NOT NULL,
+		address_country TEXT NOT NULL, tags JSONB NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range ruleTestContacts {
+		tags, _, err := contactJSON(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.ExecContext(ctx, `INSERT INTO contacts VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			c.ID, c.FullName, c.FirstName, c.Patronymic, c.PhoneNumber, c.Email, c.Address.Street,
+			c.Address.Locality, c.Address.Region, c.Address.PostalCode, c.Address.Country, tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, text := range ruleTestRules {
+		t.Run(text, func(t *testing.T) {
+			expr, err := rule.Parse(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var args []interface{}
+			condition, err := ruleCondition(expr, &args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := conn.QueryContext(ctx, "SELECT id FROM contacts WHERE "+condition, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			selected := []string{}
+			for rows.Next() {
+				var id string
+				if err := rows.Scan(&id); err != nil {
+					t.Fatal(err)
+				}
+				selected = append(selected, id)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(selected)
+
+			if want := matchingIDs(expr); !reflect.DeepEqual(selected, want) {
+				t.Errorf("SQL %s selects %v, Match accepts %v", condition, selected, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Transactor runs a function in one database transaction. The contact and
+// group repository methods called with the context it passes join that
+// transaction instead of committing on their own, as do the custom field
+// repository methods.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txContextKey struct{}
+
+type transaction struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+func transactionFrom(ctx context.Context) *transaction {
+	t, _ := ctx.Value(txContextKey{}).(*transaction)
+	return t
+}
+
+type transactorImpl struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactorImpl{
+		db: db,
+	}
+}
+
+// InTransaction commits if fn returns nil and rolls back otherwise. Called
+// within a transaction, it runs fn in that one.
+func (t *transactorImpl) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if transactionFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txn := &transaction{tx: tx}
+	if err := fn(context.WithValue(ctx, txContextKey{}, txn)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, f := range txn.afterCommit {
+		f()
+	}
+	return nil
+}
+
+// afterCommit runs f once the transaction in ctx commits. Without one it
+// does nothing, as the write has committed already.
+func afterCommit(ctx context.Context, f func()) {
+	if t := transactionFrom(ctx); t != nil {
+		t.afterCommit = append(t.afterCommit, f)
+	}
+}
+
+// txHandle is the transaction of a single repository method. When the
+// method joins the transaction in its context, Commit and Rollback are left
+// to the Transactor.
+type txHandle struct {
+	*sql.Tx
+	joined bool
+}
+
+func begin(ctx context.Context, db *sql.DB) (*txHandle, error) {
+	if t := transactionFrom(ctx); t != nil {
+		return &txHandle{Tx: t.tx, joined: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txHandle{Tx: tx}, nil
+}
+
+func (h *txHandle) Commit() error {
+	if h.joined {
+		return nil
+	}
+	return h.Tx.Commit()
+}
+
+func (h *txHandle) Rollback() error {
+	if h.joined {
+		return nil
+	}
+	return h.Tx.Rollback()
+}
+
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn is what reads run on: the transaction in ctx, so that they see its
+// writes, or db.
+func conn(ctx context.Context, db *sql.DB) dbConn {
+	if t := transactionFrom(ctx); t != nil {
+		return t.tx
+	}
+	return db
+}
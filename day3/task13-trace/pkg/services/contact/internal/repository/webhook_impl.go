@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type webhookRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *webhookRepositoryImpl) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	subscription.OwnerID = owner
+
+	query := `INSERT INTO webhook_subscriptions (owner_id, url, secret, events)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, owner, subscription.URL, subscription.Secret, pq.Array(subscription.Events)).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+}
+
+// GetSubscriptions leaves out the secrets.
+func (r *webhookRepositoryImpl) GetSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, owner_id, url, events, created_at FROM webhook_subscriptions
+		WHERE owner_id = $1 ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		subscription := &domain.WebhookSubscription{}
+		err := rows.Scan(&subscription.ID, &subscription.OwnerID, &subscription.URL,
+			pq.Array(&subscription.Events), &subscription.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription drops the subscription's deliveries and their log
+// with it.
+func (r *webhookRepositoryImpl) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return err
+	}
+	query := "DELETE FROM webhook_subscriptions WHERE id = $1 AND owner_id = $2"
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, owner)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// GetDeliveries returns the latest deliveries of a subscription, newest
+// first, optionally only those with status.
+func (r *webhookRepositoryImpl) GetDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*domain.WebhookDelivery, error) {
+	owner, err := ownerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1 AND owner_id = $2)"
+	if err := r.db.QueryRowContext(ctx, query, subscriptionID, owner).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+
+	query = `SELECT d.id, d.owner_id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts,
+		d.next_attempt_at, d.created_at
+		FROM webhook_deliveries d JOIN outbox e ON e.id = d.event_id
+		WHERE d.subscription_id = $1 AND d.owner_id = $2 AND ($3 = '' OR d.status = $3)
+		ORDER BY d.created_at DESC, d.id LIMIT $4`
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID, owner, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	byID := map[string]*domain.WebhookDelivery{}
+	ids := []string{}
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{Log: []domain.WebhookAttempt{}}
+		var nextAttemptAt sql.NullTime
+		err := rows.Scan(&delivery.ID, &delivery.OwnerID, &delivery.SubscriptionID, &delivery.EventID,
+			&delivery.EventType, &delivery.Status, &delivery.Attempts, &nextAttemptAt, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if nextAttemptAt.Valid {
+			delivery.NextAttemptAt = &nextAttemptAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+		byID[delivery.ID] = delivery
+		ids = append(ids, delivery.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	query = `SELECT delivery_id, attempt, attempted_at, response_status, error, duration_ms
+		FROM webhook_delivery_attempts WHERE delivery_id = ANY($1) ORDER BY delivery_id, attempt`
+	attempts, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer attempts.Close()
+
+	for attempts.Next() {
+		var deliveryID string
+		var attempt domain.WebhookAttempt
+		var responseStatus sql.NullInt64
+		var durationMS int64
+		err := attempts.Scan(&deliveryID, &attempt.Attempt, &attempt.AttemptedAt, &responseStatus,
+			&attempt.Error, &durationMS)
+		if err != nil {
+			return nil, err
+		}
+		attempt.ResponseStatus = int(responseStatus.Int64)
+		attempt.DurationMS = durationMS
+		byID[deliveryID].Log = append(byID[deliveryID].Log, attempt)
+	}
+
+	if err := attempts.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FanOutEvents turns up to limit undispatched outbox events into one
+// delivery per matching subscription of the event's tenant and returns the
+// number of events handled. Concurrent dispatchers skip each other's
+// events.
+func (r *webhookRepositoryImpl) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, owner_id, event_type FROM outbox
+		WHERE dispatched_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	events := []domain.Event{}
+	eventIDs := []int64{}
+	owners := []string{}
+	for rows.Next() {
+		var event domain.Event
+		if err := rows.Scan(&event.ID, &event.OwnerID, &event.Type); err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+		eventIDs = append(eventIDs, event.ID)
+		owners = append(owners, event.OwnerID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	query = "SELECT id, owner_id, events FROM webhook_subscriptions WHERE owner_id = ANY($1)"
+	subs, err := tx.QueryContext(ctx, query, pq.Array(owners))
+	if err != nil {
+		return 0, err
+	}
+	defer subs.Close()
+
+	subscriptions := map[string][]*domain.WebhookSubscription{}
+	for subs.Next() {
+		subscription := &domain.WebhookSubscription{}
+		if err := subs.Scan(&subscription.ID, &subscription.OwnerID, pq.Array(&subscription.Events)); err != nil {
+			return 0, err
+		}
+		subscriptions[subscription.OwnerID] = append(subscriptions[subscription.OwnerID], subscription)
+	}
+	if err := subs.Err(); err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO webhook_deliveries (owner_id, subscription_id, event_id, next_attempt_at)
+		VALUES ($1, $2, $3, now()) ON CONFLICT DO NOTHING`
+	for _, event := range events {
+		for _, subscription := range subscriptions[event.OwnerID] {
+			if !subscription.Matches(event.Type) {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, query, event.OwnerID, subscription.ID, event.ID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	query = "UPDATE outbox SET dispatched_at = now() WHERE id = ANY($1)"
+	if _, err := tx.ExecContext(ctx, query, pq.Array(eventIDs)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// ClaimDeliveries returns up to limit pending deliveries due at now and
+// moves their next attempt to now+lease, so that no other dispatcher takes
+// them meanwhile and they are retried should this one not record an
+// attempt.
+func (r *webhookRepositoryImpl) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDispatch, error) {
+	query := `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at LIMIT $2 FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = $3
+		FROM due, webhook_subscriptions s, outbox e
+		WHERE d.id = due.id AND s.id = d.subscription_id AND e.id = d.event_id
+		RETURNING d.id, d.owner_id, d.subscription_id, d.event_id, d.status, d.attempts, d.created_at,
+			s.url, s.secret, e.event_type, e.payload, e.created_at`
+	rows, err := r.db.QueryContext(ctx, query, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dispatches := []*domain.WebhookDispatch{}
+	for rows.Next() {
+		dispatch := &domain.WebhookDispatch{}
+		delivery, event := &dispatch.Delivery, &dispatch.Event
+		err := rows.Scan(&delivery.ID, &delivery.OwnerID, &delivery.SubscriptionID, &delivery.EventID,
+			&delivery.Status, &delivery.Attempts, &delivery.CreatedAt,
+			&dispatch.URL, &dispatch.Secret, &event.Type, &event.Data, &event.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.EventType = event.Type
+		event.ID = delivery.EventID
+		event.OwnerID = delivery.OwnerID
+		dispatches = append(dispatches, dispatch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dispatches, nil
+}
+
+// RecordAttempt logs attempt and stores the Status, Attempts and
+// NextAttemptAt of delivery.
+func (r *webhookRepositoryImpl) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var responseStatus sql.NullInt64
+	if attempt.ResponseStatus != 0 {
+		responseStatus = sql.NullInt64{Int64: int64(attempt.ResponseStatus), Valid: true}
+	}
+	query := `INSERT INTO webhook_delivery_attempts
+		(delivery_id, attempt, attempted_at, response_status, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, delivery.ID, attempt.Attempt, attempt.AttemptedAt, responseStatus,
+		attempt.Error, attempt.DurationMS)
+	if err != nil {
+		return err
+	}
+
+	query = "UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4 WHERE id = $1"
+	result, err := tx.ExecContext(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,353 @@
+// Package rule parses the filter expressions that define the members of a
+// smart group and matches contacts against them.
+//
+// A rule compares contact fields, named as in domain.ContactFields, with
+// quoted strings and combines the comparisons with and, or, not and
+// parentheses:
+//
+//	PhoneNumber startswith "+7701" and not (Email contains "@example.com")
+//
+// = and != compare exactly; contains and startswith ignore case. Keywords
+// and field names may be written in any case. In strings, \" stands for "
+// and \\ for \.
+//
+// The pseudo-field Tag compares the contact's tags: Tag = "vip" holds if
+// one of them is "vip", Tag != "vip" if none is, and contains and
+// startswith if any tag matches.
+package rule
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// FieldTag is the pseudo-field that stands for the contact's tags.
+const FieldTag = "Tag"
+
+const (
+	OpEqual      = "="
+	OpNotEqual   = "!="
+	OpContains   = "contains"
+	OpStartsWith = "startswith"
+)
+
+const (
+	// MaxLength bounds the text of a rule.
+	MaxLength = 1000
+	// maxDepth bounds how deeply a rule nests, so that the parser's
+	// recursion and the SQL made from it stay small.
+	maxDepth = 32
+)
+
+// SyntaxError tells where in a rule parsing failed. Pos counts runes from
+// 1.
+type SyntaxError struct {
+	Pos    int
+	Reason string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at %d: %s", e.Pos, e.Reason)
+}
+
+// Expr is a parsed rule. Match evaluates it in memory, e.g. for contacts
+// that are not in a database; the repositories translate it into their
+// query language instead.
+type Expr interface {
+	Match(c *domain.Contact) bool
+}
+
+// Comparison compares Field with Value using Op.
+type Comparison struct {
+	Field string
+	Op    string
+	Value string
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+func (e *Comparison) Match(c *domain.Contact) bool {
+	if e.Field == FieldTag {
+		return e.matchTags(c.Tags)
+	}
+	value, _ := c.Field(e.Field)
+	return e.matchValue(value)
+}
+
+// matchTags holds if any tag matches, or for != if no tag is equal.
+func (e *Comparison) matchTags(tags []string) bool {
+	negated := e.Op == OpNotEqual
+	match := e
+	if negated {
+		match = &Comparison{Field: e.Field, Op: OpEqual, Value: e.Value}
+	}
+	for _, tag := range tags {
+		if match.matchValue(tag) {
+			return !negated
+		}
+	}
+	return negated
+}
+
+func (e *Comparison) matchValue(value string) bool {
+	switch e.Op {
+	case OpEqual:
+		return value == e.Value
+	case OpNotEqual:
+		return value != e.Value
+	case OpContains:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(e.Value))
+	case OpStartsWith:
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(e.Value))
+	}
+	return false
+}
+
+func (e *And) Match(c *domain.Contact) bool { return e.Left.Match(c) && e.Right.Match(c) }
+func (e *Or) Match(c *domain.Contact) bool  { return e.Left.Match(c) || e.Right.Match(c) }
+func (e *Not) Match(c *domain.Contact) bool { return !e.Expr.Match(c) }
+
+// Parse parses a rule.
+func Parse(text string) (Expr, error) {
+	if utf8.RuneCountInString(text) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength + 1, Reason: fmt.Sprintf("rule is longer than %d characters", MaxLength)}
+	}
+	p := &parser{text: []rune(text)}
+	p.next()
+	expr, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokInvalid
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of rule"
+	case tokString:
+		return "string"
+	case tokInvalid:
+		switch t.text {
+		case `"`:
+			return "unterminated string"
+		case `\`:
+			return "invalid escape in string"
+		}
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	text []rune
+	off  int
+	tok  token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Pos: p.tok.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+// next reads the token at p.off into p.tok.
+func (p *parser) next() {
+	for p.off < len(p.text) && unicode.IsSpace(p.text[p.off]) {
+		p.off++
+	}
+	start := p.off
+	p.tok = token{pos: start + 1}
+	if p.off == len(p.text) {
+		p.tok.kind = tokEOF
+		return
+	}
+
+	switch r := p.text[p.off]; {
+	case r == '(':
+		p.off++
+		p.tok.kind, p.tok.text = tokLParen, "("
+	case r == ')':
+		p.off++
+		p.tok.kind, p.tok.text = tokRParen, ")"
+	case r == '=':
+		p.off++
+		p.tok.kind, p.tok.text = tokOp, OpEqual
+	case r == '!' && p.off+1 < len(p.text) && p.text[p.off+1] == '=':
+		p.off += 2
+		p.tok.kind, p.tok.text = tokOp, OpNotEqual
+	case r == '"':
+		p.readString()
+	case unicode.IsLetter(r):
+		for p.off < len(p.text) && (unicode.IsLetter(p.text[p.off]) || unicode.IsDigit(p.text[p.off]) || p.text[p.off] == '.') {
+			p.off++
+		}
+		p.tok.kind, p.tok.text = tokWord, string(p.text[start:p.off])
+	default:
+		p.off++
+		p.tok.kind, p.tok.text = tokInvalid, string(r)
+	}
+}
+
+func (p *parser) readString() {
+	var b strings.Builder
+	for p.off++; p.off < len(p.text); p.off++ {
+		switch r := p.text[p.off]; r {
+		case '"':
+			p.off++
+			p.tok.kind, p.tok.text = tokString, b.String()
+			return
+		case '\\':
+			if p.off+1 < len(p.text) && (p.text[p.off+1] == '"' || p.text[p.off+1] == '\\') {
+				p.off++
+				b.WriteRune(p.text[p.off])
+				continue
+			}
+			p.tok.kind, p.tok.text = tokInvalid, `\`
+			return
+		default:
+			b.WriteRune(r)
+		}
+	}
+	p.tok.kind, p.tok.text = tokInvalid, `"`
+}
+
+func (p *parser) keyword(word string) bool {
+	return p.tok.kind == tokWord && strings.EqualFold(p.tok.text, word)
+}
+
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.next()
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.next()
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary(depth int) (Expr, error) {
+	if depth == maxDepth {
+		return nil, p.errorf("rule nests more than %d levels deep", maxDepth)
+	}
+	switch {
+	case p.keyword("not"):
+		p.next()
+		expr, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	case p.tok.kind == tokLParen:
+		p.next()
+		expr, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected \")\", found %s", p.tok)
+		}
+		p.next()
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	if p.tok.kind != tokWord {
+		return nil, p.errorf("expected a field, found %s", p.tok)
+	}
+	field := fieldName(p.tok.text)
+	if field == "" {
+		return nil, p.errorf("unknown field %q", p.tok.text)
+	}
+	p.next()
+
+	var op string
+	switch {
+	case p.tok.kind == tokOp:
+		op = p.tok.text
+	case p.keyword(OpContains):
+		op = OpContains
+	case p.keyword(OpStartsWith):
+		op = OpStartsWith
+	default:
+		return nil, p.errorf("expected =, !=, contains or startswith, found %s", p.tok)
+	}
+	p.next()
+
+	if p.tok.kind != tokString {
+		return nil, p.errorf("expected a quoted string, found %s", p.tok)
+	}
+	value := p.tok.text
+	p.next()
+	return &Comparison{Field: field, Op: op, Value: value}, nil
+}
+
+// fieldName returns FieldTag or the domain.ContactFields entry name stands
+// for, or "" if there is none.
+func fieldName(name string) string {
+	if strings.EqualFold(name, FieldTag) {
+		return FieldTag
+	}
+	for _, field := range domain.ContactFields {
+		if strings.EqualFold(field, name) {
+			return field
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package rule
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+func cmp(field, op, value string) *Comparison {
+	return &Comparison{Field: field, Op: op, Value: value}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule string
+		want Expr
+	}{
+		{`FullName = "Ivan"`, cmp("FullName", OpEqual, "Ivan")},
+		{`fullname != ""`, cmp("FullName", OpNotEqual, "")},
+		{`address.locality CONTAINS "alma"`, cmp("Address.Locality", OpContains, "alma")},
+		{`PhoneNumber StartsWith "+7701"`, cmp("PhoneNumber", OpStartsWith, "+7701")},
+		{`tag="vip"`, cmp(FieldTag, OpEqual, "vip")},
+		{`Email = "say \"hi\" \\ bye"`, cmp("Email", OpEqual, `say "hi" \ bye`)},
+		{`Email = "Иван@пример.рф"`, cmp("Email", OpEqual, "Иван@пример.рф")},
+		{
+			`Tag = "a" or Tag = "b" and not Tag = "c"`,
+			&Or{cmp(FieldTag, OpEqual, "a"), &And{cmp(FieldTag, OpEqual, "b"), &Not{cmp(FieldTag, OpEqual, "c")}}},
+		},
+		{
+			`(Tag = "a" OR Tag = "b") AND Tag = "c"`,
+			&And{&Or{cmp(FieldTag, OpEqual, "a"), cmp(FieldTag, OpEqual, "b")}, cmp(FieldTag, OpEqual, "c")},
+		},
+		{
+			`Tag = "a" and Tag = "b" and Tag = "c"`,
+			&And{&And{cmp(FieldTag, OpEqual, "a"), cmp(FieldTag, OpEqual, "b")}, cmp(FieldTag, OpEqual, "c")},
+		},
+		{`not not ( ( Email = "x" ) )`, &Not{&Not{cmp("Email", OpEqual, "x")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		rule   string
+		pos    int
+		reason string
+	}{
+		{``, 1, "expected a field, found end of rule"},
+		{`Nickname = "x"`, 1, `unknown field "Nickname"`},
+		{`Email "x"`, 7, "expected =, !=, contains or startswith, found string"},
+		{`Email like "x"`, 7, `expected =, !=, contains or startswith, found "like"`},
+		{`Email = x`, 9, `expected a quoted string, found "x"`},
+		{`Email = "x`, 9, "expected a quoted string, found unterminated string"},
+		{`Email = "\n"`, 9, "expected a quoted string, found invalid escape in string"},
+		{`Email = "x" Tag = "y"`, 13, `unexpected "Tag"`},
+		{`(Email = "x"`, 13, `expected ")", found end of rule`},
+		{`Email = "x")`, 12, `unexpected ")"`},
+		{`Email ! "x"`, 7, `expected =, !=, contains or startswith, found "!"`},
+		{`Email = "x" and`, 16, "expected a field, found end of rule"},
+		{`Имя = "x"`, 1, `unknown field "Имя"`},
+		{strings.Repeat("(", 40) + `Tag = "x"` + strings.Repeat(")", 40), 33, "rule nests more than 32 levels deep"},
+		{strings.Repeat("not ", 40) + `Tag = "x"`, 129, "rule nests more than 32 levels deep"},
+		{`Tag = "` + strings.Repeat("x", MaxLength) + `"`, MaxLength + 1, "rule is longer than 1000 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			expr, err := Parse(tt.rule)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse = %v, %v, want a SyntaxError", expr, err)
+			}
+			if syntaxErr.Pos != tt.pos || syntaxErr.Reason != tt.reason {
+				t.Errorf("Parse error = %q at %d, want %q at %d", syntaxErr.Reason, syntaxErr.Pos, tt.reason, tt.pos)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	contact := &domain.Contact{
+		FullName:    "Ivanov Ivan",
+		PhoneNumber: "+77011234567",
+		Email:       "IVAN@Example.com",
+		Address:     domain.Address{Locality: "Алматы"},
+		Tags:        []string{"vip", "friends"},
+	}
+	untagged := &domain.Contact{FullName: "Nobody"}
+
+	tests := []struct {
+		rule            string
+		match, untagged bool
+	}{
+		{`FullName = "Ivanov Ivan"`, true, false},
+		{`FullName = "ivanov ivan"`, false, false},
+		{`FullName != "ivanov ivan"`, true, true},
+		{`Email contains "example"`, true, false},
+		{`Email contains ""`, true, true},
+		{`Email startswith "ivan@"`, true, false},
+		{`Email startswith "example"`, false, false},
+		{`Address.Locality contains "АЛМА"`, true, false},
+		{`Address.Locality startswith "алма"`, true, false},
+		{`Patronymic = ""`, true, true},
+		{`Tag = "vip"`, true, false},
+		{`Tag = "VIP"`, false, false},
+		{`Tag != "vip"`, false, true},
+		{`Tag != "colleagues"`, true, true},
+		{`Tag contains "RIEND"`, true, false},
+		{`Tag startswith "fr"`, true, false},
+		{`not Tag startswith "fr"`, false, true},
+		{`Tag = "vip" and PhoneNumber startswith "+7701"`, true, false},
+		{`Tag = "none" or FullName = "Nobody"`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			expr, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Match(contact); got != tt.match {
+				t.Errorf("Match = %v, want %v", got, tt.match)
+			}
+			if got := expr.Match(untagged); got != tt.untagged {
+				t.Errorf("Match of a contact without tags = %v, want %v", got, tt.untagged)
+			}
+		})
+	}
+}
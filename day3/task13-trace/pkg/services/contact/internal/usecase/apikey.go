@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const maxAPIKeyNameLength = 100
+
+type apiKeyUseCaseImpl struct {
+	apiKeyRepo repository.APIKeyRepository
+	now        func() time.Time
+}
+
+func NewAPIKeyUseCase(apiKeyRepo repository.APIKeyRepository) APIKeyUseCase {
+	return &apiKeyUseCaseImpl{
+		apiKeyRepo: apiKeyRepo,
+		now:        time.Now,
+	}
+}
+
+// IssueAPIKey stores a new key for the caller's tenant from the Name,
+// Access and ExpiresAt of key and returns its plaintext, which is not
+// recoverable afterwards.
+func (uc *apiKeyUseCaseImpl) IssueAPIKey(ctx context.Context, key *auth.APIKey) (string, error) {
+	tenantID, _ := ctx.Value("tenantID").(string)
+	if tenantID == "" {
+		return "", errors.New("usecase: no tenant in context")
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+	switch {
+	case key.Name == "":
+		return "", &domain.ValidationError{Field: "Name", Reason: "is required"}
+	case len(key.Name) > maxAPIKeyNameLength:
+		return "", &domain.ValidationError{Field: "Name", Reason: "is too long"}
+	case !auth.ValidAccess(key.Access):
+		return "", &domain.ValidationError{Field: "Access", Reason: "must be " + auth.AccessReadOnly + " or " + auth.AccessReadWrite}
+	case key.ExpiresAt != nil && !key.ExpiresAt.After(uc.now()):
+		return "", &domain.ValidationError{Field: "ExpiresAt", Reason: "must be in the future"}
+	}
+
+	plaintext, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	key.TenantID = tenantID
+	key.Prefix = prefix
+	key.Hash = hash
+	key.CreatedBy, _ = ctx.Value("subject").(string)
+	key.RevokedAt = nil
+	key.LastUsedAt = nil
+
+	if err := uc.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return "", err
+	}
+	return plaintext, nil
+}
+
+func (uc *apiKeyUseCaseImpl) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	tenantID, _ := ctx.Value("tenantID").(string)
+	if tenantID == "" {
+		return nil, errors.New("usecase: no tenant in context")
+	}
+	return uc.apiKeyRepo.GetAPIKeys(ctx, tenantID)
+}
+
+func (uc *apiKeyUseCaseImpl) RevokeAPIKey(ctx context.Context, keyID string) error {
+	tenantID, _ := ctx.Value("tenantID").(string)
+	if tenantID == "" {
+		return errors.New("usecase: no tenant in context")
+	}
+	if _, err := uuid.Parse(keyID); err != nil {
+		return domain.ErrNotFound
+	}
+
+	_, err := uc.apiKeyRepo.RevokeAPIKey(ctx, tenantID, keyID)
+	return err
+}
+
+// AuthenticateAPIKey resolves a plaintext key to a usable key and records
+// that it was used. It runs before there is a principal in ctx.
+func (uc *apiKeyUseCaseImpl) AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error) {
+	if !auth.LooksLikeAPIKey(plaintext) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	key, err := uc.apiKeyRepo.GetAPIKeyByHash(ctx, auth.HashAPIKey(plaintext))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	if err := key.Check(now); err != nil {
+		return nil, err
+	}
+	if err := uc.apiKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
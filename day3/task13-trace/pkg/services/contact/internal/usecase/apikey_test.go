@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeAPIKeyRepository keeps keys in memory and, like the store behind the
+// real repository, reports missing keys as domain.ErrNotFound.
+type fakeAPIKeyRepository struct {
+	keys map[string]*auth.APIKey
+}
+
+func (r *fakeAPIKeyRepository) CreateAPIKey(ctx context.Context, key *auth.APIKey) error {
+	key.ID = uuid.NewString()
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error) {
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeAPIKeyRepository) GetAPIKeys(ctx context.Context, tenantID string) ([]*auth.APIKey, error) {
+	keys := []*auth.APIKey{}
+	for _, key := range r.keys {
+		if key.TenantID == tenantID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID, keyID string) (*auth.APIKey, error) {
+	key, ok := r.keys[keyID]
+	if !ok || key.TenantID != tenantID {
+		return nil, domain.ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		revokedAt := time.Now()
+		key.RevokedAt = &revokedAt
+	}
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepository) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	r.keys[keyID].LastUsedAt = &usedAt
+	return nil
+}
+
+var apiKeyTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestAPIKeyUseCase() (*apiKeyUseCaseImpl, *fakeAPIKeyRepository) {
+	repo := &fakeAPIKeyRepository{keys: map[string]*auth.APIKey{}}
+	uc := NewAPIKeyUseCase(repo).(*apiKeyUseCaseImpl)
+	uc.now = func() time.Time { return apiKeyTime }
+	return uc, repo
+}
+
+func TestIssueAPIKeyStoresOnlyTheHash(t *testing.T) {
+	uc, repo := newTestAPIKeyUseCase()
+	ctx := context.WithValue(tenantContext("tenant-a"), "subject", "alice")
+	plaintext, err := uc.IssueAPIKey(ctx, &auth.APIKey{Name: " CI ", Access: auth.AccessReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.keys) != 1 {
+		t.Fatalf("stored %d keys, want 1", len(repo.keys))
+	}
+	for _, key := range repo.keys {
+		if key.Hash != auth.HashAPIKey(plaintext) || strings.Contains(key.Hash, plaintext) {
+			t.Errorf("stored hash %q for key %q", key.Hash, plaintext)
+		}
+		if !strings.HasPrefix(plaintext, "ak_"+key.Prefix+"_") {
+			t.Errorf("key %q does not start with its prefix %q", plaintext, key.Prefix)
+		}
+		if key.TenantID != "tenant-a" || key.CreatedBy != "alice" || key.Name != "CI" {
+			t.Errorf("stored %+v", key)
+		}
+	}
+
+	other, err := uc.IssueAPIKey(ctx, &auth.APIKey{Name: "CI", Access: auth.AccessReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == plaintext || auth.HashAPIKey(other) == auth.HashAPIKey(plaintext) {
+		t.Errorf("two keys issued as %q and %q", plaintext, other)
+	}
+}
+
+func TestIssueAPIKeyValidation(t *testing.T) {
+	uc, repo := newTestAPIKeyUseCase()
+	ctx := tenantContext("tenant-a")
+	past, now := apiKeyTime.Add(-time.Minute), apiKeyTime
+
+	tests := []struct {
+		name  string
+		key   *auth.APIKey
+		field string
+	}{
+		{"no name", &auth.APIKey{Name: "  ", Access: auth.AccessReadOnly}, "Name"},
+		{"long name", &auth.APIKey{Name: strings.Repeat("k", maxAPIKeyNameLength+1), Access: auth.AccessReadOnly}, "Name"},
+		{"unknown access", &auth.APIKey{Name: "CI", Access: "admin"}, "Access"},
+		{"expired", &auth.APIKey{Name: "CI", Access: auth.AccessReadOnly, ExpiresAt: &past}, "ExpiresAt"},
+		{"expiring now", &auth.APIKey{Name: "CI", Access: auth.AccessReadOnly, ExpiresAt: &now}, "ExpiresAt"},
+	}
+	for _, tt := range tests {
+		_, err := uc.IssueAPIKey(ctx, tt.key)
+		var validation *domain.ValidationError
+		if !errors.As(err, &validation) || validation.Field != tt.field {
+			t.Errorf("%s: IssueAPIKey = %v, want a validation error on %s", tt.name, err, tt.field)
+		}
+	}
+	if len(repo.keys) != 0 {
+		t.Errorf("stored %d invalid keys", len(repo.keys))
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	uc, repo := newTestAPIKeyUseCase()
+	ctx := tenantContext("tenant-a")
+	expiresAt := apiKeyTime.Add(time.Hour)
+	plaintext, err := uc.IssueAPIKey(ctx, &auth.APIKey{Name: "CI", Access: auth.AccessReadWrite, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := uc.AuthenticateAPIKey(context.Background(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.TenantID != "tenant-a" || key.Access != auth.AccessReadWrite {
+		t.Errorf("AuthenticateAPIKey = %+v", key)
+	}
+	if used := repo.keys[key.ID].LastUsedAt; used == nil || !used.Equal(apiKeyTime) {
+		t.Errorf("LastUsedAt = %v, want %v", used, apiKeyTime)
+	}
+
+	for _, unknown := range []string{plaintext + "x", "ak_" + strings.Repeat("0", 8) + "_secret", "eyJhbGciOiJIUzI1NiJ9", ""} {
+		if _, err := uc.AuthenticateAPIKey(context.Background(), unknown); !errors.Is(err, auth.ErrInvalidAPIKey) {
+			t.Errorf("AuthenticateAPIKey(%q) = %v, want ErrInvalidAPIKey", unknown, err)
+		}
+	}
+
+	uc.now = func() time.Time { return expiresAt }
+	if _, err := uc.AuthenticateAPIKey(context.Background(), plaintext); !errors.Is(err, auth.ErrAPIKeyExpired) {
+		t.Errorf("AuthenticateAPIKey at expiry = %v, want ErrAPIKeyExpired", err)
+	}
+	if used := repo.keys[key.ID].LastUsedAt; !used.Equal(apiKeyTime) {
+		t.Errorf("an expired key was marked used at %v", used)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	uc, repo := newTestAPIKeyUseCase()
+	ctx := tenantContext("tenant-a")
+	plaintext, err := uc.IssueAPIKey(ctx, &auth.APIKey{Name: "CI", Access: auth.AccessReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyID string
+	for id := range repo.keys {
+		keyID = id
+	}
+
+	for name, err := range map[string]error{
+		"another tenant's key": uc.RevokeAPIKey(tenantContext("tenant-b"), keyID),
+		"a missing key":        uc.RevokeAPIKey(ctx, uuid.NewString()),
+		"a malformed ID":       uc.RevokeAPIKey(ctx, "key-1"),
+	} {
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("revoking %s = %v, want ErrNotFound", name, err)
+		}
+	}
+	if _, err := uc.AuthenticateAPIKey(context.Background(), plaintext); err != nil {
+		t.Fatalf("key revoked by another tenant: %v", err)
+	}
+
+	if err := uc.RevokeAPIKey(ctx, keyID); err != nil {
+		t.Fatal(err)
+	}
+	revokedAt := *repo.keys[keyID].RevokedAt
+	if err := uc.RevokeAPIKey(ctx, keyID); err != nil {
+		t.Errorf("revoking twice: %v", err)
+	}
+	if !repo.keys[keyID].RevokedAt.Equal(revokedAt) {
+		t.Errorf("revoking twice moved RevokedAt from %v to %v", revokedAt, repo.keys[keyID].RevokedAt)
+	}
+	if _, err := uc.AuthenticateAPIKey(context.Background(), plaintext); !errors.Is(err, auth.ErrAPIKeyRevoked) {
+		t.Errorf("AuthenticateAPIKey after revoking = %v, want ErrAPIKeyRevoked", err)
+	}
+}
@@ -0,0 +1,651 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go/pkg/auth"
+	"go/pkg/services/contact/internal/domain"
+)
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// roleRank orders roles; every role includes the permissions of the ones
+// ranked below it.
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+type Operation string
+
+const (
+	OpCreateContact        Operation = "CreateContact"
+	OpCreateContacts       Operation = "CreateContacts"
+	OpUpdateContact        Operation = "UpdateContact"
+	OpDeleteContact        Operation = "DeleteContact"
+	OpGetContactByID       Operation = "GetContactByID"
+	OpLookupContactByPhone Operation = "LookupContactByPhone"
+	OpGetAllContacts       Operation = "GetAllContacts"
+	OpFindContacts         Operation = "FindContacts"
+	OpFindDuplicates       Operation = "FindDuplicates"
+	OpMergeContacts        Operation = "MergeContacts"
+	OpGetUpcomingEvents    Operation = "GetUpcomingEvents"
+	OpDefineCustomField    Operation = "DefineCustomField"
+	OpGetCustomFields      Operation = "GetCustomFields"
+	OpDeleteCustomField    Operation = "DeleteCustomField"
+	OpSetAvatar            Operation = "SetAvatar"
+	OpGetAvatar            Operation = "GetAvatar"
+	OpDeleteAvatar         Operation = "DeleteAvatar"
+	OpAddNote              Operation = "AddNote"
+	OpGetNotes             Operation = "GetNotes"
+	OpGetHistory           Operation = "GetHistory"
+	OpCreateGroup          Operation = "CreateGroup"
+	OpUpdateGroup          Operation = "UpdateGroup"
+	OpDeleteGroup          Operation = "DeleteGroup"
+	OpGetGroupByID         Operation = "GetGroupByID"
+	OpGetAllGroups         Operation = "GetAllGroups"
+	OpGetGroupContacts     Operation = "GetGroupContacts"
+	OpAddContactToGroup    Operation = "AddContactToGroup"
+	OpGetGroupSubtree      Operation = "GetGroupSubtree"
+	OpGetEffectiveMembers  Operation = "GetEffectiveMembers"
+	OpIssueAPIKey          Operation = "IssueAPIKey"
+	OpGetAPIKeys           Operation = "GetAPIKeys"
+	OpRevokeAPIKey         Operation = "RevokeAPIKey"
+	OpCreateWebhook        Operation = "CreateWebhook"
+	OpGetWebhooks          Operation = "GetWebhooks"
+	OpDeleteWebhook        Operation = "DeleteWebhook"
+	OpGetWebhookDeliveries Operation = "GetWebhookDeliveries"
+	OpGetChanges           Operation = "GetChanges"
+	OpSyncContacts         Operation = "SyncContacts"
+)
+
+// DefaultPolicy is the least role each operation needs.
+var DefaultPolicy = map[Operation]Role{
+	OpGetContactByID:       RoleViewer,
+	OpLookupContactByPhone: RoleViewer,
+	OpGetAllContacts:       RoleViewer,
+	OpFindContacts:         RoleViewer,
+	OpFindDuplicates:       RoleViewer,
+	OpGetUpcomingEvents:    RoleViewer,
+	OpGetCustomFields:      RoleViewer,
+	OpGetAvatar:            RoleViewer,
+	OpGetNotes:             RoleViewer,
+	OpGetHistory:           RoleViewer,
+	OpGetGroupByID:         RoleViewer,
+	OpGetAllGroups:         RoleViewer,
+	OpGetGroupContacts:     RoleViewer,
+	OpGetGroupSubtree:      RoleViewer,
+	OpGetEffectiveMembers:  RoleViewer,
+	OpGetChanges:           RoleViewer,
+	OpCreateContact:        RoleEditor,
+	OpCreateContacts:       RoleEditor,
+	OpUpdateContact:        RoleEditor,
+	OpDeleteContact:        RoleEditor,
+	OpSetAvatar:            RoleEditor,
+	OpDeleteAvatar:         RoleEditor,
+	OpAddNote:              RoleEditor,
+	OpCreateGroup:          RoleEditor,
+	OpUpdateGroup:          RoleEditor,
+	OpAddContactToGroup:    RoleEditor,
+	OpSyncContacts:         RoleEditor,
+	OpMergeContacts:        RoleAdmin,
+	OpDefineCustomField:    RoleAdmin,
+	OpDeleteCustomField:    RoleAdmin,
+	OpDeleteGroup:          RoleAdmin,
+	OpIssueAPIKey:          RoleAdmin,
+	OpGetAPIKeys:           RoleAdmin,
+	OpRevokeAPIKey:         RoleAdmin,
+	OpCreateWebhook:        RoleAdmin,
+	OpGetWebhooks:          RoleAdmin,
+	OpDeleteWebhook:        RoleAdmin,
+	OpGetWebhookDeliveries: RoleAdmin,
+}
+
+// sharedGroupOperations are allowed on a group shared with a principal
+// regardless of its roles. Sharing a group does not share the groups below
+// it, so the hierarchy operations are not among them.
+var sharedGroupOperations = map[Operation]bool{
+	OpGetGroupByID:     true,
+	OpGetAllGroups:     true,
+	OpGetGroupContacts: true,
+}
+
+// Grant is what a principal holds: roles within its tenant and groups
+// shared with it read-only.
+type Grant struct {
+	Roles        []Role
+	SharedGroups []string
+}
+
+// AccessConfig is the JSON file read by LoadAccessConfig, e.g.
+//
+//	{
+//	  "DefaultRoles": ["viewer"],
+//	  "Subjects": {
+//	    "alice": {"Roles": ["admin"]},
+//	    "partner": {"SharedGroups": ["6f1c..."]}
+//	  },
+//	  "Policy": {"DeleteContact": "admin"}
+//	}
+//
+// Grants from the file add to the ones carried by the token. Policy entries
+// override DefaultPolicy.
+type AccessConfig struct {
+	DefaultRoles []Role
+	Subjects     map[string]Grant
+	Policy       map[Operation]Role
+}
+
+func LoadAccessConfig(path string) (*AccessConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &AccessConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("access config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Authorizer checks the principal of a request, taken from the "subject",
+// "roles" and "sharedGroups" context values set by the delivery layer,
+// against the policy.
+type Authorizer struct {
+	policy       map[Operation]Role
+	defaultRoles []Role
+	subjects     map[string]Grant
+}
+
+func NewAuthorizer(config *AccessConfig) (*Authorizer, error) {
+	if config == nil {
+		config = &AccessConfig{}
+	}
+
+	a := &Authorizer{
+		policy:       make(map[Operation]Role, len(DefaultPolicy)),
+		defaultRoles: config.DefaultRoles,
+		subjects:     config.Subjects,
+	}
+	for op, role := range DefaultPolicy {
+		a.policy[op] = role
+	}
+	for op, role := range config.Policy {
+		if _, ok := DefaultPolicy[op]; !ok {
+			return nil, fmt.Errorf("access config: unknown operation %q", op)
+		}
+		a.policy[op] = role
+	}
+
+	roles := append([]Role{}, config.DefaultRoles...)
+	for _, role := range a.policy {
+		roles = append(roles, role)
+	}
+	for _, grant := range config.Subjects {
+		roles = append(roles, grant.Roles...)
+	}
+	for _, role := range roles {
+		if roleRank[role] == 0 {
+			return nil, fmt.Errorf("access config: unknown role %q", role)
+		}
+	}
+	return a, nil
+}
+
+func (a *Authorizer) grant(ctx context.Context) Grant {
+	subject, _ := ctx.Value("subject").(string)
+	roles, _ := ctx.Value("roles").([]string)
+	shared, _ := ctx.Value("sharedGroups").([]string)
+
+	g := Grant{SharedGroups: shared}
+	for _, role := range roles {
+		g.Roles = append(g.Roles, Role(role))
+	}
+	// API keys carry exactly the role of their access level; default and
+	// configured roles would let a read-only key write.
+	if apiKeyID, _ := ctx.Value("apiKeyID").(string); subject == "" || apiKeyID != "" {
+		return g
+	}
+	g.Roles = append(g.Roles, a.defaultRoles...)
+	if configured, ok := a.subjects[subject]; ok {
+		g.Roles = append(g.Roles, configured.Roles...)
+		g.SharedGroups = append(g.SharedGroups, configured.SharedGroups...)
+	}
+	return g
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, op Operation) error {
+	return a.authorize(op, a.grant(ctx))
+}
+
+func (a *Authorizer) authorize(op Operation, g Grant) error {
+	required, ok := a.policy[op]
+	if !ok {
+		return fmt.Errorf("%w: no policy for %s", domain.ErrForbidden, op)
+	}
+	for _, role := range g.Roles {
+		if roleRank[role] >= roleRank[required] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s requires the %s role", domain.ErrForbidden, op, required)
+}
+
+// AuthorizeGroup is Authorize for an operation on a single group, which
+// also succeeds for read operations on a group shared with the principal.
+func (a *Authorizer) AuthorizeGroup(ctx context.Context, op Operation, groupID string) error {
+	g := a.grant(ctx)
+	err := a.authorize(op, g)
+	if err == nil || !sharedGroupOperations[op] {
+		return err
+	}
+	for _, shared := range g.SharedGroups {
+		if shared == groupID {
+			return nil
+		}
+	}
+	return err
+}
+
+// The authorized use cases wrap every method explicitly rather than embed
+// the interface, so a method added later cannot skip the policy unnoticed.
+type authorizedContactUseCase struct {
+	useCase ContactUseCase
+	authz   *Authorizer
+}
+
+// NewAuthorizedContactUseCase checks every operation of useCase against the
+// authorizer before running it. ValidateContact needs no permission.
+func NewAuthorizedContactUseCase(useCase ContactUseCase, authz *Authorizer) ContactUseCase {
+	return &authorizedContactUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedContactUseCase) ValidateContact(ctx context.Context, contact *domain.Contact) error {
+	return uc.useCase.ValidateContact(ctx, contact)
+}
+
+func (uc *authorizedContactUseCase) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := uc.authz.Authorize(ctx, OpCreateContact); err != nil {
+		return err
+	}
+	return uc.useCase.CreateContact(ctx, contact)
+}
+
+func (uc *authorizedContactUseCase) CreateContacts(ctx context.Context, contacts []*domain.Contact) error {
+	if err := uc.authz.Authorize(ctx, OpCreateContacts); err != nil {
+		return err
+	}
+	return uc.useCase.CreateContacts(ctx, contacts)
+}
+
+func (uc *authorizedContactUseCase) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := uc.authz.Authorize(ctx, OpUpdateContact); err != nil {
+		return err
+	}
+	return uc.useCase.UpdateContact(ctx, contact)
+}
+
+func (uc *authorizedContactUseCase) DeleteContact(ctx context.Context, contactID string) error {
+	if err := uc.authz.Authorize(ctx, OpDeleteContact); err != nil {
+		return err
+	}
+	return uc.useCase.DeleteContact(ctx, contactID)
+}
+
+func (uc *authorizedContactUseCase) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	if err := uc.authz.Authorize(ctx, OpGetContactByID); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetContactByID(ctx, contactID)
+}
+
+func (uc *authorizedContactUseCase) LookupContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error) {
+	if err := uc.authz.Authorize(ctx, OpLookupContactByPhone); err != nil {
+		return nil, err
+	}
+	return uc.useCase.LookupContactByPhone(ctx, phoneNumber)
+}
+
+func (uc *authorizedContactUseCase) GetAllContacts(ctx context.Context) ([]*domain.Contact, error) {
+	if err := uc.authz.Authorize(ctx, OpGetAllContacts); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetAllContacts(ctx)
+}
+
+func (uc *authorizedContactUseCase) FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error) {
+	if err := uc.authz.Authorize(ctx, OpFindContacts); err != nil {
+		return nil, err
+	}
+	return uc.useCase.FindContacts(ctx, filter)
+}
+
+func (uc *authorizedContactUseCase) FindDuplicates(ctx context.Context) ([]*domain.DuplicateGroup, error) {
+	if err := uc.authz.Authorize(ctx, OpFindDuplicates); err != nil {
+		return nil, err
+	}
+	return uc.useCase.FindDuplicates(ctx)
+}
+
+func (uc *authorizedContactUseCase) MergeContacts(ctx context.Context, survivorID string, duplicateIDs []string) (*domain.Contact, error) {
+	if err := uc.authz.Authorize(ctx, OpMergeContacts); err != nil {
+		return nil, err
+	}
+	return uc.useCase.MergeContacts(ctx, survivorID, duplicateIDs)
+}
+
+func (uc *authorizedContactUseCase) GetUpcomingEvents(ctx context.Context, days int, loc *time.Location) ([]domain.UpcomingEvent, error) {
+	if err := uc.authz.Authorize(ctx, OpGetUpcomingEvents); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetUpcomingEvents(ctx, days, loc)
+}
+
+type authorizedCustomFieldUseCase struct {
+	useCase CustomFieldUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedCustomFieldUseCase(useCase CustomFieldUseCase, authz *Authorizer) CustomFieldUseCase {
+	return &authorizedCustomFieldUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedCustomFieldUseCase) DefineCustomField(ctx context.Context, field *domain.CustomField) error {
+	if err := uc.authz.Authorize(ctx, OpDefineCustomField); err != nil {
+		return err
+	}
+	return uc.useCase.DefineCustomField(ctx, field)
+}
+
+func (uc *authorizedCustomFieldUseCase) GetCustomFields(ctx context.Context) ([]*domain.CustomField, error) {
+	if err := uc.authz.Authorize(ctx, OpGetCustomFields); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetCustomFields(ctx)
+}
+
+func (uc *authorizedCustomFieldUseCase) DeleteCustomField(ctx context.Context, name string) error {
+	if err := uc.authz.Authorize(ctx, OpDeleteCustomField); err != nil {
+		return err
+	}
+	return uc.useCase.DeleteCustomField(ctx, name)
+}
+
+type authorizedAvatarUseCase struct {
+	useCase AvatarUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedAvatarUseCase(useCase AvatarUseCase, authz *Authorizer) AvatarUseCase {
+	return &authorizedAvatarUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedAvatarUseCase) SetAvatar(ctx context.Context, contactID string, data []byte) (*domain.Avatar, error) {
+	if err := uc.authz.Authorize(ctx, OpSetAvatar); err != nil {
+		return nil, err
+	}
+	return uc.useCase.SetAvatar(ctx, contactID, data)
+}
+
+func (uc *authorizedAvatarUseCase) GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error) {
+	if err := uc.authz.Authorize(ctx, OpGetAvatar); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetAvatar(ctx, contactID)
+}
+
+func (uc *authorizedAvatarUseCase) OpenAvatar(ctx context.Context, avatar *domain.Avatar, size string) (io.ReadCloser, error) {
+	if err := uc.authz.Authorize(ctx, OpGetAvatar); err != nil {
+		return nil, err
+	}
+	return uc.useCase.OpenAvatar(ctx, avatar, size)
+}
+
+func (uc *authorizedAvatarUseCase) DeleteAvatar(ctx context.Context, contactID string) error {
+	if err := uc.authz.Authorize(ctx, OpDeleteAvatar); err != nil {
+		return err
+	}
+	return uc.useCase.DeleteAvatar(ctx, contactID)
+}
+
+type authorizedNoteUseCase struct {
+	useCase NoteUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedNoteUseCase(useCase NoteUseCase, authz *Authorizer) NoteUseCase {
+	return &authorizedNoteUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedNoteUseCase) AddNote(ctx context.Context, note *domain.Note) error {
+	if err := uc.authz.Authorize(ctx, OpAddNote); err != nil {
+		return err
+	}
+	return uc.useCase.AddNote(ctx, note)
+}
+
+func (uc *authorizedNoteUseCase) GetNotes(ctx context.Context, contactID, noteType, cursor string, limit int) (*domain.NotePage, error) {
+	if err := uc.authz.Authorize(ctx, OpGetNotes); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetNotes(ctx, contactID, noteType, cursor, limit)
+}
+
+func (uc *authorizedNoteUseCase) GetHistory(ctx context.Context, contactID, cursor string, limit int) (*domain.HistoryPage, error) {
+	if err := uc.authz.Authorize(ctx, OpGetHistory); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetHistory(ctx, contactID, cursor, limit)
+}
+
+type authorizedGroupUseCase struct {
+	useCase GroupUseCase
+	authz   *Authorizer
+}
+
+// NewAuthorizedGroupUseCase checks every operation of useCase against the
+// authorizer before running it. Principals holding shared groups but no
+// viewer role see only those groups and their contacts.
+func NewAuthorizedGroupUseCase(useCase GroupUseCase, authz *Authorizer) GroupUseCase {
+	return &authorizedGroupUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedGroupUseCase) CreateGroup(ctx context.Context, group *domain.Group) error {
+	if err := uc.authz.Authorize(ctx, OpCreateGroup); err != nil {
+		return err
+	}
+	return uc.useCase.CreateGroup(ctx, group)
+}
+
+func (uc *authorizedGroupUseCase) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	if err := uc.authz.AuthorizeGroup(ctx, OpUpdateGroup, group.ID); err != nil {
+		return err
+	}
+	return uc.useCase.UpdateGroup(ctx, group)
+}
+
+func (uc *authorizedGroupUseCase) DeleteGroup(ctx context.Context, groupID string) error {
+	if err := uc.authz.AuthorizeGroup(ctx, OpDeleteGroup, groupID); err != nil {
+		return err
+	}
+	return uc.useCase.DeleteGroup(ctx, groupID)
+}
+
+func (uc *authorizedGroupUseCase) GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error) {
+	if err := uc.authz.AuthorizeGroup(ctx, OpGetGroupByID, groupID); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetGroupByID(ctx, groupID)
+}
+
+func (uc *authorizedGroupUseCase) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
+	g := uc.authz.grant(ctx)
+	err := uc.authz.authorize(OpGetAllGroups, g)
+	if err == nil {
+		return uc.useCase.GetAllGroups(ctx)
+	}
+	if len(g.SharedGroups) == 0 {
+		return nil, err
+	}
+
+	shared := make(map[string]bool, len(g.SharedGroups))
+	for _, id := range g.SharedGroups {
+		shared[id] = true
+	}
+	groups, err := uc.useCase.GetAllGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := []*domain.Group{}
+	for _, group := range groups {
+		if shared[group.ID] {
+			visible = append(visible, group)
+		}
+	}
+	return visible, nil
+}
+
+func (uc *authorizedGroupUseCase) GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	if err := uc.authz.AuthorizeGroup(ctx, OpGetGroupContacts, groupID); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetGroupContacts(ctx, groupID)
+}
+
+func (uc *authorizedGroupUseCase) AddContactToGroup(ctx context.Context, contactID, groupID string) error {
+	if err := uc.authz.AuthorizeGroup(ctx, OpAddContactToGroup, groupID); err != nil {
+		return err
+	}
+	return uc.useCase.AddContactToGroup(ctx, contactID, groupID)
+}
+
+func (uc *authorizedGroupUseCase) GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error) {
+	if err := uc.authz.AuthorizeGroup(ctx, OpGetGroupSubtree, groupID); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetGroupSubtree(ctx, groupID)
+}
+
+func (uc *authorizedGroupUseCase) GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	if err := uc.authz.AuthorizeGroup(ctx, OpGetEffectiveMembers, groupID); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetEffectiveMembers(ctx, groupID)
+}
+
+type authorizedAPIKeyUseCase struct {
+	useCase APIKeyUseCase
+	authz   *Authorizer
+}
+
+// NewAuthorizedAPIKeyUseCase leaves AuthenticateAPIKey unchecked, as it is
+// how a principal comes about in the first place.
+func NewAuthorizedAPIKeyUseCase(useCase APIKeyUseCase, authz *Authorizer) APIKeyUseCase {
+	return &authorizedAPIKeyUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedAPIKeyUseCase) IssueAPIKey(ctx context.Context, key *auth.APIKey) (string, error) {
+	if err := uc.authz.Authorize(ctx, OpIssueAPIKey); err != nil {
+		return "", err
+	}
+	return uc.useCase.IssueAPIKey(ctx, key)
+}
+
+func (uc *authorizedAPIKeyUseCase) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	if err := uc.authz.Authorize(ctx, OpGetAPIKeys); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetAPIKeys(ctx)
+}
+
+func (uc *authorizedAPIKeyUseCase) RevokeAPIKey(ctx context.Context, keyID string) error {
+	if err := uc.authz.Authorize(ctx, OpRevokeAPIKey); err != nil {
+		return err
+	}
+	return uc.useCase.RevokeAPIKey(ctx, keyID)
+}
+
+func (uc *authorizedAPIKeyUseCase) AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error) {
+	return uc.useCase.AuthenticateAPIKey(ctx, plaintext)
+}
+
+type authorizedWebhookUseCase struct {
+	useCase WebhookUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedWebhookUseCase(useCase WebhookUseCase, authz *Authorizer) WebhookUseCase {
+	return &authorizedWebhookUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedWebhookUseCase) CreateWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := uc.authz.Authorize(ctx, OpCreateWebhook); err != nil {
+		return err
+	}
+	return uc.useCase.CreateWebhook(ctx, subscription)
+}
+
+func (uc *authorizedWebhookUseCase) GetWebhooks(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	if err := uc.authz.Authorize(ctx, OpGetWebhooks); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetWebhooks(ctx)
+}
+
+func (uc *authorizedWebhookUseCase) DeleteWebhook(ctx context.Context, subscriptionID string) error {
+	if err := uc.authz.Authorize(ctx, OpDeleteWebhook); err != nil {
+		return err
+	}
+	return uc.useCase.DeleteWebhook(ctx, subscriptionID)
+}
+
+func (uc *authorizedWebhookUseCase) GetWebhookDeliveries(ctx context.Context, subscriptionID, status string) ([]*domain.WebhookDelivery, error) {
+	if err := uc.authz.Authorize(ctx, OpGetWebhookDeliveries); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetWebhookDeliveries(ctx, subscriptionID, status)
+}
+
+type authorizedChangeUseCase struct {
+	useCase ChangeUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedChangeUseCase(useCase ChangeUseCase, authz *Authorizer) ChangeUseCase {
+	return &authorizedChangeUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedChangeUseCase) GetChanges(ctx context.Context, since int64, limit int, wait time.Duration) ([]*domain.Change, error) {
+	if err := uc.authz.Authorize(ctx, OpGetChanges); err != nil {
+		return nil, err
+	}
+	return uc.useCase.GetChanges(ctx, since, limit, wait)
+}
+
+type authorizedSyncUseCase struct {
+	useCase SyncUseCase
+	authz   *Authorizer
+}
+
+func NewAuthorizedSyncUseCase(useCase SyncUseCase, authz *Authorizer) SyncUseCase {
+	return &authorizedSyncUseCase{useCase: useCase, authz: authz}
+}
+
+func (uc *authorizedSyncUseCase) Sync(ctx context.Context, request *domain.SyncRequest) (*domain.SyncResponse, error) {
+	if err := uc.authz.Authorize(ctx, OpSyncContacts); err != nil {
+		return nil, err
+	}
+	return uc.useCase.Sync(ctx, request)
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// principalContext sets the context values the delivery layer sets for a
+// principal of tenant-a.
+func principalContext(subject string, roles, sharedGroups []string, apiKeyID string) context.Context {
+	ctx := tenantContext("tenant-a")
+	ctx = context.WithValue(ctx, "subject", subject)
+	ctx = context.WithValue(ctx, "roles", roles)
+	ctx = context.WithValue(ctx, "sharedGroups", sharedGroups)
+	if apiKeyID != "" {
+		ctx = context.WithValue(ctx, "apiKeyID", apiKeyID)
+	}
+	return ctx
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	// want is the least role each operation needs, written out rather than
+	// derived so that a change to DefaultPolicy shows up here.
+	want := map[Operation]Role{}
+	for role, ops := range map[Role][]Operation{
+		RoleViewer: {
+			OpGetContactByID, OpLookupContactByPhone, OpGetAllContacts, OpFindContacts, OpFindDuplicates,
+			OpGetUpcomingEvents, OpGetCustomFields, OpGetAvatar, OpGetNotes, OpGetHistory, OpGetGroupByID,
+			OpGetAllGroups, OpGetGroupContacts, OpGetGroupSubtree, OpGetEffectiveMembers, OpGetChanges,
+		},
+		RoleEditor: {
+			OpCreateContact, OpCreateContacts, OpUpdateContact, OpDeleteContact, OpSetAvatar, OpDeleteAvatar,
+			OpAddNote, OpCreateGroup, OpUpdateGroup, OpAddContactToGroup, OpSyncContacts,
+		},
+		RoleAdmin: {
+			OpMergeContacts, OpDefineCustomField, OpDeleteCustomField, OpDeleteGroup, OpIssueAPIKey,
+			OpGetAPIKeys, OpRevokeAPIKey, OpCreateWebhook, OpGetWebhooks, OpDeleteWebhook, OpGetWebhookDeliveries,
+		},
+	} {
+		for _, op := range ops {
+			want[op] = role
+		}
+	}
+	for op := range DefaultPolicy {
+		if _, ok := want[op]; !ok {
+			t.Errorf("%s is in DefaultPolicy but not in this test", op)
+		}
+	}
+
+	authz, err := NewAuthorizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := []Role{"", RoleViewer, RoleEditor, RoleAdmin}
+	for op, least := range want {
+		for _, role := range roles {
+			ctx := principalContext("user-1", []string{string(role)}, nil, "")
+			err := authz.Authorize(ctx, op)
+			allowed := role != "" && roleRank[role] >= roleRank[least]
+			if allowed && err != nil || !allowed && !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("Authorize(%s) as %q = %v, want allowed %v", op, role, err, allowed)
+			}
+		}
+	}
+}
+
+func TestAccessConfig(t *testing.T) {
+	authz, err := NewAuthorizer(&AccessConfig{
+		DefaultRoles: []Role{RoleViewer},
+		Subjects:     map[string]Grant{"alice": {Roles: []Role{RoleAdmin}}, "apikey:key-1": {Roles: []Role{RoleAdmin}}},
+		Policy:       map[Operation]Role{OpDeleteContact: RoleAdmin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		op      Operation
+		allowed bool
+	}{
+		{"default role", principalContext("bob", nil, nil, ""), OpGetAllContacts, true},
+		{"default role only", principalContext("bob", nil, nil, ""), OpCreateContact, false},
+		{"token role", principalContext("bob", []string{"editor"}, nil, ""), OpCreateContact, true},
+		{"policy override", principalContext("bob", []string{"editor"}, nil, ""), OpDeleteContact, false},
+		{"configured subject", principalContext("alice", nil, nil, ""), OpDeleteContact, true},
+		{"no subject gets no default role", principalContext("", nil, nil, ""), OpGetAllContacts, false},
+		// API keys keep the role of their access level, whatever the file
+		// says about their subject or everyone.
+		{"read-only API key reads", principalContext("apikey:key-1", []string{"viewer"}, nil, "key-1"), OpGetAllContacts, true},
+		{"read-only API key writes", principalContext("apikey:key-1", []string{"viewer"}, nil, "key-1"), OpCreateContact, false},
+		{"read-write API key writes", principalContext("apikey:key-2", []string{"editor"}, nil, "key-2"), OpCreateContact, true},
+		{"read-write API key administers", principalContext("apikey:key-1", []string{"editor"}, nil, "key-1"), OpIssueAPIKey, false},
+		{"unknown token role", principalContext("carol", []string{"owner"}, nil, ""), OpCreateContact, false},
+	}
+	for _, tt := range tests {
+		err := authz.Authorize(tt.ctx, tt.op)
+		if tt.allowed && err != nil || !tt.allowed && !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("%s: Authorize(%s) = %v, want allowed %v", tt.name, tt.op, err, tt.allowed)
+		}
+	}
+
+	for _, config := range []*AccessConfig{
+		{Policy: map[Operation]Role{"DropDatabase": RoleAdmin}},
+		{Policy: map[Operation]Role{OpDeleteContact: "owner"}},
+		{DefaultRoles: []Role{"owner"}},
+		{Subjects: map[string]Grant{"alice": {Roles: []Role{"owner"}}}},
+	} {
+		if _, err := NewAuthorizer(config); err == nil {
+			t.Errorf("NewAuthorizer(%+v) succeeded", config)
+		}
+	}
+}
+
+func TestSharedGroupAccess(t *testing.T) {
+	authz, err := NewAuthorizer(&AccessConfig{Subjects: map[string]Grant{"partner-2": {SharedGroups: []string{groupB}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contacts := newFakeContactRepository(&domain.Contact{ID: contactA, OwnerID: "tenant-a", FullName: "Ada"})
+	groups := newFakeGroupRepository(contacts,
+		&domain.Group{ID: groupA, OwnerID: "tenant-a", Name: "Family"},
+		&domain.Group{ID: groupB, OwnerID: "tenant-a", Name: "Work"},
+	)
+	groups.members[groupA] = []string{contactA}
+	groupUseCase := NewAuthorizedGroupUseCase(NewGroupUseCase(groups, contacts), authz)
+	contactUseCase := NewAuthorizedContactUseCase(NewContactUseCase(contacts, nil, nil), authz)
+
+	// partner-2 has groupB shared in the access config as well.
+	for _, tt := range []struct {
+		ctx     context.Context
+		visible []string
+	}{
+		{principalContext("partner", nil, []string{groupA}, ""), []string{groupA}},
+		{principalContext("partner-2", nil, []string{groupA}, ""), []string{groupA, groupB}},
+	} {
+		if group, err := groupUseCase.GetGroupByID(tt.ctx, groupA); err != nil || group.ID != groupA {
+			t.Errorf("GetGroupByID(shared) = %v, %v", group, err)
+		}
+		if members, err := groupUseCase.GetGroupContacts(tt.ctx, groupA); err != nil || len(members) != 1 {
+			t.Errorf("GetGroupContacts(shared) = %v, %v", members, err)
+		}
+		all, err := groupUseCase.GetAllGroups(tt.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := map[string]bool{}
+		for _, group := range all {
+			ids[group.ID] = true
+		}
+		if len(ids) != len(tt.visible) {
+			t.Errorf("GetAllGroups = %v, want %v", ids, tt.visible)
+		}
+		for _, id := range tt.visible {
+			if !ids[id] {
+				t.Errorf("GetAllGroups = %v, want %v", ids, tt.visible)
+			}
+		}
+	}
+
+	ctx := principalContext("partner", nil, []string{groupA}, "")
+	forbidden := map[string]error{}
+	_, forbidden["GetGroupByID(other)"] = groupUseCase.GetGroupByID(ctx, groupB)
+	_, forbidden["GetGroupContacts(other)"] = groupUseCase.GetGroupContacts(ctx, groupB)
+	_, forbidden["GetGroupSubtree(shared)"] = groupUseCase.GetGroupSubtree(ctx, groupA)
+	_, forbidden["GetEffectiveMembers(shared)"] = groupUseCase.GetEffectiveMembers(ctx, groupA)
+	forbidden["AddContactToGroup(shared)"] = groupUseCase.AddContactToGroup(ctx, contactA, groupA)
+	forbidden["UpdateGroup(shared)"] = groupUseCase.UpdateGroup(ctx, &domain.Group{ID: groupA, Name: "Mine"})
+	_, forbidden["GetContactByID"] = contactUseCase.GetContactByID(ctx, contactA)
+	_, forbidden["GetAllContacts"] = contactUseCase.GetAllContacts(ctx)
+	for call, err := range forbidden {
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("%s as a shared-group principal = %v, want ErrForbidden", call, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+
+	"go/pkg/blob"
+	"go/pkg/services/contact/internal/avatar"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type avatarUseCaseImpl struct {
+	avatarRepo  repository.AvatarRepository
+	contactRepo repository.ContactRepository
+	blobs       blob.Store
+}
+
+// NewAvatarUseCase keeps the photos in blobs. A new photo is stored under
+// new keys before the contact switches to it, so readers never see half of
+// an upload. Deleting a contact drops its avatar record and queues the
+// blobs for avatar.Sweeper.
+func NewAvatarUseCase(avatarRepo repository.AvatarRepository, contactRepo repository.ContactRepository, blobs blob.Store) AvatarUseCase {
+	return &avatarUseCaseImpl{
+		avatarRepo:  avatarRepo,
+		contactRepo: contactRepo,
+		blobs:       blobs,
+	}
+}
+
+// SetAvatar stores data as the photo of a contact of the request's tenant.
+// Blob keys do not name the tenant, so the contact is looked up before
+// anything is decoded or written.
+
+
+func (uc *avatarUseCaseImpl) SetAvatar(ctx context.Context, contactID string, data []byte) (*domain.Avatar, error) {
+	id, err := uuid.Parse(contactID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	if _, err := uc.contactRepo.GetContactByID(ctx, id.String()); err != nil {
+		return nil, err
+	}
+	if len(data) > avatar.MaxUploadSize {
+		return nil, &domain.ValidationError{Field: "image", Reason: "must be at most " + strconv.Itoa(avatar.MaxUploadSize>>20) + " MiB"}
+	}
+	img, err := avatar.Decode(data)
+	if errors.Is(err, avatar.ErrTooLarge) {
+		return nil, &domain.ValidationError{Field: "image", Reason: "must have at most " + strconv.Itoa(avatar.MaxPixels) + " pixels"}
+	}
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "image", Reason: "must be a JPEG or PNG image"}
+	}
+
+	sum := sha256.Sum256(data)
+	a := &domain.Avatar{
+		ContactID:   id.String(),
+		ContentType: img.ContentType,
+		ETag:        hex.EncodeToString(sum[:]),
+		Width:       img.Width,
+		Height:      img.Height,
+	}
+	previous, err := uc.avatarRepo.GetAvatar(ctx, a.ContactID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	blobs := map[string][]byte{avatar.SizeOriginal: data}
+	for size, edge := range avatar.Thumbnails {
+		thumb, err := img.Thumbnail(edge)
+		if err != nil {
+			return nil, err
+		}
+		blobs[size] = thumb
+	}
+	for size, content := range blobs {
+		if err := uc.blobs.Put(ctx, avatar.BlobKey(a, size), bytes.NewReader(content)); err != nil {
+			uc.deleteBlobs(a, previous)
+			return nil, err
+		}
+	}
+
+	if err := uc.avatarRepo.SaveAvatar(ctx, a); err != nil {
+		uc.deleteBlobs(a, previous)
+		return nil, err
+	}
+	if previous != nil {
+		uc.deleteBlobs(previous, a)
+	}
+	return a, nil
+}
+
+// deleteBlobs removes the blobs of a unless they are those of keep, which
+// is the case when the same photo is uploaded again. A blob left behind
+// only wastes space, so failures are ignored.
+func (uc *avatarUseCaseImpl) deleteBlobs(a, keep *domain.Avatar) {
+	if keep != nil && keep.ETag == a.ETag {
+		return
+	}
+	for _, size := range avatar.Sizes() {
+		uc.blobs.Delete(context.Background(), avatar.BlobKey(a, size))
+	}
+}
+
+func (uc *avatarUseCaseImpl) GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error) {
+	id, err := uuid.Parse(contactID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	return uc.avatarRepo.GetAvatar(ctx, id.String())
+}
+
+func (uc *avatarUseCaseImpl) OpenAvatar(ctx context.Context, a *domain.Avatar, size string) (io.ReadCloser, error) {
+	if !avatar.ValidSize(size) {
+		return nil, &domain.ValidationError{Field: "size", Reason: "must be original, small or medium"}
+	}
+	r, err := uc.blobs.Get(ctx, avatar.BlobKey(a, size))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	return r, err
+}
+
+func (uc *avatarUseCaseImpl) DeleteAvatar(ctx context.Context, contactID string) error {
+	a, err := uc.GetAvatar(ctx, contactID)
+	if err != nil {
+		return err
+	}
+	if err := uc.avatarRepo.DeleteAvatar(ctx, a.ContactID); err != nil {
+		return err
+	}
+	uc.deleteBlobs(a, nil)
+	return nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"go/pkg/blob"
+	"go/pkg/services/contact/internal/avatar"
+	"go/pkg/services/contact/internal/domain"
+)
+
+// countingStore counts the writes and deletes that reach the store.
+type countingStore struct {
+	blob.Store
+	writes int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, r io.Reader) error {
+	s.writes++
+	return s.Store.Put(ctx, key, r)
+}
+
+func (s *countingStore) Delete(ctx context.Context, key string) error {
+	s.writes++
+	return s.Store.Delete(ctx, key)
+}
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSetAvatarOfAnotherTenantsContact(t *testing.T) {
+	fs, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &countingStore{Store: fs}
+	contacts := newFakeContactRepository(&domain.Contact{ID: "9b2f6a1e-0c4d-4e8a-9f3b-1a2b3c4d5e6f", OwnerID: "tenant-a"})
+	uc := NewAvatarUseCase(&fakeAvatarRepository{contacts: contacts, avatars: map[string]*domain.Avatar{}}, contacts, store)
+	photo := testPNG(t)
+
+	a, err := uc.SetAvatar(tenantContext("tenant-a"), "9b2f6a1e-0c4d-4e8a-9f3b-1a2b3c4d5e6f", photo)
+	if err != nil {
+		t.Fatalf("SetAvatar by the owner: %v", err)
+	}
+
+	store.writes = 0
+	for _, data := range [][]byte{photo, []byte("not an image")} {
+		_, err := uc.SetAvatar(tenantContext("tenant-b"), a.ContactID, data)
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("SetAvatar by another tenant = %v, want ErrNotFound", err)
+		}
+	}
+	if store.writes != 0 {
+		t.Errorf("another tenant's uploads made %d blob writes, want 0", store.writes)
+	}
+	for _, size := range avatar.Sizes() {
+		r, err := uc.OpenAvatar(tenantContext("tenant-a"), a, size)
+		if err != nil {
+			t.Errorf("owner's %s photo: %v", size, err)
+			continue
+		}
+		r.Close()
+	}
+}
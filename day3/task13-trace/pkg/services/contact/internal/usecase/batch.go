@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// DefaultMaxBatchSize is the most operations a batch may hold unless
+// configured otherwise.
+const DefaultMaxBatchSize = 500
+
+// errBatchFailed aborts the transaction of an atomic batch; the failure
+// itself is in the results.
+var errBatchFailed = errors.New("batch operation failed")
+
+type batchUseCaseImpl struct {
+	contacts   ContactUseCase
+	transactor repository.Transactor
+	maxSize    int
+}
+
+// NewBatchUseCase runs batches through contacts, so every operation is
+// validated and authorized as if it came on its own.
+func NewBatchUseCase(contacts ContactUseCase, transactor repository.Transactor, maxSize int) BatchUseCase {
+	return &batchUseCaseImpl{
+		contacts:   contacts,
+		transactor: transactor,
+		maxSize:    maxSize,
+	}
+}
+
+func (uc *batchUseCaseImpl) ApplyBatch(ctx context.Context, request *domain.BatchRequest) (*domain.BatchResponse, error) {
+	mode := request.Mode
+	if mode == "" {
+		mode = domain.BatchAtomic
+	}
+	if mode != domain.BatchAtomic && mode != domain.BatchBestEffort {
+		return nil, &domain.ValidationError{Field: "Mode", Reason: "must be atomic or best-effort"}
+	}
+	if len(request.Operations) == 0 {
+		return nil, &domain.ValidationError{Field: "Operations", Reason: "is required"}
+	}
+	if len(request.Operations) > uc.maxSize {
+		return nil, &domain.ValidationError{Field: "Operations", Reason: fmt.Sprintf("must hold at most %d operations", uc.maxSize)}
+	}
+
+	response := &domain.BatchResponse{Mode: mode, Results: make([]domain.BatchResult, len(request.Operations))}
+	for i, op := range request.Operations {
+		response.Results[i] = domain.BatchResult{Op: op.Op, ID: op.ID, Status: domain.BatchSkipped}
+		if op.Contact != nil && op.Op != domain.BatchCreate {
+			response.Results[i].ID = op.Contact.ID
+		}
+	}
+
+	if mode == domain.BatchBestEffort {
+		for i, op := range request.Operations {
+			if err := uc.apply(ctx, op, &response.Results[i]); err != nil && !isOperationError(err) {
+				return nil, err
+			}
+		}
+		response.Committed = true
+		return response, nil
+	}
+
+	err := uc.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		for i, op := range request.Operations {
+			if err := uc.apply(ctx, op, &response.Results[i]); err != nil {
+				for j := 0; j < i; j++ {
+					response.Results[j].Status = domain.BatchRolledBack
+					response.Results[j].Contact = nil
+				}
+				if isOperationError(err) {
+					return errBatchFailed
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		return nil, err
+	}
+	response.Committed = err == nil
+	return response, nil
+}
+
+// isOperationError tells the errors that fail one operation from those
+// that fail the whole batch.
+func isOperationError(err error) bool {
+	return errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrForbidden)
+}
+
+// apply runs op and records its outcome in result. Errors of the
+// operation itself are recorded and returned as well.
+func (uc *batchUseCaseImpl) apply(ctx context.Context, op domain.BatchOperation, result *domain.BatchResult) error {
+	var err error
+	switch op.Op {
+	case domain.BatchCreate, domain.BatchUpdate:
+		if op.Contact == nil {
+			err = &domain.ValidationError{Field: "Contact", Reason: "is required"}
+			break
+		}
+		contact := *op.Contact
+		if op.Op == domain.BatchCreate {
+			err = uc.contacts.CreateContact(ctx, &contact)
+		} else {
+			err = uc.contacts.UpdateContact(ctx, &contact)
+		}
+		if err == nil {
+			result.ID = contact.ID
+			result.Contact = &contact
+		}
+	case domain.BatchDelete:
+		if op.ID == "" {
+			err = &domain.ValidationError{Field: "ID", Reason: "is required"}
+			break
+		}
+		err = uc.contacts.DeleteContact(ctx, op.ID)
+	default:
+		err = &domain.ValidationError{Field: "Op", Reason: "must be create, update or delete"}
+	}
+
+	if err != nil {
+		result.Status = domain.BatchFailed
+		result.Error = err.Error()
+		return err
+	}
+	result.Status = domain.BatchSucceeded
+	return nil
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// fakeTransactor gives the fake contact repository a transaction: what fn
+// wrote is undone if it fails.
+type fakeTransactor struct {
+	contacts *fakeContactRepository
+}
+
+func (tr fakeTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r := tr.contacts
+	contacts := make(map[string]*domain.Contact, len(r.contacts))
+	for id, contact := range r.contacts {
+		contacts[id] = contact
+	}
+	clocks := make(map[string]domain.FieldClock, len(r.clocks))
+	for id, clock := range r.clocks {
+		clocks[id] = clock
+	}
+	feeds := make(map[string][]*domain.Change, len(r.feeds))
+	for tenantID, feed := range r.feeds {
+		feeds[tenantID] = feed
+	}
+
+	err := fn(ctx)
+	if err != nil {
+		r.contacts, r.clocks, r.feeds = contacts, clocks, feeds
+	}
+	return err
+}
+
+// brokenContactRepository fails to delete one contact the way a lost
+// connection would.
+type brokenContactRepository struct {
+	*fakeContactRepository
+	broken string
+}
+
+var errConnectionLost = errors.New("connection lost")
+
+func (r *brokenContactRepository) DeleteContact(ctx context.Context, contactID string) error {
+	if contactID == r.broken {
+		return errConnectionLost
+	}
+	return r.fakeContactRepository.DeleteContact(ctx, contactID)
+}
+
+func TestApplyBatch(t *testing.T) {
+	create := func(name string) domain.BatchOperation {
+		return domain.BatchOperation{Op: domain.BatchCreate, Contact: &domain.Contact{FullName: name}}
+	}
+	rename := domain.BatchOperation{Op: domain.BatchUpdate, Contact: &domain.Contact{ID: contactA, FullName: "Ada Lovelace"}}
+	renameMissing := domain.BatchOperation{Op: domain.BatchUpdate, Contact: &domain.Contact{ID: nowhere, FullName: "Nobody"}}
+	remove := domain.BatchOperation{Op: domain.BatchDelete, ID: contactB}
+	removeBroken := domain.BatchOperation{Op: domain.BatchDelete, ID: groupA}
+
+	const (
+		ok         = domain.BatchSucceeded
+		failed     = domain.BatchFailed
+		rolledBack = domain.BatchRolledBack
+		skipped    = domain.BatchSkipped
+	)
+	tests := []struct {
+		name          string
+		mode          string
+		ops           []domain.BatchOperation
+		wantErr       error
+		wantCommitted bool
+		wantStatus    []string
+		// wantNames are the contacts of the tenant afterwards.
+		wantNames []string
+	}{
+		{"atomic", "", []domain.BatchOperation{create("Cleo"), rename, remove},
+			nil, true, []string{ok, ok, ok}, []string{"Ada Lovelace", "Cleo", "Gone"}},
+		{"atomic with a missing contact", domain.BatchAtomic, []domain.BatchOperation{create("Cleo"), rename, renameMissing, remove},
+			nil, false, []string{rolledBack, rolledBack, failed, skipped}, []string{"Ada", "Bob", "Gone"}},
+		{"atomic with an invalid contact", domain.BatchAtomic, []domain.BatchOperation{rename, create(" ")},
+			nil, false, []string{rolledBack, failed}, []string{"Ada", "Bob", "Gone"}},
+		{"atomic with an unknown op", domain.BatchAtomic, []domain.BatchOperation{rename, {Op: "upsert"}},
+			nil, false, []string{rolledBack, failed}, []string{"Ada", "Bob", "Gone"}},
+		{"atomic with a store failure", domain.BatchAtomic, []domain.BatchOperation{rename, removeBroken, remove},
+			errConnectionLost, false, nil, []string{"Ada", "Bob", "Gone"}},
+		{"best-effort with a missing contact", domain.BatchBestEffort, []domain.BatchOperation{create("Cleo"), renameMissing, rename, {Op: domain.BatchDelete}},
+			nil, true, []string{ok, failed, ok, failed}, []string{"Ada Lovelace", "Bob", "Cleo", "Gone"}},
+		{"best-effort with a store failure", domain.BatchBestEffort, []domain.BatchOperation{rename, removeBroken, remove},
+			errConnectionLost, false, nil, []string{"Ada Lovelace", "Bob", "Gone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts := newFakeContactRepository(
+				&domain.Contact{ID: contactA, OwnerID: "tenant-a", FullName: "Ada"},
+				&domain.Contact{ID: contactB, OwnerID: "tenant-a", FullName: "Bob"},
+				&domain.Contact{ID: groupA, OwnerID: "tenant-a", FullName: "Gone"},
+			)
+			repo := &brokenContactRepository{fakeContactRepository: contacts, broken: groupA}
+			uc := NewBatchUseCase(NewContactUseCase(repo, nil, nil), fakeTransactor{contacts}, DefaultMaxBatchSize)
+			ctx := tenantContext("tenant-a")
+
+			response, err := uc.ApplyBatch(ctx, &domain.BatchRequest{Mode: tt.mode, Operations: tt.ops})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyBatch error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				var status []string
+				for _, result := range response.Results {
+					status = append(status, result.Status)
+					if result.Status == ok && tt.ops[len(status)-1].Op != domain.BatchDelete && result.Contact == nil {
+						t.Errorf("result %d succeeded without the stored contact", len(status)-1)
+					}
+					if result.Status != ok && result.Contact != nil {
+						t.Errorf("result %d is %s but carries %+v", len(status)-1, result.Status, result.Contact)
+					}
+					if (result.Status == failed) != (result.Error != "") {
+						t.Errorf("result %d is %s with error %q", len(status)-1, result.Status, result.Error)
+					}
+				}
+				if response.Committed != tt.wantCommitted || !reflect.DeepEqual(status, tt.wantStatus) {
+					t.Errorf("committed %v with %v, want %v with %v", response.Committed, status, tt.wantCommitted, tt.wantStatus)
+				}
+			}
+
+			all, _ := contacts.GetAllContacts(ctx)
+			names := []string{}
+			for _, contact := range all {
+				names = append(names, contact.FullName)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("contacts afterwards = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestApplyBatchRequest(t *testing.T) {
+	contacts := newFakeContactRepository()
+	uc := NewBatchUseCase(NewContactUseCase(contacts, nil, nil), fakeTransactor{contacts}, 2)
+	create := domain.BatchOperation{Op: domain.BatchCreate, Contact: &domain.Contact{FullName: "Ada"}}
+	for _, request := range []*domain.BatchRequest{
+		{Mode: "eventually", Operations: []domain.BatchOperation{create}},
+		{Mode: domain.BatchAtomic},
+		{Mode: domain.BatchAtomic, Operations: []domain.BatchOperation{create, create, create}},
+	} {
+		if _, err := uc.ApplyBatch(tenantContext("tenant-a"), request); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("ApplyBatch(%+v) = %v, want a validation error", request, err)
+		}
+	}
+	if len(contacts.contacts) != 0 {
+		t.Errorf("rejected batches created %d contacts", len(contacts.contacts))
+	}
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+const (
+	DefaultChangeLimit = 100
+	MaxChangeLimit     = 1000
+	MaxChangeWait      = time.Minute
+)
+
+// ChangeNotifier delivers the events the relay publishes, like the
+// in-process event bus. The use case only takes them as a hint to look at
+// the feed again.
+type ChangeNotifier interface {
+	Subscribe(buffer int) (<-chan domain.Event, func())
+}
+
+type changeUseCaseImpl struct {
+	changeRepo repository.ChangeRepository
+	notifier   ChangeNotifier
+	// pollInterval bounds the wait for changes the notifier does not see,
+	// such as those relayed by another instance.
+	pollInterval time.Duration
+}
+
+// NewChangeUseCase serves the change feed from changeRepo. notifier may be
+// nil, in which case waiting requests poll.
+func NewChangeUseCase(changeRepo repository.ChangeRepository, notifier ChangeNotifier) ChangeUseCase {
+	return &changeUseCaseImpl{
+		changeRepo:   changeRepo,
+		notifier:     notifier,
+		pollInterval: 5 * time.Second,
+	}
+}
+
+func (uc *changeUseCaseImpl) GetChanges(ctx context.Context, since int64, limit int, wait time.Duration) ([]*domain.Change, error) {
+	if since < 0 {
+		return nil, &domain.ValidationError{Field: "since", Reason: "must not be negative"}
+	}
+	if limit == 0 {
+		limit = DefaultChangeLimit
+	}
+	if limit < 0 || limit > MaxChangeLimit {
+		return nil, &domain.ValidationError{Field: "limit", Reason: "must be between 1 and 1000"}
+	}
+	if wait < 0 || wait > MaxChangeWait {
+		return nil, &domain.ValidationError{Field: "wait", Reason: "must be between 0 and 60 seconds"}
+	}
+
+	if wait == 0 {
+		return uc.changeRepo.GetChanges(ctx, since, limit)
+	}
+
+	// Subscribe before the first look so that no change slips in between.
+	tenantID, _ := ctx.Value("tenantID").(string)
+	wake, stop := uc.watch(tenantID)
+	defer stop()
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	poll := time.NewTicker(uc.pollInterval)
+	defer poll.Stop()
+
+	for {
+		changes, err := uc.changeRepo.GetChanges(ctx, since, limit)
+		if err != nil || len(changes) > 0 {
+			return changes, err
+		}
+		select {
+		case <-wake:
+		case <-poll.C:
+		case <-deadline.C:
+			return changes, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// watch signals on the returned channel when an event of the tenant is
+// published. It drains the subscription on its own, so a waiting request
+// never holds up the publisher.
+func (uc *changeUseCaseImpl) watch(tenantID string) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+	if uc.notifier == nil {
+		return wake, func() {}
+	}
+
+	events, unsubscribe := uc.notifier.Subscribe(16)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event := <-events:
+				if event.OwnerID != tenantID {
+					continue
+				}
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return wake, func() {
+		unsubscribe()
+		close(done)
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+type customFieldUseCaseImpl struct {
+	fieldRepo   repository.CustomFieldRepository
+	contactRepo repository.ContactRepository
+	transactor  repository.Transactor
+}
+
+func NewCustomFieldUseCase(fieldRepo repository.CustomFieldRepository, contactRepo repository.ContactRepository, transactor repository.Transactor) CustomFieldUseCase {
+	return &customFieldUseCaseImpl{
+		fieldRepo:   fieldRepo,
+		contactRepo: contactRepo,
+		transactor:  transactor,
+	}
+}
+
+func (uc *customFieldUseCaseImpl) DefineCustomField(ctx context.Context, field *domain.CustomField) error {
+	if err := field.Validate(); err != nil {
+		return err
+	}
+	fields, err := uc.fieldRepo.GetCustomFields(ctx)
+	if err != nil {
+		return err
+	}
+	if len(fields) >= domain.MaxCustomFields {
+		return &domain.ValidationError{Field: "Name", Reason: "would exceed the limit of " + strconv.Itoa(domain.MaxCustomFields) + " custom fields"}
+	}
+	return uc.fieldRepo.CreateCustomField(ctx, field)
+}
+
+func (uc *customFieldUseCaseImpl) GetCustomFields(ctx context.Context) ([]*domain.CustomField, error) {
+	fields, err := uc.fieldRepo.GetCustomFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// DeleteCustomField removes the field from every contact that has it. A
+// field of the same name defined later starts out empty.
+func (uc *customFieldUseCaseImpl) DeleteCustomField(ctx context.Context, name string) error {
+	return uc.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.fieldRepo.DeleteCustomField(ctx, name); err != nil {
+			return err
+		}
+		return uc.contactRepo.RemoveCustomField(ctx, name)
+	})
+}
@@ -0,0 +1,404 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+const (
+	nameSimilarityThreshold = 0.85
+	// Same first name and patronymic only needs a loosely similar full name,
+	// which catches "Ivanov Ivan Ivanovich" vs "Ivanova Ivan Ivanovich".
+	relaxedNameSimilarityThreshold = 0.7
+
+	duplicateReasonPhone = "phone"
+	duplicateReasonName  = "name"
+)
+
+func (uc *contactUseCaseImpl) FindDuplicates(ctx context.Context) ([]*domain.DuplicateGroup, error) {
+	contacts, err := uc.contactRepo.GetAllContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findDuplicates(contacts, uc.normalizePhone), nil
+}
+
+func (uc *contactUseCaseImpl) MergeContacts(ctx context.Context, survivorID string, duplicateIDs []string) (*domain.Contact, error) {
+	ids := make([]string, 0, len(duplicateIDs))
+	seen := map[string]bool{}
+	for _, id := range duplicateIDs {
+		if id == survivorID {
+			return nil, &domain.ValidationError{Field: "DuplicateIDs", Reason: "must not contain the survivor"}
+		}
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, &domain.ValidationError{Field: "DuplicateIDs", Reason: "is required"}
+	}
+
+	survivor, err := uc.contactRepo.GetContactByID(ctx, survivorID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make([]*domain.Contact, 0, len(ids))
+	for _, id := range ids {
+		duplicate, err := uc.contactRepo.GetContactByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		mergeFields(survivor, duplicate)
+		merged = append(merged, duplicate)
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"MergedIDs": ids,
+		"Merged":    merged,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := &domain.HistoryEntry{
+		ContactID: survivor.ID,
+		Action:    domain.HistoryActionMerged,
+		Details:   details,
+	}
+
+	err = uc.contactRepo.MergeContacts(ctx, survivor, ids, entry)
+	if err != nil {
+		return nil, err
+	}
+	return survivor, nil
+}
+
+// mergeFields fills the survivor's empty fields from the duplicate; values the
+// survivor already has always win. The survivor gains the duplicate's tags,
+// and its anniversaries with labels of their own, for as long as it holds
+// fewer than a contact may.
+func mergeFields(survivor, duplicate *domain.Contact) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&survivor.FullName, duplicate.FullName)
+	fill(&survivor.FirstName, duplicate.FirstName)
+	fill(&survivor.Patronymic, duplicate.Patronymic)
+	fill(&survivor.PhoneNumber, duplicate.PhoneNumber)
+	fill(&survivor.Email, duplicate.Email)
+	if survivor.Address == (domain.Address{}) {
+		survivor.Address = duplicate.Address
+	}
+	tags := make(map[string]bool, len(survivor.Tags))
+	for _, tag := range survivor.Tags {
+		tags[tag] = true
+	}
+	for _, tag := range duplicate.Tags {
+		if !tags[tag] && len(survivor.Tags) < domain.MaxTags {
+			tags[tag] = true
+			survivor.Tags = append(survivor.Tags, tag)
+		}
+	}
+	survivor.Tags = domain.NormalizeTags(survivor.Tags)
+	if survivor.Birthday == nil {
+		survivor.Birthday = duplicate.Birthday
+	}
+	labels := make(map[string]bool, len(survivor.Anniversaries))
+	for _, a := range survivor.Anniversaries {
+		labels[a.Label] = true
+	}
+	for _, a := range duplicate.Anniversaries {
+		if !labels[a.Label] && len(survivor.Anniversaries) < domain.MaxAnniversaries {
+			labels[a.Label] = true
+			survivor.Anniversaries = append(survivor.Anniversaries, a)
+		}
+	}
+	for name, value := range duplicate.CustomFields {
+		if _, ok := survivor.CustomFields[name]; !ok {
+			if survivor.CustomFields == nil {
+				survivor.CustomFields = map[string]interface{}{}
+			}
+			survivor.CustomFields[name] = value
+		}
+	}
+}
+
+type duplicateCandidate struct {
+	contact    *domain.Contact
+	phone      string
+	name       string
+	nameLength int
+	// blocks are the sorted two-letter prefixes of the name's words.
+	blocks     []string
+	firstName  string
+	patronymic string
+}
+
+func findDuplicates(contacts []*domain.Contact, normalizePhone func(string) string) []*domain.DuplicateGroup {
+	candidates := make([]duplicateCandidate, len(contacts))
+	for i, c := range contacts {
+		name := normalizeName(c.FullName)
+		candidates[i] = duplicateCandidate{
+			contact:    c,
+			phone:      normalizePhone(c.PhoneNumber),
+			name:       name,
+			nameLength: utf8.RuneCountInString(name),
+			blocks:     nameBlocks(name),
+			firstName:  normalizeName(c.FirstName),
+			patronymic: normalizeName(c.Patronymic),
+		}
+	}
+
+	sets := newDisjointSet(len(candidates))
+	reasons := map[int]map[string]bool{}
+	link := func(i, j int, reason string) {
+		sets.union(i, j)
+		if reasons[i] == nil {
+			reasons[i] = map[string]bool{}
+		}
+		reasons[i][reason] = true
+	}
+
+	byPhone := map[string][]int{}
+	for i, c := range candidates {
+		if c.phone != "" {
+			byPhone[c.phone] = append(byPhone[c.phone], i)
+		}
+	}
+	for _, block := range byPhone {
+		for _, j := range block[1:] {
+			link(block[0], j, duplicateReasonPhone)
+		}
+	}
+
+	// Names are only compared within blocks sharing a token prefix, so the
+	// cost stays far below comparing every pair of contacts. A pair sharing
+	// several blocks is compared only in the first of them.
+	byBlock := map[string][]int{}
+	for i, c := range candidates {
+		for _, key := range c.blocks {
+			byBlock[key] = append(byBlock[key], i)
+		}
+	}
+	for key, block := range byBlock {
+		// With the block sorted by name length, the names after one too long
+		// to be similar to the current name are too long as well.
+		sort.SliceStable(block, func(a, b int) bool {
+			return candidates[block[a]].nameLength < candidates[block[b]].nameLength
+		})
+		for a, i := range block {
+			for _, j := range block[a+1:] {
+				if !similarLengths(candidates[i], candidates[j]) {
+					break
+				}
+				if firstSharedBlock(candidates[i].blocks, candidates[j].blocks) != key {
+					continue
+				}
+				if similarNames(candidates[i], candidates[j]) {
+					link(i, j, duplicateReasonName)
+				}
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range candidates {
+		root := sets.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := []*domain.DuplicateGroup{}
+	for _, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		group := &domain.DuplicateGroup{}
+		groupReasons := map[string]bool{}
+		for _, i := range indexes {
+			group.Contacts = append(group.Contacts, candidates[i].contact)
+			for reason := range reasons[i] {
+				groupReasons[reason] = true
+			}
+		}
+		for reason := range groupReasons {
+			group.Reasons = append(group.Reasons, reason)
+		}
+		sort.Strings(group.Reasons)
+		sort.Slice(group.Contacts, func(a, b int) bool {
+			return contactBefore(group.Contacts[a], group.Contacts[b])
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		return contactBefore(groups[a].Contacts[0], groups[b].Contacts[0])
+	})
+	return groups
+}
+
+// contactBefore orders contacts by name, then by ID, so duplicate groups are
+// listed the same way every time.
+func contactBefore(a, b *domain.Contact) bool {
+	if a.FullName != b.FullName {
+		return a.FullName < b.FullName
+	}
+	return a.ID < b.ID
+}
+
+// similarLengths reports whether the names are close enough in length to
+// reach relaxedNameSimilarityThreshold: at best the shorter name is all
+// of the longer one.
+func similarLengths(a, b duplicateCandidate) bool {
+	shorter, longer := a.nameLength, b.nameLength
+	if shorter > longer {
+		shorter, longer = longer, shorter
+	}
+	return float64(shorter) >= relaxedNameSimilarityThreshold*float64(longer)
+}
+
+func similarNames(a, b duplicateCandidate) bool {
+	if a.name == "" || b.name == "" {
+		return false
+	}
+	score := similarity(a.name, b.name)
+	if score >= nameSimilarityThreshold {
+		return true
+	}
+	return a.firstName != "" && a.patronymic != "" &&
+		a.firstName == b.firstName && a.patronymic == b.patronymic &&
+		score >= relaxedNameSimilarityThreshold
+}
+
+// normalizePhone compares numbers in E.164 form, falling back to bare digits
+// for values stored before normalization or that do not parse.
+func (uc *contactUseCaseImpl) normalizePhone(number string) string {
+	if number == "" {
+		return ""
+	}
+	if normalized, err := uc.phones.Normalize(number); err == nil {
+		return normalized
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+}
+
+// normalizeName lowercases, folds "ё" to "е", drops punctuation and sorts the
+// words, so "Ivanov Ivan" and "ivan  IVANOV" produce the same key.
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "ё", "е")
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// nameBlocks returns the sorted, distinct two-letter prefixes of the words
+// of a normalized name.
+func nameBlocks(name string) []string {
+	var blocks []string
+	for _, word := range strings.Fields(name) {
+		blocks = append(blocks, prefix(word, 2))
+	}
+	sort.Strings(blocks)
+	unique := blocks[:0]
+	for i, block := range blocks {
+		if i == 0 || block != blocks[i-1] {
+			unique = append(unique, block)
+		}
+	}
+	return unique
+}
+
+// firstSharedBlock returns the smallest block in both sorted lists.
+func firstSharedBlock(a, b []string) string {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			return a[i]
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return ""
+}
+
+func prefix(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
+
+// similarity returns 1 minus the normalized Levenshtein distance.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+type disjointSet struct {
+	parent []int
+}
+
+func newDisjointSet(n int) *disjointSet {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &disjointSet{parent: parent}
+}
+
+func (s *disjointSet) find(i int) int {
+	for s.parent[i] != i {
+		s.parent[i] = s.parent[s.parent[i]]
+		i = s.parent[i]
+	}
+	return i
+}
+
+func (s *disjointSet) union(i, j int) {
+	s.parent[s.find(i)] = s.find(j)
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+func digitsOnly(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+}
+
+func groupIDs(groups []*domain.DuplicateGroup) [][]string {
+	ids := make([][]string, len(groups))
+	for i, group := range groups {
+		for _, c := range group.Contacts {
+			ids[i] = append(ids[i], c.ID)
+		}
+	}
+	return ids
+}
+
+func TestFindDuplicates(t *testing.T) {
+	contacts := []*domain.Contact{
+		{ID: "6", FullName: "Petrov Petr", PhoneNumber: "+7 900 000-00-02"},
+		{ID: "1", FullName: "Ivanov Ivan Ivanovich", FirstName: "Ivan", Patronymic: "Ivanovich"},
+		{ID: "5", FullName: "Petrov Petr", PhoneNumber: "+7 900 000-00-01"},
+		{ID: "2", FullName: "ivan  IVANOV Ivanovich"},
+		{ID: "3", FullName: "Ivanova Ivan Ivanovich", FirstName: "Ivan", Patronymic: "Ivanovich"},
+		{ID: "4", FullName: "Sidorov Sidor", PhoneNumber: "7 (900) 000-00-01"},
+		{ID: "7", FullName: "Ivan"},
+		{ID: "8", FullName: "Unrelated Person"},
+	}
+	want := [][]string{{"1", "3", "2"}, {"5", "6", "4"}}
+	wantReasons := [][]string{{"name"}, {"name", "phone"}}
+
+	for run := 0; run < 20; run++ {
+		groups := findDuplicates(contacts, digitsOnly)
+		if got := groupIDs(groups); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: groups = %v, want %v", run, got, want)
+		}
+		for i, group := range groups {
+			if !reflect.DeepEqual(group.Reasons, wantReasons[i]) {
+				t.Errorf("group %d reasons = %v, want %v", i, group.Reasons, wantReasons[i])
+			}
+		}
+	}
+}
+
+func TestFindDuplicatesOrdersTiesByID(t *testing.T) {
+	contacts := []*domain.Contact{
+		{ID: "d", FullName: "Same Name", PhoneNumber: "2"},
+		{ID: "c", FullName: "Same Name", PhoneNumber: "1"},
+		{ID: "b", FullName: "Same Name", PhoneNumber: "2"},
+		{ID: "a", FullName: "Same Name", PhoneNumber: "1"},
+	}
+	for run := 0; run < 20; run++ {
+		groups := findDuplicates(contacts, digitsOnly)
+		if got := groupIDs(groups); !reflect.DeepEqual(got, [][]string{{"a", "b", "c", "d"}}) {
+			t.Fatalf("run %d: groups = %v", run, got)
+		}
+	}
+}
+
+func TestNameBlocks(t *testing.T) {
+	if got := nameBlocks(normalizeName("Ivanov Ivan Petrovich")); !reflect.DeepEqual(got, []string{"iv", "pe"}) {
+		t.Errorf("nameBlocks = %q", got)
+	}
+	if got := firstSharedBlock([]string{"aa", "iv", "pe"}, []string{"iv", "pe"}); got != "iv" {
+		t.Errorf("firstSharedBlock = %q, want iv", got)
+	}
+	if got := firstSharedBlock([]string{"aa"}, []string{"bb"}); got != "" {
+		t.Errorf("firstSharedBlock = %q, want none", got)
+	}
+}
+
+func TestMergeFieldsKeepsLimits(t *testing.T) {
+	survivor := &domain.Contact{FullName: "Survivor"}
+	duplicate := &domain.Contact{FullName: "Duplicate", Email: "d@example.com"}
+	for i := 0; i < domain.MaxTags; i++ {
+		survivor.Tags = append(survivor.Tags, fmt.Sprintf("s%02d", i))
+		duplicate.Tags = append(duplicate.Tags, fmt.Sprintf("d%02d", i))
+	}
+	survivor.Tags = survivor.Tags[:domain.MaxTags-2]
+	duplicate.Tags = append(duplicate.Tags, survivor.Tags[0])
+	for i := 0; i < domain.MaxAnniversaries+1; i++ {
+		duplicate.Anniversaries = append(duplicate.Anniversaries, domain.Anniversary{Label: fmt.Sprintf("a%d", i), Date: domain.Date{Month: 1, Day: 1}})
+	}
+
+	mergeFields(survivor, duplicate)
+
+	if survivor.FullName != "Survivor" || survivor.Email != "d@example.com" {
+		t.Errorf("merged names = %q, %q", survivor.FullName, survivor.Email)
+	}
+	if len(survivor.Tags) != domain.MaxTags || len(survivor.Anniversaries) != domain.MaxAnniversaries {
+		t.Errorf("merged %d tags and %d anniversaries, want %d and %d",
+			len(survivor.Tags), len(survivor.Anniversaries), domain.MaxTags, domain.MaxAnniversaries)
+	}
+	if err := survivor.Validate(); err != nil {
+		t.Errorf("merged contact does not validate: %v", err)
+	}
+}
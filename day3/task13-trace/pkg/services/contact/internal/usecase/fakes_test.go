@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// The fake repositories keep rows in memory and scope them to the tenant
+// in the context the way the Postgres ones do: rows of another tenant are
+// not found.
+
+func tenantContext(tenantID string) context.Context {
+	return context.WithValue(context.Background(), "tenantID", tenantID)
+}
+
+func tenantOf(ctx context.Context) string {
+	tenantID, _ := ctx.Value("tenantID").(string)
+	return tenantID
+}
+
+// fakeContactRepository keeps contact IDs unique across tenants and
+// records the change feed of every tenant.
+type fakeContactRepository struct {
+	repository.ContactRepository
+	contacts map[string]*domain.Contact
+	clocks   map[string]domain.FieldClock
+	feeds    map[string][]*domain.Change
+}
+
+func newFakeContactRepository(contacts ...*domain.Contact) *fakeContactRepository {
+	r := &fakeContactRepository{
+		contacts: map[string]*domain.Contact{},
+		clocks:   map[string]domain.FieldClock{},
+		feeds:    map[string][]*domain.Change{},
+	}
+	for _, contact := range contacts {
+		if contact.Version == 0 {
+			contact.Version = 1
+		}
+		r.contacts[contact.ID] = contact
+		r.clocks[contact.ID] = domain.FieldClock{}
+	}
+	return r
+}
+
+func (r *fakeContactRepository) record(ctx context.Context, event domain.DomainEvent) {
+	data, _ := json.Marshal(event)
+	feed := r.feeds[tenantOf(ctx)]
+	change := &domain.Change{Seq: int64(len(feed) + 1), Type: event.EventType(), Data: data}
+	r.feeds[tenantOf(ctx)] = append(feed, change)
+}
+
+func (r *fakeContactRepository) owned(ctx context.Context, contactID string) (*domain.Contact, error) {
+	contact, ok := r.contacts[contactID]
+	if !ok || contact.OwnerID != tenantOf(ctx) {
+		return nil, domain.ErrNotFound
+	}
+	return contact, nil
+}
+
+func (r *fakeContactRepository) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	contact, err := r.owned(ctx, contactID)
+	if err != nil {
+		return nil, err
+	}
+	copied := *contact
+	return &copied, nil
+}
+
+type fakeAvatarRepository struct {
+	repository.AvatarRepository
+	contacts *fakeContactRepository
+	avatars  map[string]*domain.Avatar
+}
+
+func (r *fakeAvatarRepository) SaveAvatar(ctx context.Context, avatar *domain.Avatar) error {
+	if _, err := r.contacts.owned(ctx, avatar.ContactID); err != nil {
+		return err
+	}
+	avatar.OwnerID = tenantOf(ctx)
+	copied := *avatar
+	r.avatars[avatar.ContactID] = &copied
+	return nil
+}
+
+func (r *fakeAvatarRepository) GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error) {
+	avatar, ok := r.avatars[contactID]
+	if !ok || avatar.OwnerID != tenantOf(ctx) {
+		return nil, domain.ErrNotFound
+	}
+	copied := *avatar
+	return &copied, nil
+}
+
+func (r *fakeContactRepository) GetAllContacts(ctx context.Context) ([]*domain.Contact, error) {
+	contacts := []*domain.Contact{}
+	for id := range r.contacts {
+		if contact, err := r.GetContactByID(ctx, id); err == nil {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts, nil
+}
+
+func (r *fakeContactRepository) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	if contact.ID == "" {
+		contact.ID = uuid.New().String()
+	}
+	if _, ok := r.contacts[contact.ID]; ok {
+		return domain.ErrIDInUse
+	}
+	contact.OwnerID = tenantOf(ctx)
+	contact.Version = 1
+	copied := *contact
+	r.contacts[contact.ID] = &copied
+	r.clocks[contact.ID] = domain.FieldClock{}
+	r.record(ctx, domain.ContactCreated{Contact: copied})
+	return nil
+}
+
+func (r *fakeContactRepository) GetContactClock(ctx context.Context, contactID string) (*domain.Contact, domain.FieldClock, error) {
+	contact, err := r.GetContactByID(ctx, contactID)
+	if err != nil {
+		return nil, nil, err
+	}
+	clock := domain.FieldClock{}
+	for name, stamp := range r.clocks[contactID] {
+		clock[name] = stamp
+	}
+	return contact, clock, nil
+}
+
+func (r *fakeContactRepository) CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error {
+	stored, err := r.owned(ctx, contact.ID)
+	if err != nil || stored.Version != contact.Version {
+		return domain.ErrVersionConflict
+	}
+	contact.OwnerID = stored.OwnerID
+	contact.Version++
+	copied := *contact
+	r.contacts[contact.ID] = &copied
+	r.clocks[contact.ID] = clock
+	r.record(ctx, domain.ContactUpdated{Contact: copied})
+	return nil
+}
+
+func (r *fakeContactRepository) CompareAndDeleteContact(ctx context.Context, contactID string, version int64) error {
+	stored, err := r.owned(ctx, contactID)
+	if err != nil || stored.Version != version {
+		return domain.ErrVersionConflict
+	}
+	delete(r.contacts, contactID)
+	delete(r.clocks, contactID)
+	r.record(ctx, domain.ContactDeleted{ID: contactID})
+	return nil
+}
+
+// fakeChangeRepository reads the feeds recorded by a fakeContactRepository.
+type fakeChangeRepository struct {
+	contacts *fakeContactRepository
+}
+
+func (r *fakeChangeRepository) GetChanges(ctx context.Context, since int64, limit int) ([]*domain.Change, error) {
+	changes := []*domain.Change{}
+	for _, change := range r.contacts.feeds[tenantOf(ctx)] {
+		if change.Seq > since && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (r *fakeContactRepository) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	stored, err := r.owned(ctx, contact.ID)
+	if err != nil {
+		return err
+	}
+	contact.OwnerID = stored.OwnerID
+	contact.Version = stored.Version + 1
+	copied := *contact
+	r.contacts[contact.ID] = &copied
+	r.record(ctx, domain.ContactUpdated{Contact: copied})
+	return nil
+}
+
+func (r *fakeContactRepository) DeleteContact(ctx context.Context, contactID string) error {
+	if _, err := r.owned(ctx, contactID); err != nil {
+		return err
+	}
+	delete(r.contacts, contactID)
+	delete(r.clocks, contactID)
+	r.record(ctx, domain.ContactDeleted{ID: contactID})
+	return nil
+}
+
+func (r *fakeContactRepository) MergeContacts(ctx context.Context, survivor *domain.Contact, duplicateIDs []string, entry *domain.HistoryEntry) error {
+	for _, id := range append([]string{survivor.ID}, duplicateIDs...) {
+		if _, err := r.owned(ctx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range duplicateIDs {
+		delete(r.contacts, id)
+	}
+	return r.UpdateContact(ctx, survivor)
+}
+
+type fakeGroupRepository struct {
+	repository.GroupRepository
+	contacts *fakeContactRepository
+	groups   map[string]*domain.Group
+	members  map[string][]string
+}
+
+func newFakeGroupRepository(contacts *fakeContactRepository, groups ...*domain.Group) *fakeGroupRepository {
+	r := &fakeGroupRepository{contacts: contacts, groups: map[string]*domain.Group{}, members: map[string][]string{}}
+	for _, group := range groups {
+		r.groups[group.ID] = group
+	}
+	return r
+}
+
+func (r *fakeGroupRepository) GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error) {
+	group, ok := r.groups[groupID]
+	if !ok || group.OwnerID != tenantOf(ctx) {
+		return nil, domain.ErrNotFound
+	}
+	copied := *group
+	return &copied, nil
+}
+
+func (r *fakeGroupRepository) GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	if _, err := r.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	contacts := []*domain.Contact{}
+	for _, id := range r.members[groupID] {
+		contact, err := r.contacts.GetContactByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, nil
+}
+
+func (r *fakeGroupRepository) AddContactToGroup(ctx context.Context, contactID, groupID string) error {
+	if _, err := r.contacts.owned(ctx, contactID); err != nil {
+		return err
+	}
+	if _, err := r.GetGroupByID(ctx, groupID); err != nil {
+		return err
+	}
+	r.members[groupID] = append(r.members[groupID], contactID)
+	return nil
+}
+
+func (r *fakeGroupRepository) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
+	groups := []*domain.Group{}
+	for _, group := range r.groups {
+		if group.OwnerID == tenantOf(ctx) {
+			copied := *group
+			groups = append(groups, &copied)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// checkParent rejects a parent that is not a group or that would put group
+// groupID, empty for a new one, under itself. The repository checks again
+// when it writes, as the hierarchy may change in between.
+func (uc *groupUseCaseImpl) checkParent(ctx context.Context, groupID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(parentID); err != nil {
+		return &domain.ValidationError{Field: "ParentID", Reason: "must be a UUID"}
+	}
+	if strings.EqualFold(parentID, groupID) {
+		return &domain.ValidationError{Field: "ParentID", Reason: "is the group itself or one below it"}
+	}
+
+	_, err := uc.groupRepo.GetGroupByID(ctx, parentID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.ValidationError{Field: "ParentID", Reason: "is not a group"}
+	}
+	if err != nil {
+		return err
+	}
+	if groupID == "" {
+		return nil
+	}
+
+	subtree, err := uc.groupRepo.GetGroupSubtree(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	for _, g := range subtree {
+		if strings.EqualFold(g.ID, parentID) {
+			return &domain.ValidationError{Field: "ParentID", Reason: "is the group itself or one below it"}
+		}
+	}
+	return nil
+}
+
+func (uc *groupUseCaseImpl) GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error) {
+	_, err := uc.groupRepo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := uc.groupRepo.GetGroupSubtree(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (uc *groupUseCaseImpl) GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	_, err := uc.groupRepo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	contacts, err := uc.groupRepo.GetEffectiveMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
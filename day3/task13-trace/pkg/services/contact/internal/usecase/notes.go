@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultNoteLimit = 50
+	MaxNoteLimit     = 200
+)
+
+type noteUseCaseImpl struct {
+	noteRepo    repository.NoteRepository
+	contactRepo repository.ContactRepository
+	now         func() time.Time
+}
+
+func NewNoteUseCase(noteRepo repository.NoteRepository, contactRepo repository.ContactRepository) NoteUseCase {
+	return &noteUseCaseImpl{
+		noteRepo:    noteRepo,
+		contactRepo: contactRepo,
+		now:         time.Now,
+	}
+}
+
+// AddNote logs a note against note.ContactID by the request's subject and
+// records it in the contact's history. OccurredAt defaults to now.
+func (uc *noteUseCaseImpl) AddNote(ctx context.Context, note *domain.Note) error {
+	if _, err := uuid.Parse(note.ContactID); err != nil {
+		return domain.ErrNotFound
+	}
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	if note.OccurredAt.IsZero() {
+		note.OccurredAt = uc.now()
+	}
+	note.ID = uuid.New().String()
+	note.Author, _ = ctx.Value("subject").(string)
+
+	details, err := json.Marshal(map[string]interface{}{
+		"NoteID": note.ID,
+		"Type":   note.Type,
+		"Author": note.Author,
+	})
+	if err != nil {
+		return err
+	}
+	entry := &domain.HistoryEntry{
+		ContactID: note.ContactID,
+		Action:    domain.HistoryActionNoteAdded,
+		Details:   details,
+	}
+	return uc.noteRepo.CreateNote(ctx, note, entry)
+}
+
+// GetNotes returns a page of a contact's notes, of noteType unless it is
+// empty. cursor is the NextCursor of the page before, or empty for the
+// first page.
+func (uc *noteUseCaseImpl) GetNotes(ctx context.Context, contactID, noteType, cursor string, limit int) (*domain.NotePage, error) {
+	if limit == 0 {
+		limit = DefaultNoteLimit
+	}
+	if limit < 0 || limit > MaxNoteLimit {
+		return nil, &domain.ValidationError{Field: "limit", Reason: "must be between 1 and " + strconv.Itoa(MaxNoteLimit)}
+	}
+	if noteType != "" && !domain.ValidNoteType(noteType) {
+		return nil, &domain.ValidationError{Field: "type", Reason: "is not a note type"}
+	}
+	if _, err := uuid.Parse(cursor); cursor != "" && err != nil {
+		return nil, &domain.ValidationError{Field: "cursor", Reason: "is not a cursor from an earlier page"}
+	}
+	if _, err := uuid.Parse(contactID); err != nil {
+		return nil, domain.ErrNotFound
+	}
+	// An unknown contact has no notes either, but is told apart by 404.
+	if _, err := uc.contactRepo.GetContactByID(ctx, contactID); err != nil {
+		return nil, err
+	}
+
+	// One note more than asked for tells whether there is another page.
+	notes, err := uc.noteRepo.GetNotes(ctx, contactID, noteType, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.NotePage{Notes: notes}
+	if len(notes) > limit {
+		page.Notes = notes[:limit]
+		page.NextCursor = notes[limit-1].ID
+	}
+	return page, nil
+}
+
+// GetHistory returns a page of a contact's history, latest first, paged
+// like GetNotes.
+func (uc *noteUseCaseImpl) GetHistory(ctx context.Context, contactID, cursor string, limit int) (*domain.HistoryPage, error) {
+	if limit == 0 {
+		limit = DefaultNoteLimit
+	}
+	if limit < 0 || limit > MaxNoteLimit {
+		return nil, &domain.ValidationError{Field: "limit", Reason: "must be between 1 and " + strconv.Itoa(MaxNoteLimit)}
+	}
+	if _, err := uuid.Parse(cursor); cursor != "" && err != nil {
+		return nil, &domain.ValidationError{Field: "cursor", Reason: "is not a cursor from an earlier page"}
+	}
+	if _, err := uuid.Parse(contactID); err != nil {
+		return nil, domain.ErrNotFound
+	}
+	if _, err := uc.contactRepo.GetContactByID(ctx, contactID); err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.noteRepo.GetHistory(ctx, contactID, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.HistoryPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = entries[limit-1].ID
+	}
+	return page, nil
+}
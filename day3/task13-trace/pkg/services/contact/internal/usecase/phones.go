@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// PhoneBackfill is what NormalizePhoneNumbers did to a tenant's contacts,
+// or would do on a dry run.
+type PhoneBackfill struct {
+	Normalized int
+	// Unparseable lists the contacts whose number is not valid in the
+	// default region or any other; they are left as they are.
+	Unparseable []string
+}
+
+// NormalizePhoneNumbers rewrites the phone numbers of the tenant in ctx to
+// E.164. Numbers have been normalized on write since phones came with a
+// parser, but contacts stored before then still hold them as typed, and
+// lookup by phone does not find those.
+//
+// A number is rewritten the way a sync update writes a field, so clients
+// pull it as a change. A contact edited meanwhile is read again: the edit
+// normalized its number, unless it failed to parse, or it gets a new try.
+func NormalizePhoneNumbers(ctx context.Context, contactRepo repository.ContactRepository, phones *phone.Parser, dryRun bool) (*PhoneBackfill, error) {
+	contacts, err := contactRepo.GetAllContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	backfill := &PhoneBackfill{Unparseable: []string{}}
+	for _, contact := range contacts {
+		if contact.PhoneNumber == "" {
+			continue
+		}
+		normalized, err := phones.Normalize(contact.PhoneNumber)
+		if err != nil {
+			backfill.Unparseable = append(backfill.Unparseable, contact.ID)
+			continue
+		}
+		if normalized == contact.PhoneNumber {
+			continue
+		}
+		if dryRun {
+			backfill.Normalized++
+			continue
+		}
+
+		written, err := normalizePhoneNumber(ctx, contactRepo, phones, contact.ID)
+		if err != nil {
+			return backfill, err
+		}
+		if written {
+			backfill.Normalized++
+		}
+	}
+	return backfill, nil
+}
+
+// normalizePhoneNumber rewrites the stored number of one contact and
+// reports whether it wrote it.
+func normalizePhoneNumber(ctx context.Context, contactRepo repository.ContactRepository, phones *phone.Parser, contactID string) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		current, clock, err := contactRepo.GetContactClock(ctx, contactID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		normalized, err := phones.Normalize(current.PhoneNumber)
+		if err != nil || normalized == current.PhoneNumber {
+			return false, nil
+		}
+
+		updated := *current
+		updated.PhoneNumber = normalized
+		clock.Stamp(current, &updated, domain.FieldStamp{Version: current.Version + 1, ModifiedAt: time.Now()})
+		err = contactRepo.CompareAndUpdateContact(ctx, &updated, clock)
+		if !errors.Is(err, domain.ErrVersionConflict) || attempt == syncAttempts {
+			return err == nil, err
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+)
+
+// editedMeanwhile renames a contact just before the first write to it, as
+// a request racing the backfill would.
+type editedMeanwhile struct {
+	*fakeContactRepository
+	edited bool
+}
+
+func (r *editedMeanwhile) CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error {
+	if !r.edited {
+		r.edited = true
+		stored := r.contacts[contact.ID]
+		stored.FullName = "Ada King"
+		stored.Version++
+	}
+	return r.fakeContactRepository.CompareAndUpdateContact(ctx, contact, clock)
+}
+
+func TestNormalizePhoneNumbers(t *testing.T) {
+	phones, err := phone.NewParser("DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRepository := func() *fakeContactRepository {
+		return newFakeContactRepository(
+			&domain.Contact{ID: contactA, OwnerID: "tenant-a", FullName: "Ada", PhoneNumber: "030/1234567"},
+			&domain.Contact{ID: groupA, OwnerID: "tenant-a", FullName: "Normalized", PhoneNumber: "+4915123456789"},
+			&domain.Contact{ID: groupB, OwnerID: "tenant-a", FullName: "Garbled", PhoneNumber: "call me"},
+			&domain.Contact{ID: nowhere, OwnerID: "tenant-a", FullName: "No phone"},
+			&domain.Contact{ID: contactB, OwnerID: "tenant-b", FullName: "Bob", PhoneNumber: "030 12345678"},
+		)
+	}
+	ctx := tenantContext("tenant-a")
+	want := &PhoneBackfill{Normalized: 1, Unparseable: []string{groupB}}
+
+	contacts := newRepository()
+	backfill, err := NormalizePhoneNumbers(ctx, contacts, phones, true)
+	if err != nil || !reflect.DeepEqual(backfill, want) {
+		t.Errorf("dry run = %+v, %v, want %+v", backfill, err, want)
+	}
+	if contacts.contacts[contactA].PhoneNumber != "030/1234567" || len(contacts.feeds["tenant-a"]) != 0 {
+		t.Errorf("dry run wrote %+v", contacts.contacts[contactA])
+	}
+
+	backfill, err = NormalizePhoneNumbers(ctx, contacts, phones, false)
+	if err != nil || !reflect.DeepEqual(backfill, want) {
+		t.Errorf("NormalizePhoneNumbers = %+v, %v, want %+v", backfill, err, want)
+	}
+	ada := contacts.contacts[contactA]
+	if ada.PhoneNumber != "+49301234567" || ada.Version != 2 || contacts.clocks[contactA]["PhoneNumber"].Version != 2 {
+		t.Errorf("Ada = %+v with clock %v", ada, contacts.clocks[contactA])
+	}
+	if feed := contacts.feeds["tenant-a"]; len(feed) != 1 || feed[0].Type != domain.EventContactUpdated {
+		t.Errorf("change feed = %+v, want one update", feed)
+	}
+	if contacts.contacts[groupB].PhoneNumber != "call me" || contacts.contacts[contactB].PhoneNumber != "030 12345678" {
+		t.Error("changed an unparseable number or another tenant's")
+	}
+
+	backfill, err = NormalizePhoneNumbers(ctx, contacts, phones, false)
+	if err != nil || backfill.Normalized != 0 {
+		t.Errorf("second run = %+v, %v, want nothing to do", backfill, err)
+	}
+
+	raced := &editedMeanwhile{fakeContactRepository: newRepository()}
+	backfill, err = NormalizePhoneNumbers(ctx, raced, phones, false)
+	if err != nil || backfill.Normalized != 1 {
+		t.Errorf("with an edit meanwhile = %+v, %v", backfill, err)
+	}
+	if ada := raced.contacts[contactA]; ada.PhoneNumber != "+49301234567" || ada.FullName != "Ada King" {
+		t.Errorf("with an edit meanwhile Ada = %+v, want both the edit and the number", ada)
+	}
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+)
+
+const (
+	contactA = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	contactB = "5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9"
+	groupA   = "6f1d2c3b-4a59-4e8d-9c7b-a6f5e4d3c2b1"
+	groupB   = "7a2e3d4c-5b6a-4f9e-8d7c-b6a5f4e3d2c1"
+	// nowhere is an ID no tenant has.
+	nowhere = "8b3f4e5d-6c7b-4a0f-9e8d-c7b6a5f4e3d2"
+)
+
+// TestOtherTenantsRowsAreNotFound runs every operation that names a
+// contact or group as tenant B on tenant A's rows and on rows that do not
+// exist at all, and expects the same ErrNotFound from both.
+func TestOtherTenantsRowsAreNotFound(t *testing.T) {
+	phones, err := phone.NewParser("DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contacts := newFakeContactRepository(
+		&domain.Contact{ID: contactA, OwnerID: "tenant-a", FullName: "Ada Lovelace"},
+		&domain.Contact{ID: contactB, OwnerID: "tenant-b", FullName: "Bob Babbage"},
+	)
+	groups := newFakeGroupRepository(contacts,
+		&domain.Group{ID: groupA, OwnerID: "tenant-a", Name: "Family"},
+		&domain.Group{ID: groupB, OwnerID: "tenant-b", Name: "Work"},
+	)
+	contactUseCase := NewContactUseCase(contacts, nil, phones)
+	groupUseCase := NewGroupUseCase(groups, contacts)
+	ctx := tenantContext("tenant-b")
+
+	tests := []struct {
+		name string
+		op   func(contactID, groupID string) error
+	}{
+		{"get contact", func(contactID, groupID string) error {
+			_, err := contactUseCase.GetContactByID(ctx, contactID)
+			return err
+		}},
+		{"update contact", func(contactID, groupID string) error {
+			return contactUseCase.UpdateContact(ctx, &domain.Contact{ID: contactID, FullName: "Eve"})
+		}},
+		{"delete contact", func(contactID, groupID string) error {
+			return contactUseCase.DeleteContact(ctx, contactID)
+		}},
+		{"add contact to own group", func(contactID, groupID string) error {
+			return groupUseCase.AddContactToGroup(ctx, contactID, groupB)
+		}},
+		{"add own contact to group", func(contactID, groupID string) error {
+			return groupUseCase.AddContactToGroup(ctx, contactB, groupID)
+		}},
+		{"get group", func(contactID, groupID string) error {
+			_, err := groupUseCase.GetGroupByID(ctx, groupID)
+			return err
+		}},
+		{"list group contacts", func(contactID, groupID string) error {
+			_, err := groupUseCase.GetGroupContacts(ctx, groupID)
+			return err
+		}},
+		{"merge into own contact", func(contactID, groupID string) error {
+			_, err := contactUseCase.MergeContacts(ctx, contactB, []string{contactID})
+			return err
+		}},
+		{"merge own contact away", func(contactID, groupID string) error {
+			_, err := contactUseCase.MergeContacts(ctx, contactID, []string{contactB})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			foreign := tt.op(contactA, groupA)
+			missing := tt.op(nowhere, nowhere)
+			if !errors.Is(foreign, domain.ErrNotFound) || foreign != missing {
+				t.Errorf("on tenant A's rows: %v, on missing rows: %v, want ErrNotFound for both", foreign, missing)
+			}
+		})
+	}
+
+	a, err := contactUseCase.GetContactByID(tenantContext("tenant-a"), contactA)
+	if err != nil || a.FullName != "Ada Lovelace" {
+		t.Errorf("tenant A's contact is now %+v, %v", a, err)
+	}
+	if len(groups.members[groupA]) != 0 || len(groups.members[groupB]) != 0 {
+		t.Errorf("group members = %v, want none", groups.members)
+	}
+	if _, err := contactUseCase.GetContactByID(ctx, contactB); err != nil {
+		t.Errorf("tenant B's own contact: %v", err)
+	}
+	if err := groupUseCase.AddContactToGroup(ctx, contactB, groupB); err != nil {
+		t.Errorf("tenant B adding its contact to its group: %v", err)
+	}
+}
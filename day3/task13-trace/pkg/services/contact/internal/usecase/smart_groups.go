@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/rule"
+)
+
+// checkRule rejects a smart group rule that does not parse.
+func checkRule(text string) error {
+	if text == "" {
+		return nil
+	}
+	if _, err := rule.Parse(text); err != nil {
+		return &domain.ValidationError{Field: "Rule", Reason: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxSyncChanges = 500
+	// syncPullLimit is how many feed entries one sync reads.
+	syncPullLimit = 1000
+	// syncAttempts bounds how often a change is merged again after the
+	// contact changed between reading and writing it.
+	syncAttempts = 3
+)
+
+type syncUseCaseImpl struct {
+	contactRepo repository.ContactRepository
+	changeRepo  repository.ChangeRepository
+	phones      *phone.Parser
+	now         func() time.Time
+}
+
+func NewSyncUseCase(contactRepo repository.ContactRepository, changeRepo repository.ChangeRepository, phones *phone.Parser) SyncUseCase {
+	return &syncUseCaseImpl{
+		contactRepo: contactRepo,
+		changeRepo:  changeRepo,
+		phones:      phones,
+		now:         time.Now,
+	}
+}
+
+// Sync applies the pushed changes one by one, then pulls. Each change is
+// applied or not on its own; if Sync fails halfway the client pushes the
+// same changes again, which is safe: an update that already made it finds
+// its values in place and applies as a no-op, and a create or delete is a
+// conflict or no-op whose outcome the pull shows.
+func (uc *syncUseCaseImpl) Sync(ctx context.Context, request *domain.SyncRequest) (*domain.SyncResponse, error) {
+	since, err := decodeSyncToken(request.Token)
+	if err != nil {
+		return nil, err
+	}
+	strategy := request.Strategy
+	if strategy == "" {
+		strategy = domain.SyncReport
+	}
+	if strategy != domain.SyncReport && strategy != domain.SyncLastWriterWins {
+		return nil, &domain.ValidationError{Field: "Strategy", Reason: "must be report or lww"}
+	}
+	if len(request.Changes) > MaxSyncChanges {
+		return nil, &domain.ValidationError{Field: "Changes", Reason: "must hold at most 500 changes"}
+	}
+
+	response := &domain.SyncResponse{Results: make([]domain.SyncResult, 0, len(request.Changes))}
+	for _, change := range request.Changes {
+		result, err := uc.apply(ctx, change, strategy)
+		if err != nil {
+			return nil, err
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if err := uc.pull(ctx, since, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *syncUseCaseImpl) apply(ctx context.Context, change domain.SyncChange, strategy string) (domain.SyncResult, error) {
+	result := domain.SyncResult{ID: change.ID, Status: domain.SyncApplied}
+
+	if _, err := uuid.Parse(change.ID); err != nil {
+		return rejected(result, &domain.ValidationError{Field: "ID", Reason: "must be a UUID"})
+	}
+	fields, err := uc.normalizeFields(change.Fields)
+	if err != nil {
+		return rejected(result, err)
+	}
+	// A change from the future would win every later conflict.
+	if now := uc.now(); change.ModifiedAt.IsZero() || change.ModifiedAt.After(now) {
+		change.ModifiedAt = now
+	}
+
+	for attempt := 1; ; attempt++ {
+		switch change.Op {
+		case domain.SyncCreate:
+			err = uc.create(ctx, change, fields, &result)
+		case domain.SyncUpdate:
+			err = uc.update(ctx, change, fields, strategy, &result)
+		case domain.SyncDelete:
+			err = uc.delete(ctx, change, strategy, &result)
+		default:
+			err = &domain.ValidationError{Field: "Op", Reason: "must be create, update or delete"}
+		}
+		if !errors.Is(err, domain.ErrVersionConflict) {
+			break
+		}
+		if attempt == syncAttempts {
+			result.Status = domain.SyncConflict
+			result.Error = "the contact kept changing on the server; sync again"
+			return result, nil
+		}
+		result = domain.SyncResult{ID: change.ID, Status: domain.SyncApplied}
+	}
+	if errors.Is(err, domain.ErrValidation) {
+		return rejected(result, err)
+	}
+	return result, err
+}
+
+func rejected(result domain.SyncResult, err error) (domain.SyncResult, error) {
+	result.Status = domain.SyncRejected
+	result.Version = 0
+	result.Conflicts = nil
+	result.Error = err.Error()
+	return result, nil
+}
+
+// normalizeFields checks the field names and brings the phone number into
+// the stored form, so that it compares equal to the server's value.
+func (uc *syncUseCaseImpl) normalizeFields(fields map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(fields))
+	probe := &domain.Contact{}
+	for name, value := range fields {
+		if !probe.SetField(name, value) {
+			return nil, &domain.ValidationError{Field: name, Reason: "is not a contact field"}
+		}
+		if name == "PhoneNumber" && value != "" {
+			var err error
+			value, err = uc.phones.Normalize(value)
+			if err != nil {
+				return nil, &domain.ValidationError{Field: "PhoneNumber", Reason: "is not a valid phone number"}
+			}
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
+
+// create stores a contact made offline under the ID the client chose. IDs
+// are unique across tenants, so a taken one is a conflict whoever holds
+// it; a retried create gets the conflict too and the contact in the pull.
+func (uc *syncUseCaseImpl) create(ctx context.Context, change domain.SyncChange, fields map[string]string, result *domain.SyncResult) error {
+	contact := &domain.Contact{ID: change.ID}
+	for name, value := range fields {
+		contact.SetField(name, value)
+	}
+	if err := contact.Validate(); err != nil {
+		return err
+	}
+	err := uc.contactRepo.CreateContact(ctx, contact)
+	if errors.Is(err, domain.ErrIDInUse) {
+		result.Status = domain.SyncConflict
+		result.Error = "a contact with this ID exists"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result.Version = contact.Version
+	return nil
+}
+
+// update merges the client's fields into the contact field by field. A
+// field the server has not changed since the client's base version takes
+// the client's value; one it has is a conflict for the strategy to decide.
+func (uc *syncUseCaseImpl) update(ctx context.Context, change domain.SyncChange, fields map[string]string, strategy string, result *domain.SyncResult) error {
+	current, clock, err := uc.contactRepo.GetContactClock(ctx, change.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		result.Status = domain.SyncConflict
+		result.Error = "the contact was deleted on the server"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	merged := *current
+	stamp := domain.FieldStamp{Version: current.Version + 1, ModifiedAt: change.ModifiedAt}
+	changed := false
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		serverValue, _ := current.Field(name)
+		if value == serverValue {
+			continue
+		}
+		if serverStamp := clock[name]; serverStamp.Version > change.BaseVersion {
+			conflict := domain.FieldConflict{
+				Field:         name,
+				ClientValue:   value,
+				ServerValue:   serverValue,
+				ServerVersion: serverStamp.Version,
+				Resolution:    "server",
+			}
+			if strategy == domain.SyncLastWriterWins && change.ModifiedAt.After(serverStamp.ModifiedAt) {
+				conflict.Resolution = "client"
+			}
+			result.Conflicts = append(result.Conflicts, conflict)
+			if conflict.Resolution == "server" {
+				result.Status = domain.SyncConflict
+				continue
+			}
+		}
+		merged.SetField(name, value)
+		clock[name] = stamp
+		changed = true
+	}
+
+	result.Version = current.Version
+	if !changed {
+		return nil
+	}
+	if err := merged.Validate(); err != nil {
+		return err
+	}
+	if err := uc.contactRepo.CompareAndUpdateContact(ctx, &merged, clock); err != nil {
+		return err
+	}
+	result.Version = merged.Version
+	return nil
+}
+
+// delete removes the contact unless the server changed a field of it after
+// the client's base version; that is a conflict for the strategy to decide.
+func (uc *syncUseCaseImpl) delete(ctx context.Context, change domain.SyncChange, strategy string, result *domain.SyncResult) error {
+	current, clock, err := uc.contactRepo.GetContactClock(ctx, change.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var latest *domain.FieldStamp
+	for _, stamp := range clock {
+		if stamp.Version > change.BaseVersion && (latest == nil || stamp.ModifiedAt.After(latest.ModifiedAt)) {
+			stamp := stamp
+			latest = &stamp
+		}
+	}
+	if latest != nil && !(strategy == domain.SyncLastWriterWins && change.ModifiedAt.After(latest.ModifiedAt)) {
+		result.Status = domain.SyncConflict
+		result.Version = current.Version
+		result.Error = "the contact changed on the server after version " + strconv.FormatInt(change.BaseVersion, 10)
+		return nil
+	}
+
+	return uc.contactRepo.CompareAndDeleteContact(ctx, change.ID, current.Version)
+}
+
+// pull adds the contacts changed after since, as they are at the end of
+// the page read, and the ones deleted.
+func (uc *syncUseCaseImpl) pull(ctx context.Context, since int64, response *domain.SyncResponse) error {
+	changes, err := uc.changeRepo.GetChanges(ctx, since, syncPullLimit)
+	if err != nil {
+		return err
+	}
+
+	var order []string
+	contacts := make(map[string]*domain.Contact)
+	deleted := make(map[string]bool)
+	for _, change := range changes {
+		since = change.Seq
+		switch change.Type {
+		case domain.EventContactCreated, domain.EventContactUpdated:
+			contact := &domain.Contact{}
+			if err := json.Unmarshal(change.Data, contact); err != nil {
+				return err
+			}
+			if contacts[contact.ID] == nil && !deleted[contact.ID] {
+				order = append(order, contact.ID)
+			}
+			contacts[contact.ID] = contact
+			delete(deleted, contact.ID)
+		case domain.EventContactDeleted:
+			var event domain.ContactDeleted
+			if err := json.Unmarshal(change.Data, &event); err != nil {
+				return err
+			}
+			if contacts[event.ID] == nil && !deleted[event.ID] {
+				order = append(order, event.ID)
+			}
+			delete(contacts, event.ID)
+			deleted[event.ID] = true
+		}
+	}
+
+	response.Contacts = []*domain.Contact{}
+	response.Deleted = []string{}
+	for _, id := range order {
+		if contact := contacts[id]; contact != nil {
+			response.Contacts = append(response.Contacts, contact)
+		} else {
+			response.Deleted = append(response.Deleted, id)
+		}
+	}
+	response.Token = encodeSyncToken(since)
+	response.HasMore = len(changes) == syncPullLimit
+	return nil
+}
+
+// Sync tokens wrap a position in the tenant's change feed. They are opaque
+// to clients so that their form can change.
+const syncTokenPrefix = "1:"
+
+func encodeSyncToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+func decodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	invalid := &domain.ValidationError{Field: "Token", Reason: "is not a sync token"}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, invalid
+	}
+	rest, ok := strings.CutPrefix(string(data), syncTokenPrefix)
+	if !ok {
+		return 0, invalid
+	}
+	seq, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, invalid
+	}
+	return seq, nil
+}
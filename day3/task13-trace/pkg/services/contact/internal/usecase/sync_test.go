@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+)
+
+const (
+	syncContactID = "9b2f6a1e-0c4d-4e8a-9f3b-1a2b3c4d5e6f"
+	syncOtherID   = "0c1d2e3f-4a5b-4c6d-8e7f-8a9b0c1d2e3f"
+)
+
+var syncTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestSyncUseCase(t *testing.T, contacts *fakeContactRepository) *syncUseCaseImpl {
+	phones, err := phone.NewParser("DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := NewSyncUseCase(contacts, &fakeChangeRepository{contacts: contacts}, phones).(*syncUseCaseImpl)
+	uc.now = func() time.Time { return syncTime }
+	return uc
+}
+
+func TestSyncCreateOfTakenIDDoesNotTellTenantsApart(t *testing.T) {
+	contacts := newFakeContactRepository(&domain.Contact{ID: syncContactID, OwnerID: "tenant-a", FullName: "Ada"})
+	uc := newTestSyncUseCase(t, contacts)
+	create := &domain.SyncRequest{Changes: []domain.SyncChange{{
+		Op:     domain.SyncCreate,
+		ID:     syncContactID,
+		Fields: map[string]string{"FullName": "Ada Lovelace"},
+	}}}
+
+	own, err := uc.Sync(tenantContext("tenant-a"), create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := uc.Sync(tenantContext("tenant-b"), create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := domain.SyncResult{ID: syncContactID, Status: domain.SyncConflict, Error: "a contact with this ID exists"}
+	for tenant, response := range map[string]*domain.SyncResponse{"tenant-a": own, "tenant-b": other} {
+		if !reflect.DeepEqual(response.Results, []domain.SyncResult{want}) {
+			t.Errorf("%s: results = %+v, want %+v", tenant, response.Results, want)
+		}
+	}
+	if len(other.Contacts) != 0 || contacts.contacts[syncContactID].FullName != "Ada" {
+		t.Errorf("another tenant's create touched the contact: pulled %d, name %q", len(other.Contacts), contacts.contacts[syncContactID].FullName)
+	}
+
+	create.Changes[0].ID = syncOtherID
+	fresh, err := uc.Sync(tenantContext("tenant-b"), create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fresh.Results[0]; got.Status != domain.SyncApplied || got.Version != 1 {
+		t.Errorf("create under a free ID = %+v, want applied at version 1", got)
+	}
+}
+
+// syncedContact is at version 3: FullName last changed in it, Email in
+// version 2 and the rest when the contact was created.
+func syncedContact() *fakeContactRepository {
+	contacts := newFakeContactRepository(&domain.Contact{
+		ID: syncContactID, OwnerID: "tenant-a", Version: 3,
+		FullName: "Ada", Email: "ada@old.example", PhoneNumber: "+49301234567",
+	})
+	contacts.clocks[syncContactID] = domain.FieldClock{
+		"FullName": {Version: 3, ModifiedAt: syncTime.Add(-time.Hour)},
+		"Email":    {Version: 2, ModifiedAt: syncTime.Add(-2 * time.Hour)},
+	}
+	return contacts
+}
+
+func TestSyncUpdate(t *testing.T) {
+	serverChange := syncTime.Add(-time.Hour)
+	tests := []struct {
+		name        string
+		strategy    string
+		baseVersion int64
+		modifiedAt  time.Time
+		fields      map[string]string
+		wantStatus  string
+		// wantConflicts maps the conflicting fields to their resolution.
+		wantConflicts map[string]string
+		wantContact   map[string]string
+		wantVersion   int64
+	}{
+		{"field unchanged on the server", "", 2, serverChange, map[string]string{"Email": "ada@new.example"},
+			domain.SyncApplied, nil, map[string]string{"FullName": "Ada", "Email": "ada@new.example"}, 4},
+		{"field changed on the server", "", 2, serverChange.Add(time.Minute), map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncConflict, map[string]string{"FullName": "server"}, map[string]string{"FullName": "Ada"}, 3},
+		{"changed since the latest base", "", 3, serverChange, map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncApplied, nil, map[string]string{"FullName": "Ada Lovelace"}, 4},
+		{"conflict and clean field together", "", 2, serverChange, map[string]string{"FullName": "Ada Lovelace", "Email": "ada@new.example"},
+			domain.SyncConflict, map[string]string{"FullName": "server"}, map[string]string{"FullName": "Ada", "Email": "ada@new.example"}, 4},
+		{"lww with the later change", domain.SyncLastWriterWins, 2, serverChange.Add(time.Minute), map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncApplied, map[string]string{"FullName": "client"}, map[string]string{"FullName": "Ada Lovelace"}, 4},
+		{"lww with the earlier change", domain.SyncLastWriterWins, 2, serverChange.Add(-time.Minute), map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncConflict, map[string]string{"FullName": "server"}, map[string]string{"FullName": "Ada"}, 3},
+		{"lww on a tie", domain.SyncLastWriterWins, 2, serverChange, map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncConflict, map[string]string{"FullName": "server"}, map[string]string{"FullName": "Ada"}, 3},
+		{"lww from the future", domain.SyncLastWriterWins, 2, syncTime.Add(time.Hour), map[string]string{"FullName": "Ada Lovelace"},
+			domain.SyncApplied, map[string]string{"FullName": "client"}, map[string]string{"FullName": "Ada Lovelace"}, 4},
+		{"server's value", "", 1, serverChange, map[string]string{"FullName": "Ada"},
+			domain.SyncApplied, nil, map[string]string{"FullName": "Ada"}, 3},
+		{"phone number as typed", "", 1, serverChange, map[string]string{"PhoneNumber": "030/1234567"},
+			domain.SyncApplied, nil, map[string]string{"PhoneNumber": "+49301234567"}, 3},
+		{"unknown field", "", 3, serverChange, map[string]string{"Nickname": "Ada"},
+			domain.SyncRejected, nil, map[string]string{"FullName": "Ada"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts := syncedContact()
+			uc := newTestSyncUseCase(t, contacts)
+			response, err := uc.Sync(tenantContext("tenant-a"), &domain.SyncRequest{Strategy: tt.strategy, Changes: []domain.SyncChange{{
+				Op: domain.SyncUpdate, ID: syncContactID, BaseVersion: tt.baseVersion, ModifiedAt: tt.modifiedAt, Fields: tt.fields,
+			}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := response.Results[0]
+			conflicts := map[string]string{}
+			for _, conflict := range result.Conflicts {
+				conflicts[conflict.Field] = conflict.Resolution
+			}
+			if result.Status != tt.wantStatus || result.Version != tt.wantVersion || len(conflicts) != len(tt.wantConflicts) {
+				t.Fatalf("result = %+v, want %s at version %d with conflicts %v", result, tt.wantStatus, tt.wantVersion, tt.wantConflicts)
+			}
+			for field, resolution := range tt.wantConflicts {
+				if conflicts[field] != resolution {
+					t.Errorf("%s resolved for the %s, want the %s", field, conflicts[field], resolution)
+				}
+			}
+
+			stored := contacts.contacts[syncContactID]
+			for field, want := range tt.wantContact {
+				if got, _ := stored.Field(field); got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+			if result.Status == domain.SyncRejected {
+				return
+			}
+			// Every field the change wrote is stamped with the new version
+			// at the time of the change, or now for one from the future.
+			modifiedAt := tt.modifiedAt
+			if modifiedAt.After(syncTime) {
+				modifiedAt = syncTime
+			}
+			for field := range tt.fields {
+				stamp := contacts.clocks[syncContactID][field]
+				written := tt.wantVersion == 4 && tt.wantConflicts[field] != "server"
+				if written && (stamp.Version != 4 || !stamp.ModifiedAt.Equal(modifiedAt)) {
+					t.Errorf("%s stamped %+v, want version 4 at %v", field, stamp, modifiedAt)
+				}
+				if !written && stamp.Version == 4 {
+					t.Errorf("%s stamped %+v though not written", field, stamp)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		baseVersion int64
+		modifiedAt  time.Time
+		wantStatus  string
+		wantDeleted bool
+	}{
+		{"at the latest version", "", 3, syncTime, domain.SyncApplied, true},
+		{"after an update on the server", "", 2, syncTime, domain.SyncConflict, false},
+		{"lww after the update", domain.SyncLastWriterWins, 2, syncTime.Add(-time.Minute), domain.SyncApplied, true},
+		{"lww before the update", domain.SyncLastWriterWins, 2, syncTime.Add(-90 * time.Minute), domain.SyncConflict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts := syncedContact()
+			uc := newTestSyncUseCase(t, contacts)
+			response, err := uc.Sync(tenantContext("tenant-a"), &domain.SyncRequest{Strategy: tt.strategy, Changes: []domain.SyncChange{{
+				Op: domain.SyncDelete, ID: syncContactID, BaseVersion: tt.baseVersion, ModifiedAt: tt.modifiedAt,
+			}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := response.Results[0]
+			_, stored := contacts.contacts[syncContactID]
+			if result.Status != tt.wantStatus || stored == tt.wantDeleted {
+				t.Errorf("result = %+v, contact kept %v; want %s, deleted %v", result, stored, tt.wantStatus, tt.wantDeleted)
+			}
+			if result.Status == domain.SyncConflict && result.Version != 3 {
+				t.Errorf("conflict at version %d, want the server's 3", result.Version)
+			}
+		})
+	}
+
+	// Once the contact is gone, a delete is a no-op and an update a
+	// conflict.
+	contacts := newFakeContactRepository()
+	uc := newTestSyncUseCase(t, contacts)
+	response, err := uc.Sync(tenantContext("tenant-a"), &domain.SyncRequest{Changes: []domain.SyncChange{
+		{Op: domain.SyncDelete, ID: syncContactID, BaseVersion: 3},
+		{Op: domain.SyncUpdate, ID: syncContactID, BaseVersion: 3, Fields: map[string]string{"FullName": "Ada"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Results; got[0].Status != domain.SyncApplied || got[1].Status != domain.SyncConflict || got[1].Error == "" {
+		t.Errorf("results on a deleted contact = %+v", got)
+	}
+}
+
+// racingContactRepository lets another writer change the contact before
+// each of the first races writes of a sync.
+type racingContactRepository struct {
+	*fakeContactRepository
+	races  int
+	writes int
+}
+
+func (r *racingContactRepository) CompareAndUpdateContact(ctx context.Context, contact *domain.Contact, clock domain.FieldClock) error {
+	r.writes++
+	if r.writes <= r.races {
+		r.contacts[contact.ID].Version++
+	}
+	return r.fakeContactRepository.CompareAndUpdateContact(ctx, contact, clock)
+}
+
+func TestSyncRetriesAfterConcurrentWrites(t *testing.T) {
+	tests := []struct {
+		races      int
+		wantStatus string
+		wantWrites int
+	}{
+		{0, domain.SyncApplied, 1},
+		{syncAttempts - 1, domain.SyncApplied, syncAttempts},
+		{syncAttempts, domain.SyncConflict, syncAttempts},
+	}
+	for _, tt := range tests {
+		contacts := &racingContactRepository{fakeContactRepository: syncedContact(), races: tt.races}
+		uc := newTestSyncUseCase(t, contacts.fakeContactRepository)
+		uc.contactRepo = contacts
+		response, err := uc.Sync(tenantContext("tenant-a"), &domain.SyncRequest{Changes: []domain.SyncChange{{
+			Op: domain.SyncUpdate, ID: syncContactID, BaseVersion: 3, Fields: map[string]string{"Email": "ada@new.example"},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := response.Results[0]
+		if result.Status != tt.wantStatus || contacts.writes != tt.wantWrites {
+			t.Errorf("%d races: result = %+v after %d writes, want %s after %d", tt.races, result, contacts.writes, tt.wantStatus, tt.wantWrites)
+		}
+		if tt.wantStatus == domain.SyncApplied && result.Version != contacts.contacts[syncContactID].Version {
+			t.Errorf("%d races: result version %d, stored %d", tt.races, result.Version, contacts.contacts[syncContactID].Version)
+		}
+	}
+}
+
+func TestSyncPull(t *testing.T) {
+	contacts := newFakeContactRepository()
+	uc := newTestSyncUseCase(t, contacts)
+	ctx := tenantContext("tenant-a")
+	sync := func(token string, changes ...domain.SyncChange) *domain.SyncResponse {
+		t.Helper()
+		response, err := uc.Sync(ctx, &domain.SyncRequest{Token: token, Changes: changes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	first := sync("",
+		domain.SyncChange{Op: domain.SyncCreate, ID: syncContactID, Fields: map[string]string{"FullName": "Ada"}},
+		domain.SyncChange{Op: domain.SyncUpdate, ID: syncContactID, BaseVersion: 1, Fields: map[string]string{"FullName": "Ada Lovelace"}},
+		domain.SyncChange{Op: domain.SyncCreate, ID: syncOtherID, Fields: map[string]string{"FullName": "Bob"}},
+		domain.SyncChange{Op: domain.SyncDelete, ID: syncOtherID, BaseVersion: 1},
+	)
+	if len(first.Contacts) != 1 || first.Contacts[0].FullName != "Ada Lovelace" || !reflect.DeepEqual(first.Deleted, []string{syncOtherID}) {
+		t.Errorf("first pull = %+v and deleted %v, want Ada once as updated and Bob deleted", first.Contacts, first.Deleted)
+	}
+
+	if again := sync(first.Token); len(again.Contacts) != 0 || len(again.Deleted) != 0 || again.Token != first.Token {
+		t.Errorf("pull after the token = %+v", again)
+	}
+	next := sync(first.Token, domain.SyncChange{Op: domain.SyncDelete, ID: syncContactID, BaseVersion: 2})
+	if len(next.Contacts) != 0 || !reflect.DeepEqual(next.Deleted, []string{syncContactID}) {
+		t.Errorf("pull after a delete = %+v and deleted %v", next.Contacts, next.Deleted)
+	}
+}
+
+func TestSyncToken(t *testing.T) {
+	for _, seq := range []int64{0, 1, 1000, 1<<63 - 1} {
+		if got, err := decodeSyncToken(encodeSyncToken(seq)); err != nil || got != seq {
+			t.Errorf("decodeSyncToken(encodeSyncToken(%d)) = %d, %v", seq, got, err)
+		}
+	}
+	if seq, err := decodeSyncToken(""); err != nil || seq != 0 {
+		t.Errorf("decodeSyncToken(\"\") = %d, %v, want the start of the feed", seq, err)
+	}
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, token := range []string{"1:5", "!!", encode("2:5"), encode("1:"), encode("1:-1"), encode("1:5x"), encode("5")} {
+		var validation *domain.ValidationError
+		if _, err := decodeSyncToken(token); !errors.As(err, &validation) || validation.Field != "Token" {
+			t.Errorf("decodeSyncToken(%q) = %v, want a validation error", token, err)
+		}
+	}
+}
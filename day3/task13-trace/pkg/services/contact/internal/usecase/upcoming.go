@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+// MaxUpcomingDays bounds how far ahead GetUpcomingEvents looks: a year,
+// so every date comes round once.
+const MaxUpcomingDays = 365
+
+// GetUpcomingEvents returns the birthdays and anniversaries that come
+// round from today, as it is in loc, up to days later, soonest first.
+func (uc *contactUseCaseImpl) GetUpcomingEvents(ctx context.Context, days int, loc *time.Location) ([]domain.UpcomingEvent, error) {
+	if days < 0 || days > MaxUpcomingDays {
+		return nil, &domain.ValidationError{Field: "days", Reason: "must be between 0 and " + strconv.Itoa(MaxUpcomingDays)}
+	}
+	today := domain.DateOf(time.Now().In(loc))
+
+	contacts, err := uc.contactRepo.FindContacts(ctx, &domain.ContactFilter{EventDays: domain.EventDaysWithin(today, days)})
+	if err != nil {
+		return nil, err
+	}
+
+	events := []domain.UpcomingEvent{}
+	for _, contact := range contacts {
+		events = append(events, contact.UpcomingEvents(today, days)...)
+	}
+	// The contacts come sorted by name, which the stable sort keeps within
+	// a day.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DaysUntil < events[j].DaysUntil
+	})
+	return events, nil
+}
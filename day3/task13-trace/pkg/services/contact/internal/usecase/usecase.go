@@ -1,17 +1,85 @@
 package usecase
 
-import "go/pkg/services/contact/internal/domain"
+import (
+    "context"
+    "io"
+    "time"
+
+    "go/pkg/auth"
+    "go/pkg/services/contact/internal/domain"
+)
 
 type ContactUseCase interface {
-    CreateContact(contact *domain.Contact) error
-    UpdateContact(contact *domain.Contact) error
-    DeleteContact(contactID string) error
-    GetContactByID(contactID string) (*domain.Contact, error)
+    ValidateContact(ctx context.Context, contact *domain.Contact) error
+    CreateContact(ctx context.Context, contact *domain.Contact) error
+    CreateContacts(ctx context.Context, contacts []*domain.Contact) error
+    UpdateContact(ctx context.Context, contact *domain.Contact) error
+    DeleteContact(ctx context.Context, contactID string) error
+    GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error)
+    LookupContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error)
+    GetAllContacts(ctx context.Context) ([]*domain.Contact, error)
+    FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error)
+    FindDuplicates(ctx context.Context) ([]*domain.DuplicateGroup, error)
+    MergeContacts(ctx context.Context, survivorID string, duplicateIDs []string) (*domain.Contact, error)
+    GetUpcomingEvents(ctx context.Context, days int, loc *time.Location) ([]domain.UpcomingEvent, error)
+}
+
+type CustomFieldUseCase interface {
+    DefineCustomField(ctx context.Context, field *domain.CustomField) error
+    GetCustomFields(ctx context.Context) ([]*domain.CustomField, error)
+    DeleteCustomField(ctx context.Context, name string) error
+}
+
+type AvatarUseCase interface {
+    SetAvatar(ctx context.Context, contactID string, data []byte) (*domain.Avatar, error)
+    GetAvatar(ctx context.Context, contactID string) (*domain.Avatar, error)
+    // OpenAvatar reads the photo of avatar in size, as returned by GetAvatar.
+    OpenAvatar(ctx context.Context, avatar *domain.Avatar, size string) (io.ReadCloser, error)
+    DeleteAvatar(ctx context.Context, contactID string) error
+}
+
+type NoteUseCase interface {
+    AddNote(ctx context.Context, note *domain.Note) error
+    GetNotes(ctx context.Context, contactID, noteType, cursor string, limit int) (*domain.NotePage, error)
+    GetHistory(ctx context.Context, contactID, cursor string, limit int) (*domain.HistoryPage, error)
 }
 
 type GroupUseCase interface {
-    CreateGroup(group *domain.Group) error
-    GetGroupByID(groupID string) (*domain.Group, error)
-    GetAllGroups() ([]*domain.Group, error)
-    AddContactToGroup(contactID, groupID string) error
+    CreateGroup(ctx context.Context, group *domain.Group) error
+    UpdateGroup(ctx context.Context, group *domain.Group) error
+    DeleteGroup(ctx context.Context, groupID string) error
+    GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error)
+    GetAllGroups(ctx context.Context) ([]*domain.Group, error)
+    GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error)
+    AddContactToGroup(ctx context.Context, contactID, groupID string) error
+    GetGroupSubtree(ctx context.Context, groupID string) ([]*domain.Group, error)
+    GetEffectiveMembers(ctx context.Context, groupID string) ([]*domain.Contact, error)
+}
+
+type APIKeyUseCase interface {
+    IssueAPIKey(ctx context.Context, key *auth.APIKey) (string, error)
+    GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error)
+    RevokeAPIKey(ctx context.Context, keyID string) error
+    AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.APIKey, error)
+}
+
+type WebhookUseCase interface {
+    CreateWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error
+    GetWebhooks(ctx context.Context) ([]*domain.WebhookSubscription, error)
+    DeleteWebhook(ctx context.Context, subscriptionID string) error
+    GetWebhookDeliveries(ctx context.Context, subscriptionID, status string) ([]*domain.WebhookDelivery, error)
+}
+
+type ChangeUseCase interface {
+    // GetChanges returns the changes after since. With a positive wait it
+    // waits up to that long for the first one instead of returning none.
+    GetChanges(ctx context.Context, since int64, limit int, wait time.Duration) ([]*domain.Change, error)
+}
+
+type SyncUseCase interface {
+    Sync(ctx context.Context, request *domain.SyncRequest) (*domain.SyncResponse, error)
+}
+
+type BatchUseCase interface {
+    ApplyBatch(ctx context.Context, request *domain.BatchRequest) (*domain.BatchResponse, error)
 }
@@ -1,110 +1,276 @@
 package usecase
 
 import (
+    "context"
+
+    "go/pkg/phone"
     "go/pkg/services/contact/internal/domain"
     "go/pkg/services/contact/internal/repository"
 )
 
 type contactUseCaseImpl struct {
     contactRepo repository.ContactRepository
+    fieldRepo   repository.CustomFieldRepository
+    phones      *phone.Parser
 }
 
-func NewContactUseCase(contactRepo repository.ContactRepository) ContactUseCase {
+func NewContactUseCase(contactRepo repository.ContactRepository, fieldRepo repository.CustomFieldRepository, phones *phone.Parser) ContactUseCase {
     return &contactUseCaseImpl{
         contactRepo: contactRepo,
+        fieldRepo:   fieldRepo,
+        phones:      phones,
     }
 }
 
-func (uc *contactUseCaseImpl) CreateContact(contact *domain.Contact) error {
-	err := uc.contactRepo.SaveContact(contact)
+func (uc *contactUseCaseImpl) ValidateContact(ctx context.Context, contact *domain.Contact) error {
+	var fields []*domain.CustomField
+	return uc.validateContact(ctx, contact, &fields)
+}
+
+// validateContact loads the tenant's custom fields into *fields when the
+// first contact that has custom fields needs them, so validating many
+// contacts loads them once.
+func (uc *contactUseCaseImpl) validateContact(ctx context.Context, contact *domain.Contact, fields *[]*domain.CustomField) error {
+	contact.Tags = domain.NormalizeTags(contact.Tags)
+	if contact.PhoneNumber != "" {
+		normalized, err := uc.phones.Normalize(contact.PhoneNumber)
+		if err != nil {
+			return &domain.ValidationError{Field: "PhoneNumber", Reason: "is not a valid phone number"}
+		}
+		contact.PhoneNumber = normalized
+	}
+	if err := contact.Validate(); err != nil {
+		return err
+	}
+
+	if len(contact.CustomFields) > 0 {
+		if *fields == nil {
+			defined, err := uc.fieldRepo.GetCustomFields(ctx)
+			if err != nil {
+				return err
+			}
+			*fields = defined
+		}
+		if err := contact.CheckCustomFields(*fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *contactUseCaseImpl) CreateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := uc.ValidateContact(ctx, contact); err != nil {
+		return err
+	}
+	// Only sync creates contacts under IDs that clients choose.
+	contact.ID = ""
+
+	err := uc.contactRepo.CreateContact(ctx, contact)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (uc *contactUseCaseImpl) UpdateContact(contact *domain.Contact) error {
-	existingContact, err := uc.contactRepo.GetContactByID(contact.ID)
+func (uc *contactUseCaseImpl) CreateContacts(ctx context.Context, contacts []*domain.Contact) error {
+	var fields []*domain.CustomField
+	for _, contact := range contacts {
+		if err := uc.validateContact(ctx, contact, &fields); err != nil {
+			return err
+		}
+	}
+
+	err := uc.contactRepo.CreateContacts(ctx, contacts)
 	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	existingContact.Name = contact.Name
+func (uc *contactUseCaseImpl) UpdateContact(ctx context.Context, contact *domain.Contact) error {
+	if err := uc.ValidateContact(ctx, contact); err != nil {
+		return err
+	}
+
+	existingContact, err := uc.contactRepo.GetContactByID(ctx, contact.ID)
+	if err != nil {
+		return err
+	}
+
+	existingContact.FullName = contact.FullName
+	existingContact.FirstName = contact.FirstName
+	existingContact.Patronymic = contact.Patronymic
+	existingContact.PhoneNumber = contact.PhoneNumber
 	existingContact.Email = contact.Email
-	existingContact.Phone = contact.Phone
+	existingContact.Address = contact.Address
+	existingContact.Tags = contact.Tags
+	existingContact.CustomFields = contact.CustomFields
+	existingContact.Birthday = contact.Birthday
+	existingContact.Anniversaries = contact.Anniversaries
 
-	err = uc.contactRepo.SaveContact(existingContact)
+	err = uc.contactRepo.UpdateContact(ctx, existingContact)
 	if err != nil {
 		return err
 	}
 
+	*contact = *existingContact
 	return nil
 }
 
-func (uc *contactUseCaseImpl) DeleteContact(contactID string) error {
-	err := uc.contactRepo.DeleteContact(contactID)
+func (uc *contactUseCaseImpl) DeleteContact(ctx context.Context, contactID string) error {
+	err := uc.contactRepo.DeleteContact(ctx, contactID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (uc *contactUseCaseImpl) GetContactByID(contactID string) (*domain.Contact, error) {
-	contact, err := uc.contactRepo.GetContactByID(contactID)
+func (uc *contactUseCaseImpl) GetContactByID(ctx context.Context, contactID string) (*domain.Contact, error) {
+	contact, err := uc.contactRepo.GetContactByID(ctx, contactID)
+	if err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+func (uc *contactUseCaseImpl) LookupContactByPhone(ctx context.Context, phoneNumber string) (*domain.Contact, error) {
+	normalized, err := uc.phones.Normalize(phoneNumber)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "phone", Reason: "is not a valid phone number"}
+	}
+
+	contact, err := uc.contactRepo.GetContactByPhone(ctx, normalized)
 	if err != nil {
 		return nil, err
 	}
 	return contact, nil
 }
 
+func (uc *contactUseCaseImpl) GetAllContacts(ctx context.Context) ([]*domain.Contact, error) {
+	contacts, err := uc.contactRepo.GetAllContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (uc *contactUseCaseImpl) FindContacts(ctx context.Context, filter *domain.ContactFilter) ([]*domain.Contact, error) {
+	// The values are checked the way a contact's are, so "42" finds the
+	// number 42 and dates compare in their stored form.
+	probe := &domain.Contact{Tags: domain.NormalizeTags(filter.Tags), CustomFields: filter.CustomFields}
+	if len(probe.CustomFields) > 0 {
+		fields, err := uc.fieldRepo.GetCustomFields(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := probe.CheckCustomFields(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	contacts, err := uc.contactRepo.FindContacts(ctx, &domain.ContactFilter{Tags: probe.Tags, CustomFields: probe.CustomFields,
+		EventDays: filter.EventDays})
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
 type groupUseCaseImpl struct {
-    groupRepo repository.GroupRepository
+    groupRepo   repository.GroupRepository
+    contactRepo repository.ContactRepository
 }
 
-func NewGroupUseCase(groupRepo repository.GroupRepository) GroupUseCase {
+func NewGroupUseCase(groupRepo repository.GroupRepository, contactRepo repository.ContactRepository) GroupUseCase {
     return &groupUseCaseImpl{
-        groupRepo: groupRepo,
+        groupRepo:   groupRepo,
+        contactRepo: contactRepo,
     }
 }
 
-func (uc *groupUseCaseImpl) CreateGroup(group *domain.Group) error {
-	err := uc.groupRepo.SaveGroup(group)
+func (uc *groupUseCaseImpl) CreateGroup(ctx context.Context, group *domain.Group) error {
+	if err := checkRule(group.Rule); err != nil {
+		return err
+	}
+	if err := uc.checkParent(ctx, "", group.ParentID); err != nil {
+		return err
+	}
+
+	err := uc.groupRepo.CreateGroup(ctx, group)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (uc *groupUseCaseImpl) GetGroupByID(groupID string) (*domain.Group, error) {
-	group, err := uc.groupRepo.GetGroupByID(groupID)
+func (uc *groupUseCaseImpl) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	if err := checkRule(group.Rule); err != nil {
+		return err
+	}
+	if err := uc.checkParent(ctx, group.ID, group.ParentID); err != nil {
+		return err
+	}
+
+	err := uc.groupRepo.UpdateGroup(ctx, group)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uc *groupUseCaseImpl) DeleteGroup(ctx context.Context, groupID string) error {
+	err := uc.groupRepo.DeleteGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uc *groupUseCaseImpl) GetGroupByID(ctx context.Context, groupID string) (*domain.Group, error) {
+	group, err := uc.groupRepo.GetGroupByID(ctx, groupID)
 	if err != nil {
 		return nil, err
 	}
 	return group, nil
 }
 
-func (uc *groupUseCaseImpl) GetAllGroups() ([]*domain.Group, error) {
-	groups, err := uc.groupRepo.GetAllGroups()
+func (uc *groupUseCaseImpl) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
+	groups, err := uc.groupRepo.GetAllGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return groups, nil
 }
 
-func (uc *groupUseCaseImpl) AddContactToGroup(contactID, groupID string) error {
-	contact, err := uc.contactRepo.GetContactByID(contactID)
+func (uc *groupUseCaseImpl) GetGroupContacts(ctx context.Context, groupID string) ([]*domain.Contact, error) {
+	_, err := uc.groupRepo.GetGroupByID(ctx, groupID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	contacts, err := uc.groupRepo.GetGroupContacts(ctx, groupID)
+	if err != nil {
+		return nil, err
 	}
+	return contacts, nil
+}
 
-	group, err := uc.groupRepo.GetGroupByID(groupID)
+func (uc *groupUseCaseImpl) AddContactToGroup(ctx context.Context, contactID, groupID string) error {
+	_, err := uc.contactRepo.GetContactByID(ctx, contactID)
 	if err != nil {
 		return err
 	}
 
-	group.Contacts = append(group.Contacts, contact)
+	group, err := uc.groupRepo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if group.Rule != "" {
+		return &domain.ValidationError{Field: "GroupID", Reason: "is a smart group; its members follow its rule"}
+	}
 
-	err = uc.groupRepo.SaveGroup(group)
+	err = uc.groupRepo.AddContactToGroup(ctx, contactID, groupID)
 	if err != nil {
 		return err
 	}
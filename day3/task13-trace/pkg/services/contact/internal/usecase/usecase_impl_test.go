@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go/pkg/phone"
+	"go/pkg/services/contact/internal/domain"
+)
+
+func TestValidateContactNormalizesPhone(t *testing.T) {
+	phones, err := phone.NewParser("DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := &contactUseCaseImpl{phones: phones}
+
+	contact := &domain.Contact{FullName: "Max Mustermann", PhoneNumber: "030/1234567"}
+	if err := uc.ValidateContact(context.Background(), contact); err != nil {
+		t.Fatalf("ValidateContact: %v", err)
+	}
+	if contact.PhoneNumber != "+49301234567" {
+		t.Errorf("PhoneNumber = %q, want +49301234567", contact.PhoneNumber)
+	}
+
+	var invalid *domain.ValidationError
+	err = uc.ValidateContact(context.Background(), &domain.Contact{FullName: "Max Mustermann", PhoneNumber: "030 12"})
+	if !errors.As(err, &invalid) || invalid.Field != "PhoneNumber" {
+		t.Errorf("ValidateContact = %v, want a PhoneNumber validation error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+	"go/pkg/services/contact/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minWebhookSecretLength = 16
+	maxWebhookDeliveries   = 100
+)
+
+type webhookUseCaseImpl struct {
+	webhookRepo repository.WebhookRepository
+}
+
+func NewWebhookUseCase(webhookRepo repository.WebhookRepository) WebhookUseCase {
+	return &webhookUseCaseImpl{
+		webhookRepo: webhookRepo,
+	}
+}
+
+func (uc *webhookUseCaseImpl) CreateWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := validateWebhook(ctx, subscription); err != nil {
+		return err
+	}
+	if err := uc.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return err
+	}
+	subscription.Secret = ""
+	return nil
+}
+
+func validateWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return &domain.ValidationError{Field: "URL", Reason: "must be an absolute http or https URL"}
+	}
+	if err := webhook.CheckHost(ctx, u.Hostname()); errors.Is(err, webhook.ErrNonPublicAddress) {
+		return &domain.ValidationError{Field: "URL", Reason: "must point to a public address"}
+	} else if err != nil {
+		return &domain.ValidationError{Field: "URL", Reason: "host cannot be resolved"}
+	}
+	if len(subscription.Secret) < minWebhookSecretLength {
+		return &domain.ValidationError{Field: "Secret", Reason: "must be at least 16 characters"}
+	}
+	if len(subscription.Events) == 0 {
+		return &domain.ValidationError{Field: "Events", Reason: "is required"}
+	}
+	for _, filter := range subscription.Events {
+		if !validEventFilter(filter) {
+			return &domain.ValidationError{Field: "Events", Reason: "unknown event type " + filter}
+		}
+	}
+	return nil
+}
+
+// validEventFilter accepts "*", event types and prefixes like "contact.*"
+// that match at least one of them.
+func validEventFilter(filter string) bool {
+	if filter == "*" {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(filter, ".*")
+	for _, eventType := range domain.EventTypes {
+		if eventType == filter || wildcard && strings.HasPrefix(eventType, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (uc *webhookUseCaseImpl) GetWebhooks(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return uc.webhookRepo.GetSubscriptions(ctx)
+}
+
+func (uc *webhookUseCaseImpl) DeleteWebhook(ctx context.Context, subscriptionID string) error {
+	if _, err := uuid.Parse(subscriptionID); err != nil {
+		return domain.ErrNotFound
+	}
+	return uc.webhookRepo.DeleteSubscription(ctx, subscriptionID)
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a subscription
+// with their attempt log; status "dead" lists the dead letters.
+func (uc *webhookUseCaseImpl) GetWebhookDeliveries(ctx context.Context, subscriptionID, status string) ([]*domain.WebhookDelivery, error) {
+	switch status {
+	case "", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryDead:
+	default:
+		return nil, &domain.ValidationError{Field: "status", Reason: "must be pending, succeeded or dead"}
+	}
+	if _, err := uuid.Parse(subscriptionID); err != nil {
+		return nil, domain.ErrNotFound
+	}
+	return uc.webhookRepo.GetDeliveries(ctx, subscriptionID, status, maxWebhookDeliveries)
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		url   string
+		field string
+	}{
+		{"https://93.184.216.34/hooks", ""},
+		{"http://[2606:2800:220:1::1]:8080/hooks", ""},
+		{"ftp://93.184.216.34/hooks", "URL"},
+		{"/hooks", "URL"},
+		{"http://127.0.0.1:8080/hooks", "URL"},
+		{"http://localhost/hooks", "URL"},
+		{"http://[::1]/hooks", "URL"},
+		{"http://10.0.0.5/hooks", "URL"},
+		{"http://172.20.1.1/hooks", "URL"},
+		{"http://192.168.0.10/hooks", "URL"},
+		{"http://169.254.169.254/latest/meta-data/", "URL"},
+		{"http://[fe80::1%25eth0]/hooks", "URL"},
+		{"http://0.0.0.0/hooks", "URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validateWebhook(context.Background(), &domain.WebhookSubscription{
+				URL:    tt.url,
+				Secret: "0123456789abcdef",
+				Events: []string{"contact.*"},
+			})
+			var validationErr *domain.ValidationError
+			switch {
+			case tt.field == "" && err != nil:
+				t.Errorf("validateWebhook = %v, want nil", err)
+			case tt.field != "" && (!errors.As(err, &validationErr) || validationErr.Field != tt.field):
+				t.Errorf("validateWebhook = %v, want a validation error for %s", err, tt.field)
+			}
+		})
+	}
+}
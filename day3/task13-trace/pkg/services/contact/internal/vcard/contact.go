@@ -0,0 +1,244 @@
+package vcard
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+var ErrNoName = errors.New("vcard: card has neither FN nor N")
+
+// customFieldProperty carries a custom field, named by its X-NAME parameter.
+const customFieldProperty = "X-CONTACT-FIELD"
+
+// anniversaryProperty carries an anniversary, named by its X-LABEL
+// parameter. A standard ANNIVERSARY is read as one labelled
+// defaultAnniversaryLabel.
+const (
+	anniversaryProperty     = "X-CONTACT-ANNIVERSARY"
+	defaultAnniversaryLabel = "Anniversary"
+)
+
+// N components as defined by RFC 6350 section 6.2.2.
+const (
+	nFamily = iota
+	nGiven
+	nAdditional
+)
+
+// ADR components as defined by RFC 6350 section 6.3.1.
+const (
+	adrStreet = iota + 2
+	adrLocality
+	adrRegion
+	adrPostalCode
+	adrCountry
+)
+
+func ToContact(card *Card) (*domain.Contact, error) {
+	contact := &domain.Contact{}
+
+	var family string
+	if n := card.Get("N"); n != nil {
+		parts := n.Components()
+		family = component(parts, nFamily)
+		contact.FirstName = component(parts, nGiven)
+		contact.Patronymic = component(parts, nAdditional)
+	}
+
+	if fn := card.Get("FN"); fn != nil {
+		contact.FullName = strings.TrimSpace(fn.Text())
+	}
+	if contact.FullName == "" {
+		contact.FullName = joinNonEmpty(family, contact.FirstName, contact.Patronymic)
+	}
+	if contact.FullName == "" {
+		return nil, ErrNoName
+	}
+
+	if tel := card.Get("TEL"); tel != nil {
+		contact.PhoneNumber = strings.TrimPrefix(tel.Text(), "tel:")
+	}
+	if email := card.Get("EMAIL"); email != nil {
+		contact.Email = email.Text()
+	}
+	if adr := card.Get("ADR"); adr != nil {
+		parts := adr.Components()
+		contact.Address = domain.Address{
+			Street:     component(parts, adrStreet),
+			Locality:   component(parts, adrLocality),
+			Region:     component(parts, adrRegion),
+			PostalCode: component(parts, adrPostalCode),
+			Country:    component(parts, adrCountry),
+		}
+	}
+	if bday := card.Get("BDAY"); bday != nil {
+		birthday, err := parseDate(bday)
+		if err != nil {
+			return nil, err
+		}
+		contact.Birthday = &birthday
+	}
+	for _, prop := range card.Properties {
+		label := defaultAnniversaryLabel
+		switch {
+		case strings.EqualFold(prop.Name, anniversaryProperty):
+			if labels := prop.Params["X-LABEL"]; len(labels) > 0 {
+				label = labels[0]
+			}
+		case !strings.EqualFold(prop.Name, "ANNIVERSARY"):
+			continue
+		}
+		date, err := parseDate(prop)
+		if err != nil {
+			return nil, err
+		}
+		contact.Anniversaries = append(contact.Anniversaries, domain.Anniversary{Label: label, Date: date})
+	}
+	for _, categories := range card.All("CATEGORIES") {
+		contact.Tags = append(contact.Tags, categories.List()...)
+	}
+	// Values stay text here; checking them against the field's type turns
+	// them into numbers, dates or booleans.
+	for _, field := range card.All(customFieldProperty) {
+		if names := field.Params["X-NAME"]; len(names) > 0 {
+			if contact.CustomFields == nil {
+				contact.CustomFields = map[string]interface{}{}
+			}
+			contact.CustomFields[names[0]] = field.Text()
+		}
+	}
+
+	return contact, nil
+}
+
+func FromContact(contact *domain.Contact, version string) *Card {
+	card := &Card{Version: version}
+
+	if version == Version4 && contact.ID != "" {
+		card.Add(&Property{Name: "UID", Value: "urn:uuid:" + contact.ID})
+	} else if contact.ID != "" {
+		card.Add(&Property{Name: "UID", Value: Escape(contact.ID)})
+	}
+
+	card.Add(&Property{Name: "FN", Value: Escape(contact.FullName)})
+	card.Add(&Property{Name: "N", Value: JoinComponents(
+		familyName(contact), contact.FirstName, contact.Patronymic, "", "")})
+
+	if contact.PhoneNumber != "" {
+		if version == Version4 {
+			card.Add(&Property{
+				Name:   "TEL",
+				Params: map[string][]string{"VALUE": {"uri"}},
+				Value:  "tel:" + telURI(contact.PhoneNumber),
+			})
+		} else {
+			card.Add(&Property{Name: "TEL", Value: Escape(contact.PhoneNumber)})
+		}
+	}
+	if contact.Email != "" {
+		card.Add(&Property{Name: "EMAIL", Value: Escape(contact.Email)})
+	}
+	if contact.Address != (domain.Address{}) {
+		a := contact.Address
+		card.Add(&Property{Name: "ADR", Value: JoinComponents(
+			"", "", a.Street, a.Locality, a.Region, a.PostalCode, a.Country)})
+	}
+	if len(contact.Tags) > 0 {
+		tags := make([]string, len(contact.Tags))
+		for i, tag := range contact.Tags {
+			tags[i] = Escape(tag)
+		}
+		card.Add(&Property{Name: "CATEGORIES", Value: strings.Join(tags, ",")})
+	}
+	if contact.Birthday != nil {
+		card.Add(&Property{Name: "BDAY", Value: formatDate(*contact.Birthday, version)})
+	}
+	for _, a := range contact.Anniversaries {
+		card.Add(&Property{
+			Name:   anniversaryProperty,
+			Params: map[string][]string{"X-LABEL": {a.Label}},
+			Value:  formatDate(a.Date, version),
+		})
+	}
+	names := make([]string, 0, len(contact.CustomFields))
+	for name := range contact.CustomFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		card.Add(&Property{
+			Name:   customFieldProperty,
+			Params: map[string][]string{"X-NAME": {name}},
+			Value:  Escape(domain.FormatCustomValue(contact.CustomFields[name])),
+		})
+	}
+
+	return card
+}
+
+// parseDate reads the date of a BDAY or ANNIVERSARY, dropping the time
+// some writers add.
+func parseDate(prop *Property) (domain.Date, error) {
+	value, _, _ := strings.Cut(strings.TrimSpace(prop.Text()), "T")
+	date, err := domain.ParseDate(value)
+	if err != nil {
+		return domain.Date{}, fmt.Errorf("vcard: %s: %w", prop.Name, err)
+	}
+	return date, nil
+}
+
+// formatDate writes d in the basic form vCard 4 requires, 19900517 or
+// --0517, and in the extended form for vCard 3.
+func formatDate(d domain.Date, version string) string {
+	switch {
+	case version != Version4:
+		return d.String()
+	case d.Year == 0:
+		return fmt.Sprintf("--%02d%02d", d.Month, d.Day)
+	}
+	return fmt.Sprintf("%04d%02d%02d", d.Year, d.Month, d.Day)
+}
+
+// familyName recovers the surname from FullName, which is the only place the
+// domain model keeps it, by removing the first name and patronymic.
+func familyName(contact *domain.Contact) string {
+	var rest []string
+	for _, word := range strings.Fields(contact.FullName) {
+		if word == contact.FirstName || word == contact.Patronymic {
+			continue
+		}
+		rest = append(rest, word)
+	}
+	return strings.Join(rest, " ")
+}
+
+// telURI keeps only the characters RFC 3966 allows in a global number.
+func telURI(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '+' || r == '-' || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+func component(parts []string, i int) string {
+	if i >= len(parts) {
+		return ""
+	}
+	return strings.TrimSpace(parts[i])
+}
+
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
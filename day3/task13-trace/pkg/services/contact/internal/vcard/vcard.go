@@ -0,0 +1,387 @@
+package vcard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	Version3 = "3.0"
+	Version4 = "4.0"
+
+	MediaType = "text/vcard"
+
+	maxLineLength = 75
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("vcard: unsupported version")
+	ErrMalformed          = errors.New("vcard: malformed card")
+)
+
+type Property struct {
+	Group  string
+	Name   string
+	Params map[string][]string
+	Value  string
+}
+
+func (p *Property) HasType(t string) bool {
+	for _, v := range p.Params["TYPE"] {
+		if strings.EqualFold(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Components splits a structured value such as N or ADR on unescaped semicolons.
+func (p *Property) Components() []string {
+	parts := splitEscaped(p.Value, ';')
+	for i := range parts {
+		parts[i] = unescape(parts[i])
+	}
+	return parts
+}
+
+// List splits a value such as CATEGORIES on unescaped commas.
+func (p *Property) List() []string {
+	parts := splitEscaped(p.Value, ',')
+	for i := range parts {
+		parts[i] = unescape(parts[i])
+	}
+	return parts
+}
+
+func (p *Property) Text() string {
+	return unescape(p.Value)
+}
+
+type Card struct {
+	Version    string
+	Properties []*Property
+}
+
+func (c *Card) Get(name string) *Property {
+	props := c.All(name)
+	if len(props) == 0 {
+		return nil
+	}
+	for _, p := range props {
+		if p.HasType("pref") || len(p.Params["PREF"]) > 0 {
+			return p
+		}
+	}
+	return props[0]
+}
+
+func (c *Card) All(name string) []*Property {
+	var props []*Property
+	for _, p := range c.Properties {
+		if strings.EqualFold(p.Name, name) {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+func (c *Card) Add(p *Property) {
+	c.Properties = append(c.Properties, p)
+}
+
+// Decoder reads consecutive cards from a stream. A malformed card is reported
+// by Decode and skipped, so the caller can keep reading the rest of the file.
+type Decoder struct {
+	scanner *bufio.Scanner
+	pending string
+	// line counts the physical lines read; start is the one the content
+	// line last returned by next began on, and pendingStart that of pending.
+	line         int
+	start        int
+	pendingStart int
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &Decoder{scanner: scanner}
+}
+
+// next returns the next unfolded content line. Finding where it ends takes
+// reading the line after it, so d.start rather than d.line is where it is.
+func (d *Decoder) next() (string, bool) {
+	line, start := d.pending, d.pendingStart
+	d.pending = ""
+	for d.scanner.Scan() {
+		d.line++
+		raw := strings.TrimRight(d.scanner.Text(), "\r")
+		if strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t") {
+			if line == "" {
+				start = d.line
+			}
+			line += raw[1:]
+			continue
+		}
+		if line == "" {
+			line, start = raw, d.line
+			continue
+		}
+		d.pending, d.pendingStart = raw, d.line
+		d.start = start
+		return line, true
+	}
+	d.start = start
+	return line, line != ""
+}
+
+func (d *Decoder) Decode() (*Card, error) {
+	for {
+		line, ok := d.next()
+		if !ok {
+			if err := d.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.EqualFold(line, "BEGIN:VCARD") {
+			return nil, fmt.Errorf("%w: line %d: expected BEGIN:VCARD", ErrMalformed, d.start)
+		}
+		return d.decodeCard()
+	}
+}
+
+func (d *Decoder) decodeCard() (*Card, error) {
+	card := &Card{}
+	var cardErr error
+	for {
+		line, ok := d.next()
+		if !ok {
+			if err := d.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: missing END:VCARD", ErrMalformed)
+		}
+		if strings.EqualFold(line, "END:VCARD") {
+			break
+		}
+		if cardErr != nil || strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			cardErr = fmt.Errorf("%w: line %d: %v", ErrMalformed, d.start, err)
+			continue
+		}
+		if strings.EqualFold(prop.Name, "VERSION") {
+			card.Version = prop.Value
+			continue
+		}
+		card.Add(prop)
+	}
+	if cardErr != nil {
+		return nil, cardErr
+	}
+	if card.Version != Version3 && card.Version != Version4 {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedVersion, card.Version)
+	}
+	return card, nil
+}
+
+func parseProperty(line string) (*Property, error) {
+	colon := indexUnquoted(line, ':')
+	if colon < 0 {
+		return nil, errors.New("missing ':'")
+	}
+	head, value := line[:colon], line[colon+1:]
+
+	parts := splitQuoted(head, ';')
+	name := parts[0]
+	prop := &Property{Params: map[string][]string{}, Value: value}
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		prop.Group, name = name[:dot], name[dot+1:]
+	}
+	if name == "" {
+		return nil, errors.New("empty property name")
+	}
+	prop.Name = strings.ToUpper(name)
+
+	for _, param := range parts[1:] {
+		key, val, found := strings.Cut(param, "=")
+		if !found {
+			// vCard 2.1 style bare type, e.g. TEL;CELL:...
+			key, val = "TYPE", param
+		}
+		key = strings.ToUpper(key)
+		for _, v := range splitQuoted(val, ',') {
+			prop.Params[key] = append(prop.Params[key], strings.Trim(v, `"`))
+		}
+	}
+	return prop, nil
+}
+
+type Encoder struct {
+	w io.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+func (e *Encoder) Encode(card *Card) error {
+	version := card.Version
+	if version == "" {
+		version = Version4
+	}
+	if version != Version3 && version != Version4 {
+		return fmt.Errorf("%w %q", ErrUnsupportedVersion, version)
+	}
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\r\n")
+	b.WriteString("VERSION:" + version + "\r\n")
+	for _, p := range card.Properties {
+		writeFolded(&b, formatProperty(p))
+	}
+	b.WriteString("END:VCARD\r\n")
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func formatProperty(p *Property) string {
+	var b strings.Builder
+	if p.Group != "" {
+		b.WriteString(p.Group + ".")
+	}
+	b.WriteString(p.Name)
+	for _, key := range sortedKeys(p.Params) {
+		values := make([]string, len(p.Params[key]))
+		for i, v := range p.Params[key] {
+			if strings.ContainsAny(v, ",;:") {
+				v = `"` + v + `"`
+			}
+			values[i] = v
+		}
+		b.WriteString(";" + key + "=" + strings.Join(values, ","))
+	}
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+// writeFolded splits a content line into chunks of at most 75 octets without
+// breaking UTF-8 sequences, as required by RFC 6350 section 3.2.
+func writeFolded(b *strings.Builder, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func Escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+	return r.Replace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+// JoinComponents builds a structured value such as N or ADR.
+func JoinComponents(components ...string) string {
+	escaped := make([]string, len(components))
+	for i, c := range components {
+		escaped[i] = Escape(c)
+	}
+	return strings.Join(escaped, ";")
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func indexUnquoted(s string, c byte) int {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case c:
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
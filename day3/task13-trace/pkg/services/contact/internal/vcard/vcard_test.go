@@ -0,0 +1,323 @@
+package vcard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go/pkg/services/contact/internal/domain"
+)
+
+func decodeAll(t *testing.T, data string) []*Card {
+	t.Helper()
+	var cards []*Card
+	d := NewDecoder(strings.NewReader(data))
+	for {
+		card, err := d.Decode()
+		if err == io.EOF {
+			return cards
+		}
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		cards = append(cards, card)
+	}
+}
+
+func encode(t *testing.T, card *Card) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(card); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.String()
+}
+
+func TestDecodeV3(t *testing.T) {
+	data := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:Ivanov Ivan\r\n" +
+		"  Ivanovich\r\n" +
+		"N:Ivanov;Ivan;Ivanovich;;\r\n" +
+		"TEL;TYPE=work:+7 495 111-22-33\r\n" +
+		"item1.TEL;TYPE=cell,pref:+7 912 345-67-89\r\n" +
+		"EMAIL;TYPE=\"internet,home\":ivan@example.com\r\n" +
+		"ADR;TYPE=home:;;Tverskaya 1\\, apt. 2;Moscow;;125009;Russia\r\n" +
+		"NOTE:first line\\nsecond\\; third\r\n" +
+		"END:VCARD\r\n"
+
+	cards := decodeAll(t, data)
+	if len(cards) != 1 {
+		t.Fatalf("got %d cards, want 1", len(cards))
+	}
+	card := cards[0]
+	if card.Version != Version3 {
+		t.Errorf("Version = %q, want %q", card.Version, Version3)
+	}
+	if got := card.Get("FN").Text(); got != "Ivanov Ivan Ivanovich" {
+		t.Errorf("FN = %q, unfolded wrongly", got)
+	}
+	tel := card.Get("TEL")
+	if tel.Group != "item1" || tel.Value != "+7 912 345-67-89" {
+		t.Errorf("Get(TEL) = %+v, want the preferred number in group item1", tel)
+	}
+	if got := card.Get("EMAIL").Params["TYPE"]; !reflect.DeepEqual(got, []string{"internet,home"}) {
+		t.Errorf("EMAIL TYPE = %q, want the quoted value kept whole", got)
+	}
+	if got := card.Get("NOTE").Text(); got != "first line\nsecond; third" {
+		t.Errorf("NOTE = %q", got)
+	}
+
+	contact, err := ToContact(card)
+	if err != nil {
+		t.Fatalf("ToContact: %v", err)
+	}
+	want := &domain.Contact{
+		FullName:    "Ivanov Ivan Ivanovich",
+		FirstName:   "Ivan",
+		Patronymic:  "Ivanovich",
+		PhoneNumber: "+7 912 345-67-89",
+		Email:       "ivan@example.com",
+		Address: domain.Address{
+			Street:     "Tverskaya 1, apt. 2",
+			Locality:   "Moscow",
+			PostalCode: "125009",
+			Country:    "Russia",
+		},
+	}
+	if !reflect.DeepEqual(contact, want) {
+		t.Errorf("ToContact = %+v, want %+v", contact, want)
+	}
+}
+
+func TestDecodeSkipsMalformedCard(t *testing.T) {
+	data := "BEGIN:VCARD\nVERSION:4.0\nFN:One\nEND:VCARD\n" +
+		"BEGIN:VCARD\nVERSION:4.0\nno colon here\nEND:VCARD\n" +
+		"BEGIN:VCARD\nVERSION:2.1\nFN:Old\nEND:VCARD\n" +
+		"BEGIN:VCARD\nVERSION:4.0\nFN:Two\nEND:VCARD\n"
+
+	d := NewDecoder(strings.NewReader(data))
+	var names []string
+	var errs []error
+	for {
+		card, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		names = append(names, card.Get("FN").Text())
+	}
+	if !reflect.DeepEqual(names, []string{"One", "Two"}) {
+		t.Errorf("decoded %q, want One and Two", names)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrMalformed) || !errors.Is(errs[1], ErrUnsupportedVersion) {
+		t.Errorf("errors = %v, want ErrMalformed then ErrUnsupportedVersion", errs)
+	}
+}
+
+func TestDecodeErrorLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"property", "BEGIN:VCARD\nVERSION:4.0\nno colon here\nFN:One\nEND:VCARD\n", "line 3:"},
+		{"folded property", "BEGIN:VCARD\r\nVERSION:4.0\r\nNOTE;broken\r\n  over\r\n  lines\r\nEND:VCARD\r\n", "line 3:"},
+		{"after blank lines", "\n\nBEGIN:VCARD\nVERSION:4.0\n:empty\nEND:VCARD\n", "line 5:"},
+		{"before the card", "BEGIN:VCARD\nVERSION:4.0\nEND:VCARD\nFN:Stray\nBEGIN:VCARD\n", "line 4:"},
+	}
+	for _, tt := range tests {
+		d := NewDecoder(strings.NewReader(tt.data))
+		_, err := d.Decode()
+		if tt.name == "before the card" {
+			_, err = d.Decode()
+		}
+		if !errors.Is(err, ErrMalformed) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decode = %v, want ErrMalformed at %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeMissingEnd(t *testing.T) {
+	_, err := NewDecoder(strings.NewReader("BEGIN:VCARD\nVERSION:4.0\nFN:One\n")).Decode()
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("Decode = %v, want ErrMalformed", err)
+	}
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	note := strings.Repeat("Съешь же ещё этих мягких французских булок. ", 5)
+	card := &Card{Version: Version4}
+	card.Add(&Property{Name: "NOTE", Value: Escape(note)})
+	out := encode(t, card)
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line of %d octets, want at most %d: %q", len(line), maxLineLength, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a UTF-8 sequence: %q", line)
+		}
+	}
+
+	cards := decodeAll(t, out)
+	if len(cards) != 1 {
+		t.Fatalf("got %d cards, want 1", len(cards))
+	}
+	if got := cards[0].Get("NOTE").Text(); got != note {
+		t.Errorf("NOTE after round trip = %q, want %q", got, note)
+	}
+}
+
+func TestPropertyRoundTrip(t *testing.T) {
+	card := &Card{Version: Version3}
+	noParams := map[string][]string{}
+	card.Add(&Property{Name: "FN", Params: noParams, Value: Escape(`Back\slash, comma; semicolon`)})
+	card.Add(&Property{Name: "ADR", Params: noParams, Value: JoinComponents("", "", "Line 1\nLine 2", "Town; Ville", "", "", "")})
+	card.Add(&Property{Group: "item2", Name: "X-CUSTOM", Params: map[string][]string{"X-LABEL": {"a:b"}, "TYPE": {"x", "y"}}, Value: "v"})
+
+	cards := decodeAll(t, encode(t, card))
+	if len(cards) != 1 {
+		t.Fatalf("got %d cards, want 1", len(cards))
+	}
+	if !reflect.DeepEqual(cards[0], card) {
+		t.Errorf("round trip = %+v, want %+v", cards[0], card)
+	}
+	if got := cards[0].Get("FN").Text(); got != `Back\slash, comma; semicolon` {
+		t.Errorf("FN = %q", got)
+	}
+	adr := cards[0].Get("ADR").Components()
+	if adr[2] != "Line 1\nLine 2" || adr[3] != "Town; Ville" {
+		t.Errorf("ADR components = %q", adr)
+	}
+}
+
+func TestEncodeUnsupportedVersion(t *testing.T) {
+	err := NewEncoder(io.Discard).Encode(&Card{Version: "2.1"})
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Encode = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestContactRoundTrip(t *testing.T) {
+	contact := &domain.Contact{
+		FullName:    "Petrova Anna Sergeevna",
+		FirstName:   "Anna",
+		Patronymic:  "Sergeevna",
+		PhoneNumber: "+79123456789",
+		Email:       "anna@example.com",
+		Address: domain.Address{
+			Street:     "Nevsky 28; office 5",
+			Locality:   "Saint Petersburg",
+			Region:     "Leningrad, Oblast",
+			PostalCode: "191186",
+			Country:    "Russia",
+		},
+		Tags:         []string{"family", "vip, really"},
+		CustomFields: map[string]interface{}{"nickname": "Anya", "note": "two\nlines"},
+		Birthday:     &domain.Date{Month: time.February, Day: 29},
+		Anniversaries: []domain.Anniversary{
+			{Label: "Wedding", Date: domain.Date{Year: 2015, Month: time.June, Day: 20}},
+		},
+	}
+
+	for _, version := range []string{Version3, Version4} {
+		t.Run(version, func(t *testing.T) {
+			cards := decodeAll(t, encode(t, FromContact(contact, version)))
+			if len(cards) != 1 {
+				t.Fatalf("got %d cards, want 1", len(cards))
+			}
+			got, err := ToContact(cards[0])
+			if err != nil {
+				t.Fatalf("ToContact: %v", err)
+			}
+			if !reflect.DeepEqual(got, contact) {
+				t.Errorf("round trip = %+v, want %+v", got, contact)
+			}
+		})
+	}
+}
+
+func TestFromContactV4Forms(t *testing.T) {
+	contact := &domain.Contact{
+		ID:          "0b7e1f9c-58a5-4e8e-9f36-1f2a3c4d5e6f",
+		FullName:    "Ivan",
+		FirstName:   "Ivan",
+		PhoneNumber: "+7 (912) 345-67-89",
+		Birthday:    &domain.Date{Year: 1990, Month: time.May, Day: 17},
+	}
+	card := FromContact(contact, Version4)
+
+	if got := card.Get("UID").Value; got != "urn:uuid:"+contact.ID {
+		t.Errorf("UID = %q", got)
+	}
+	tel := card.Get("TEL")
+	if tel.Value != "tel:+7912345-67-89" || !reflect.DeepEqual(tel.Params["VALUE"], []string{"uri"}) {
+		t.Errorf("TEL = %+v, want a tel: URI", tel)
+	}
+	if got := card.Get("BDAY").Value; got != "19900517" {
+		t.Errorf("BDAY = %q, want the basic form", got)
+	}
+}
+
+func TestToContact(t *testing.T) {
+	tests := []struct {
+		name    string
+		card    string
+		want    *domain.Contact
+		wantErr error
+	}{
+		{
+			name: "name from N",
+			card: "N:Sidorov;Petr;;;\n",
+			want: &domain.Contact{FullName: "Sidorov Petr", FirstName: "Petr"},
+		},
+		{
+			name:    "no name",
+			card:    "EMAIL:x@example.com\n",
+			wantErr: ErrNoName,
+		},
+		{
+			name: "tel URI and dates with time",
+			card: "FN:A\nTEL;VALUE=uri:tel:+15551234567\nBDAY:19800101T120000Z\nANNIVERSARY:--0310\n",
+			want: &domain.Contact{
+				FullName:    "A",
+				PhoneNumber: "+15551234567",
+				Birthday:    &domain.Date{Year: 1980, Month: time.January, Day: 1},
+				Anniversaries: []domain.Anniversary{
+					{Label: "Anniversary", Date: domain.Date{Month: time.March, Day: 10}},
+				},
+			},
+		},
+		{
+			name: "categories",
+			card: "FN:A\nCATEGORIES:work,friends\nCATEGORIES:gym\n",
+			want: &domain.Contact{FullName: "A", Tags: []string{"work", "friends", "gym"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := decodeAll(t, "BEGIN:VCARD\nVERSION:4.0\n"+tt.card+"END:VCARD\n")
+			got, err := ToContact(cards[0])
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ToContact error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToContact = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	cards := decodeAll(t, "BEGIN:VCARD\nVERSION:4.0\nFN:A\nBDAY:1990-02-30\nEND:VCARD\n")
+	if _, err := ToContact(cards[0]); err == nil {
+		t.Error("ToContact accepted BDAY 1990-02-30")
+	}
+}
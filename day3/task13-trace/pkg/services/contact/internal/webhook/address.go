@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned for webhook hosts that are, or resolve
+// to, addresses inside the service's own network, so that subscribers
+// cannot make the service call its internal endpoints or the cloud
+// metadata service.
+var ErrNonPublicAddress = errors.New("webhook: address is not public")
+
+// nonPublicRanges are the special-purpose ranges that IsGlobalUnicast and
+// IsPrivate let through.
+var nonPublicRanges = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("fec0::/10"),
+}
+
+// PublicAddr reports whether webhooks may be sent to ip. Loopback,
+// private (RFC 1918 and fc00::/7), link-local (including
+// 169.254.169.254), multicast and other special-purpose addresses are
+// not public.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicRanges {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host, a name or an IP literal, and returns
+// ErrNonPublicAddress if any of its addresses is not public. The
+// dispatcher checks again when it connects, as the name may resolve
+// differently by then.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddr(ip) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+		}
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !PublicAddr(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNonPublicAddress, host, ip)
+		}
+	}
+	return nil
+}
+
+// dialControl is the net.Dialer Control of the default client. It runs
+// after name resolution with the address actually dialed, which closes
+// the gap CheckHost leaves to DNS rebinding.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+	}
+	return nil
+}
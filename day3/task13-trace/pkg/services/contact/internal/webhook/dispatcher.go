@@ -0,0 +1,218 @@
+// Package webhook sends the events in the outbox to webhook subscribers.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+// Envelope is the JSON body of a webhook request.
+type Envelope struct {
+	ID         int64
+	Type       string
+	OwnerID    string
+	OccurredAt time.Time
+	Data       json.RawMessage
+}
+
+// Dispatcher polls the outbox, fans events out into deliveries and sends
+// due deliveries. Failed deliveries are retried with exponential backoff
+// and dead-lettered after MaxAttempts. The fields may be changed before
+// Run.
+type Dispatcher struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Lease must exceed the client timeout, or a slow delivery may be
+	// claimed a second time.
+	Lease time.Duration
+
+	repo   repository.WebhookRepository
+	client *http.Client
+	logger *log.Logger
+	now    func() time.Time
+}
+
+// NewDispatcher uses client for the requests, or when client is nil a
+// client with a ten second timeout that does not follow redirects and
+// refuses to connect to addresses that are not public.
+func NewDispatcher(repo repository.WebhookRepository, client *http.Client, logger *log.Logger) *Dispatcher {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// Through a proxy the dialer would check the proxy's address
+		// rather than the subscriber's.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   dialControl,
+		}).DialContext
+		client = &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Dispatcher{
+		PollInterval: 2 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Lease:        time.Minute,
+		repo:         repo,
+		client:       client,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Run dispatches until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Printf("Webhook dispatch error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce fans out pending outbox events and makes one attempt for
+// each due delivery. It returns the number of attempts made.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	for {
+		n, err := d.repo.FanOutEvents(ctx, d.BatchSize)
+		if err != nil {
+			return 0, err
+		}
+		if n < d.BatchSize {
+			break
+		}
+	}
+
+	dispatches, err := d.repo.ClaimDeliveries(ctx, d.now(), d.Lease, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, dispatch := range dispatches {
+		wg.Add(1)
+		go func(dispatch *domain.WebhookDispatch) {
+			defer wg.Done()
+			d.deliver(ctx, dispatch)
+		}(dispatch)
+	}
+	wg.Wait()
+	return len(dispatches), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, dispatch *domain.WebhookDispatch) {
+	delivery := &dispatch.Delivery
+	attempt := d.Send(ctx, dispatch)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the lease runs out and the delivery is
+		// attempted again.
+		return
+	}
+
+	delivery.Attempts = attempt.Attempt
+	delivery.NextAttemptAt = nil
+	switch {
+	case attempt.Error == "":
+		delivery.Status = domain.DeliverySucceeded
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = domain.DeliveryDead
+		d.logger.Printf("Webhook delivery %s of event %d is dead after %d attempts: %s\n",
+			delivery.ID, delivery.EventID, delivery.Attempts, attempt.Error)
+	default:
+		next := attempt.AttemptedAt.Add(Backoff(d.BaseBackoff, d.MaxBackoff, delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := d.repo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		d.logger.Printf("Could not record webhook attempt for delivery %s: %v\n", delivery.ID, err)
+	}
+}
+
+// Send makes one attempt to deliver dispatch. Any response other than 2xx
+// is a failure.
+func (d *Dispatcher) Send(ctx context.Context, dispatch *domain.WebhookDispatch) domain.WebhookAttempt {
+	start := d.now()
+	attempt := domain.WebhookAttempt{Attempt: dispatch.Delivery.Attempts + 1, AttemptedAt: start}
+
+	body, err := json.Marshal(Envelope{
+		ID:         dispatch.Event.ID,
+		Type:       dispatch.Event.Type,
+		OwnerID:    dispatch.Event.OwnerID,
+		OccurredAt: dispatch.Event.OccurredAt,
+		Data:       dispatch.Event.Data,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "contact-service-webhooks")
+	req.Header.Set(HeaderEvent, dispatch.Event.Type)
+	req.Header.Set(HeaderDelivery, dispatch.Delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(dispatch.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	attempt.DurationMS = d.now().Sub(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.ResponseStatus = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return attempt
+}
+
+// Backoff is the delay after the given number of failed attempts: base,
+// then doubling up to max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
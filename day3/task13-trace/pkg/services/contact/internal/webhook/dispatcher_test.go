@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go/pkg/services/contact/internal/domain"
+	"go/pkg/services/contact/internal/repository"
+)
+
+const testSecret = "0123456789abcdef"
+
+// fakeRepo holds one delivery and hands it out whenever it is due.
+type fakeRepo struct {
+	repository.WebhookRepository
+
+	mu       sync.Mutex
+	dispatch *domain.WebhookDispatch
+	attempts []domain.WebhookAttempt
+}
+
+func (r *fakeRepo) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeRepo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDispatch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery := r.dispatch.Delivery
+	if delivery.Status != domain.DeliveryPending || delivery.NextAttemptAt != nil && delivery.NextAttemptAt.After(now) {
+		return nil, nil
+	}
+	dispatch := *r.dispatch
+	return []*domain.WebhookDispatch{&dispatch}, nil
+}
+
+func (r *fakeRepo) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dispatch.Delivery = *delivery
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func newTestDispatch(url string) *domain.WebhookDispatch {
+	return &domain.WebhookDispatch{
+		Delivery: domain.WebhookDelivery{
+			ID:        "delivery-1",
+			OwnerID:   "tenant-1",
+			EventID:   42,
+			EventType: domain.EventContactCreated,
+			Status:    domain.DeliveryPending,
+		},
+		URL:    url,
+		Secret: testSecret,
+		Event: domain.Event{
+			ID:         42,
+			OwnerID:    "tenant-1",
+			Type:       domain.EventContactCreated,
+			OccurredAt: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+			Data:       json.RawMessage(`{"ID":"c1"}`),
+		},
+	}
+}
+
+// receiver answers with the statuses in order, repeating the last, and
+// fails the test on requests whose signature does not verify.
+func receiver(t *testing.T, now func() time.Time, statuses ...int) (*httptest.Server, *int) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(testSecret, r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, now(), 5*time.Minute) {
+			t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+		}
+		if Verify("another secret!!", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, now(), 5*time.Minute) {
+			t.Error("signature verifies with another secret")
+		}
+		var envelope Envelope
+		if err := json.Unmarshal(body, &envelope); err != nil || envelope.ID != 42 || envelope.Type != domain.EventContactCreated {
+			t.Errorf("body = %s, %v", body, err)
+		}
+		if r.Header.Get(HeaderEvent) != domain.EventContactCreated || r.Header.Get(HeaderDelivery) != "delivery-1" {
+			t.Errorf("headers = %v", r.Header)
+		}
+
+		mu.Lock()
+		status := statuses[min(requests, len(statuses)-1)]
+		requests++
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestDispatcher(t *testing.T, repo *fakeRepo, client *http.Client, clock *time.Time) *Dispatcher {
+	d := NewDispatcher(repo, client, log.New(io.Discard, "", 0))
+	d.MaxAttempts = 3
+	d.BaseBackoff = time.Minute
+	d.MaxBackoff = time.Hour
+	d.now = func() time.Time { return *clock }
+	return d
+}
+
+// dispatchUntilIdle runs the dispatcher, moving the clock to each
+// retry, until the delivery is no longer pending.
+func dispatchUntilIdle(t *testing.T, d *Dispatcher, repo *fakeRepo, clock *time.Time) {
+	for i := 0; i < 10; i++ {
+		n, err := d.DispatchOnce(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Fatalf("DispatchOnce made %d attempts, want 1", n)
+		}
+		delivery := repo.dispatch.Delivery
+		if delivery.Status != domain.DeliveryPending {
+			return
+		}
+		if n, _ := d.DispatchOnce(context.Background()); n != 0 {
+			t.Fatal("delivery was retried before its backoff ran out")
+		}
+		*clock = *delivery.NextAttemptAt
+	}
+	t.Fatal("delivery is still pending")
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	clock := time.Now().Truncate(time.Second)
+	server, requests := receiver(t, func() time.Time { return clock }, 500, 503, 204)
+	repo := &fakeRepo{dispatch: newTestDispatch(server.URL + "/hook")}
+	d := newTestDispatcher(t, repo, server.Client(), &clock)
+
+	start := clock
+	dispatchUntilIdle(t, d, repo, &clock)
+
+	if got := repo.dispatch.Delivery; got.Status != domain.DeliverySucceeded || got.Attempts != 3 || got.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want succeeded on the third attempt", got)
+	}
+	if *requests != 3 || len(repo.attempts) != 3 {
+		t.Fatalf("%d requests and %d recorded attempts, want 3", *requests, len(repo.attempts))
+	}
+	// The retries wait BaseBackoff, then twice that.
+	wantAt := []time.Time{start, start.Add(time.Minute), start.Add(3 * time.Minute)}
+	wantStatus := []int{500, 503, 204}
+	for i, attempt := range repo.attempts {
+		if attempt.Attempt != i+1 || !attempt.AttemptedAt.Equal(wantAt[i]) || attempt.ResponseStatus != wantStatus[i] {
+			t.Errorf("attempt %d = %+v, want status %d at %v", i+1, attempt, wantStatus[i], wantAt[i])
+		}
+		if failed := attempt.Error != ""; failed != (i < 2) {
+			t.Errorf("attempt %d error = %q", i+1, attempt.Error)
+		}
+	}
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	clock := time.Now().Truncate(time.Second)
+	server, requests := receiver(t, func() time.Time { return clock }, http.StatusInternalServerError)
+	repo := &fakeRepo{dispatch: newTestDispatch(server.URL)}
+	d := newTestDispatcher(t, repo, server.Client(), &clock)
+
+	dispatchUntilIdle(t, d, repo, &clock)
+
+	if got := repo.dispatch.Delivery; got.Status != domain.DeliveryDead || got.Attempts != d.MaxAttempts || got.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want dead after %d attempts", got, d.MaxAttempts)
+	}
+	if *requests != d.MaxAttempts {
+		t.Errorf("%d requests, want %d", *requests, d.MaxAttempts)
+	}
+	clock = clock.Add(24 * time.Hour)
+	if n, _ := d.DispatchOnce(context.Background()); n != 0 {
+		t.Error("dead delivery was attempted again")
+	}
+}
+
+func TestDefaultClientRefusesNonPublicAddresses(t *testing.T) {
+	clock := time.Now()
+	server, requests := receiver(t, func() time.Time { return clock }, http.StatusNoContent)
+	d := newTestDispatcher(t, &fakeRepo{}, nil, &clock)
+
+	for _, url := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		attempt := d.Send(context.Background(), newTestDispatch(url))
+		if !strings.Contains(attempt.Error, ErrNonPublicAddress.Error()) {
+			t.Errorf("Send(%s) error = %q, want %v", url, attempt.Error, ErrNonPublicAddress)
+		}
+	}
+	if *requests != 0 {
+		t.Errorf("receiver got %d requests", *requests)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		if got := Backoff(time.Second, 10*time.Second, attempts); got != want {
+			t.Errorf("Backoff after %d attempts = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:2800:220:1::1":     true,
+		"127.0.0.1":              false,
+		"::1":                    false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"fe80::1":                false,
+		"fd00:ec2::254":          false,
+		"100.100.100.200":        false,
+		"0.0.0.0":                false,
+		"::":                     false,
+		"224.0.0.1":              false,
+		"255.255.255.255":        false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"64:ff9b::a9fe:a9fe":     false,
+	} {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "::1", "localhost"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("CheckHost(%s) = %v, want ErrNonPublicAddress", host, err)
+		}
+	}
+	if err := CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("CheckHost(93.184.216.34) = %v", err)
+	}
+}
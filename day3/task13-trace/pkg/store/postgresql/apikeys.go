@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go/pkg/auth"
+)
+
+var ErrAPIKeyNotFound = errors.New("postgresql: API key not found")
+
+const apiKeyColumns = `id, tenant_id, name, prefix, key_hash, access, created_by,
+	created_at, expires_at, revoked_at, last_used_at`
+
+// lastUsedResolution limits last_used_at writes to one per key and minute,
+// so busy keys do not turn every request into an UPDATE.
+const lastUsedResolution = time.Minute
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*auth.APIKey, error) {
+	key := &auth.APIKey{}
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.TenantID, &key.Name, &key.Prefix, &key.Hash, &key.Access,
+		&key.CreatedBy, &key.CreatedAt, &expiresAt, &revokedAt, &lastUsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = nullTime(expiresAt)
+	key.RevokedAt = nullTime(revokedAt)
+	key.LastUsedAt = nullTime(lastUsedAt)
+	return key, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *auth.APIKey) error {
+	query := `INSERT INTO api_keys (tenant_id, name, prefix, key_hash, access, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, key.TenantID, key.Name, key.Prefix, key.Hash, key.Access,
+		key.CreatedBy, key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetAPIKeyByHash finds a key of any tenant; it is what authentication
+// uses before a tenant is known.
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+}
+
+func (r *APIKeyRepository) GetAPIKeys(ctx context.Context, tenantID string) ([]*auth.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE tenant_id = $1 ORDER BY created_at, id"
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*auth.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks a key of the tenant as revoked. Revoking a key twice
+// keeps the first revocation time.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID, keyID string) (*auth.APIKey, error) {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1 AND tenant_id = $2
+		RETURNING ` + apiKeyColumns
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, keyID, tenantID))
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`
+	_, err := r.db.ExecContext(ctx, query, keyID, usedAt, usedAt.Add(-lastUsedResolution))
+	return err
+}
@@ -0,0 +1,95 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go/pkg/idempotency"
+)
+
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+func (s *IdempotencyStore) Begin(ctx context.Context, tenantID, key, fingerprint string, now time.Time, ttl time.Duration) (*idempotency.Claim, *idempotency.Record, error) {
+	// created_at identifies the claim, so it must read back as stored.
+	now = now.Truncate(time.Microsecond)
+	claim := `INSERT INTO idempotency_keys (tenant_id, key, fingerprint, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $4
+			OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at <= $6)`
+	get := `SELECT fingerprint, status, headers, body, created_at, expires_at
+		FROM idempotency_keys WHERE tenant_id = $1 AND key = $2`
+
+	// A record can be released between the claim and the read, in which
+	// case claiming again succeeds.
+	for attempt := 0; ; attempt++ {
+		res, err := s.db.ExecContext(ctx, claim, tenantID, key, fingerprint, now, now.Add(ttl), now.Add(-idempotency.PendingTimeout))
+		if err != nil {
+			return nil, nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, nil, err
+		}
+		if n == 1 {
+			return &idempotency.Claim{TenantID: tenantID, Key: key, Fingerprint: fingerprint, CreatedAt: now}, nil, nil
+		}
+
+		record := &idempotency.Record{TenantID: tenantID, Key: key}
+		var status sql.NullInt64
+		var headers []byte
+		err = s.db.QueryRowContext(ctx, get, tenantID, key).Scan(&record.Fingerprint, &status, &headers,
+			&record.Body, &record.CreatedAt, &record.ExpiresAt)
+		if errors.Is(err, sql.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		record.Status = int(status.Int64)
+		if headers != nil {
+			if err := json.Unmarshal(headers, &record.Header); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, record, nil
+	}
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, claim *idempotency.Claim, status int, header http.Header, body []byte) error {
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE idempotency_keys SET status = $5, headers = $6, body = $7
+		WHERE tenant_id = $1 AND key = $2 AND fingerprint = $3 AND created_at = $4 AND status IS NULL`
+	_, err = s.db.ExecContext(ctx, query, claim.TenantID, claim.Key, claim.Fingerprint, claim.CreatedAt, status, headers, body)
+	return err
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, claim *idempotency.Claim) error {
+	query := `DELETE FROM idempotency_keys
+		WHERE tenant_id = $1 AND key = $2 AND fingerprint = $3 AND created_at = $4 AND status IS NULL`
+	_, err := s.db.ExecContext(ctx, query, claim.TenantID, claim.Key, claim.Fingerprint, claim.CreatedAt)
+	return err
+}
+
+func (s *IdempotencyStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
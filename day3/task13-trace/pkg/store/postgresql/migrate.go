@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+    "database/sql"
+    "embed"
+    "fmt"
+    "sort"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+func Migrate(db *sql.DB) error {
+    _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (name TEXT PRIMARY KEY)")
+    if err != nil {
+        return err
+    }
+
+    entries, err := migrations.ReadDir("migrations")
+    if err != nil {
+        return err
+    }
+    names := make([]string, 0, len(entries))
+    for _, entry := range entries {
+        names = append(names, entry.Name())
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        var applied bool
+        err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE name = $1)", name).Scan(&applied)
+        if err != nil {
+            return err
+        }
+        if applied {
+            continue
+        }
+
+        script, err := migrations.ReadFile("migrations/" + name)
+        if err != nil {
+            return err
+        }
+
+        tx, err := db.Begin()
+        if err != nil {
+            return err
+        }
+        if _, err := tx.Exec(string(script)); err != nil {
+            tx.Rollback()
+            return fmt.Errorf("migration %s: %w", name, err)
+        }
+        if _, err := tx.Exec("INSERT INTO schema_migrations (name) VALUES ($1)", name); err != nil {
+            tx.Rollback()
+            return err
+        }
+        if err := tx.Commit(); err != nil {
+            return err
+        }
+        fmt.Println("Applied migration", name)
+    }
+
+    return nil
+}
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS contacts (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    full_name           TEXT NOT NULL,
+    first_name          TEXT NOT NULL DEFAULT '',
+    patronymic          TEXT NOT NULL DEFAULT '',
+    phone_number        TEXT NOT NULL DEFAULT '',
+    email               TEXT NOT NULL DEFAULT '',
+    address_street      TEXT NOT NULL DEFAULT '',
+    address_locality    TEXT NOT NULL DEFAULT '',
+    address_region      TEXT NOT NULL DEFAULT '',
+    address_postal_code TEXT NOT NULL DEFAULT '',
+    address_country     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_contacts (
+    group_id   UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    contact_id UUID NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, contact_id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS contact_history (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    contact_id UUID NOT NULL,
+    action     TEXT NOT NULL,
+    details    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS contact_history_contact_id_idx ON contact_history (contact_id, created_at);
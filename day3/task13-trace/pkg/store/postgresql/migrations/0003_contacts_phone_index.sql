@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS contacts_phone_number_idx ON contacts (phone_number);
@@ -0,0 +1,15 @@
+-- Rows created before tenants existed keep an empty owner and are not
+-- visible to any tenant until they are assigned one.
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ALTER COLUMN owner_id DROP DEFAULT;
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE groups ALTER COLUMN owner_id DROP DEFAULT;
+
+ALTER TABLE contact_history ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE contact_history ALTER COLUMN owner_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS contacts_phone_number_idx;
+CREATE INDEX IF NOT EXISTS contacts_owner_phone_number_idx ON contacts (owner_id, phone_number);
+CREATE INDEX IF NOT EXISTS contacts_owner_full_name_idx ON contacts (owner_id, full_name, id);
+CREATE INDEX IF NOT EXISTS groups_owner_name_idx ON groups (owner_id, name, id);
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id   TEXT NOT NULL,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    events     TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_owner_idx ON webhook_subscriptions (owner_id, created_at);
+
+-- Written in the transaction of the change an event describes; the
+-- dispatcher turns undispatched events into deliveries.
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id            BIGSERIAL PRIMARY KEY,
+    owner_id      TEXT NOT NULL,
+    event_type    TEXT NOT NULL,
+    payload       JSONB NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_outbox_undispatched_idx ON webhook_outbox (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id        TEXT NOT NULL,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        BIGINT NOT NULL REFERENCES webhook_outbox (id),
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'dead')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    delivery_id     UUID NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt         INTEGER NOT NULL,
+    attempted_at    TIMESTAMPTZ NOT NULL,
+    response_status INTEGER,
+    error           TEXT NOT NULL DEFAULT '',
+    duration_ms     INTEGER NOT NULL,
+    PRIMARY KEY (delivery_id, attempt)
+);
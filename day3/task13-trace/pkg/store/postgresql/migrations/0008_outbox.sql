@@ -0,0 +1,9 @@
+-- The webhook outbox becomes the outbox of all domain events. The relay
+-- keeps track of what it published apart from the webhook fan-out.
+ALTER TABLE IF EXISTS webhook_outbox RENAME TO outbox;
+ALTER SEQUENCE IF EXISTS webhook_outbox_id_seq RENAME TO outbox_id_seq;
+ALTER INDEX IF EXISTS webhook_outbox_undispatched_idx RENAME TO outbox_undispatched_idx;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
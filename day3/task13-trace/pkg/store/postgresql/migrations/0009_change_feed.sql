@@ -0,0 +1,13 @@
+-- Every tenant's events are numbered 1, 2, 3, ... in commit order; the
+-- change feed pages through them by this number. Writers take a per-tenant
+-- advisory lock before numbering, so a number is never committed after a
+-- higher one.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE outbox SET seq = numbered.seq
+FROM (SELECT id, row_number() OVER (PARTITION BY owner_id ORDER BY id) AS seq FROM outbox) AS numbered
+WHERE outbox.id = numbered.id AND outbox.seq IS NULL;
+
+ALTER TABLE outbox ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_owner_seq_idx ON outbox (owner_id, seq);
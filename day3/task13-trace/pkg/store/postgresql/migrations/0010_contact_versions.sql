@@ -0,0 +1,6 @@
+-- version counts the writes to a contact. field_clock maps a field name to
+-- the version that last changed it and when, {"Email": {"Version": 3,
+-- "ModifiedAt": "..."}}; fields missing from it have not changed since the
+-- contact was created. Sync compares both with what a client last saw.
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS field_clock JSONB NOT NULL DEFAULT '{}';
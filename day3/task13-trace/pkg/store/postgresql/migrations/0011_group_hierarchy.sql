@@ -0,0 +1,6 @@
+-- A group may sit under a parent group of the same tenant; groups without
+-- one are roots. Deleting a group moves its children to its own parent, so
+-- SET NULL only applies to rows changed outside the repository.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES groups (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS groups_owner_parent_idx ON groups (owner_id, parent_id);
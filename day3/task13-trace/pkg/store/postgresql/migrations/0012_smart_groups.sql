@@ -0,0 +1,3 @@
+-- A group with a rule is a smart group: its members are the contacts that
+-- match the rule rather than those listed in group_contacts.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS rule TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+-- tags is a JSON array of strings; custom_fields a JSON object holding the
+-- values of the fields defined in custom_fields, by name. The GIN indexes
+-- serve the containment queries that filter contacts by both.
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS contacts_tags_idx ON contacts USING GIN (tags jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS contacts_custom_fields_idx ON contacts USING GIN (custom_fields jsonb_path_ops);
+
+CREATE TABLE IF NOT EXISTS custom_fields (
+    owner_id TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    type     TEXT NOT NULL CHECK (type IN ('string', 'number', 'date', 'boolean')),
+    PRIMARY KEY (owner_id, name)
+);
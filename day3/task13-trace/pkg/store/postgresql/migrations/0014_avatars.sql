@@ -0,0 +1,11 @@
+-- What is known about contact photos; the photos themselves are in blob
+-- storage, under keys made of the contact ID and the etag.
+CREATE TABLE IF NOT EXISTS avatars (
+    contact_id   UUID PRIMARY KEY REFERENCES contacts (id) ON DELETE CASCADE,
+    owner_id     TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    etag         TEXT NOT NULL,
+    width        INTEGER NOT NULL,
+    height       INTEGER NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
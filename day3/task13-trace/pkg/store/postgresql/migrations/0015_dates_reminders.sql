@@ -0,0 +1,22 @@
+-- birthday holds a date written 2006-01-02, or --01-02 without a year, and
+-- '' if unknown; anniversaries a JSON array of {Label, Date}. event_days
+-- lists the month and day, '01-02', of each, so the GIN index finds the
+-- contacts whose dates come round soon.
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS birthday TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS anniversaries JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS event_days TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS contacts_event_days_idx ON contacts USING GIN (event_days);
+
+-- The reminders the scheduler has sent, one per time a date comes round,
+-- so that none is sent twice. Rows for days gone by are purged.
+CREATE TABLE IF NOT EXISTS reminders_sent (
+    contact_id UUID NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    kind       TEXT NOT NULL,
+    label      TEXT NOT NULL,
+    occurs_on  DATE NOT NULL,
+    sent_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (contact_id, kind, label, occurs_on)
+);
+
+CREATE INDEX IF NOT EXISTS reminders_sent_occurs_on_idx ON reminders_sent (occurs_on);
@@ -0,0 +1,14 @@
+-- Calls, meetings and other interactions logged against a contact. Notes
+-- go with their contact; merging contacts moves them to the survivor.
+CREATE TABLE IF NOT EXISTS contact_notes (
+    id          UUID PRIMARY KEY,
+    contact_id  UUID NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    owner_id    TEXT NOT NULL,
+    author      TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('note', 'call', 'meeting', 'email')),
+    body        TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS contact_notes_contact_id_idx ON contact_notes (contact_id, occurred_at DESC, id DESC);
@@ -0,0 +1,23 @@
+-- Avatars of deleted contacts whose photos are still in blob storage. A
+-- contact can be deleted by several paths, and its avatar row goes with it
+-- by cascade, so a trigger queues the photo in the same transaction; the
+-- avatar sweeper deletes the blobs and then the row.
+CREATE TABLE IF NOT EXISTS deleted_avatars (
+    id         BIGSERIAL PRIMARY KEY,
+    contact_id UUID NOT NULL,
+    owner_id   TEXT NOT NULL,
+    etag       TEXT NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION queue_deleted_avatar() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO deleted_avatars (contact_id, owner_id, etag)
+        SELECT contact_id, owner_id, etag FROM avatars WHERE contact_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS contacts_queue_deleted_avatar ON contacts;
+CREATE TRIGGER contacts_queue_deleted_avatar BEFORE DELETE ON contacts
+    FOR EACH ROW EXECUTE FUNCTION queue_deleted_avatar();